@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
 	"syscall"
 	"time"
 
@@ -21,37 +27,131 @@ import (
 )
 
 var (
+	// cfg is the config the server started with. Settings that need a restart are read from it;
+	// those that reload live are applied by watchConfig or read from configs.
 	cfg                *config.Config
+	configs            *config.Manager
 	shutdownTimeoutSec time.Duration = 5
 )
 
-func init() {
-	var err error
-	cfg, err = config.NewConfig(getConfigFile())
+// configPath is the config file read at startup and on reload, or "" when there is none
+var configPath string
+
+// devMode runs the server on in-memory storage and rate limiting, with no PostgreSQL or Redis
+var devMode = flag.Bool("dev", false, "run with in-memory storage and no PostgreSQL or Redis; data is lost on exit")
+
+// configFile names the config file; it can also be given as the only argument when serving
+var configFile = flag.String("config", "", "path of the config file (default config.yaml, when it exists)")
+
+// printConfig prints the effective config, secrets redacted, instead of running
+var printConfig = flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+
+// configFlags holds the values of the flags that override single config settings
+var configFlags = config.RegisterFlags(flag.CommandLine)
+
+// getConfigFile returns the config file to read, or "" when there is none: settings then come
+// from the defaults, TODO_* environment variables and flags alone.
+func getConfigFile() string {
+	if *configFile != "" {
+		return *configFile
+	}
+	if flag.NArg() > 0 && flag.Arg(0) != "migrate" {
+		return flag.Arg(0)
+	}
+	const configFilePath = "config.yaml"
+	if _, err := os.Stat(configFilePath); err != nil {
+		slog.Info(fmt.Sprintf("No config file found at %s, using defaults and %s* environment variables", configFilePath, config.EnvPrefix))
+		return ""
+	}
+	return configFilePath
+}
+
+// loadConfig reads the config the server starts with
+func loadConfig() *config.Config {
+	configPath = getConfigFile()
+	cfg, err := readConfig(nil)
 	if err != nil {
 		log.Fatal(err)
 	}
+	return cfg
 }
 
-func getConfigFile() string {
-	// TODO: Improve this with ENV based config.
-	configFilePath := "config.yaml" // Default config file path
-	if len(os.Args) > 1 {
-		return os.Args[1] // Update if an argument is provided
+// readConfig reads the config and checks that the settings the command needs are there. Dev mode
+// needs none: it falls back to a local port and a JWT secret generated at startup, which is kept
+// for the config that replaces current.
+func readConfig(current *config.Config) (*config.Config, error) {
+	var required []string
+	serving := false
+	switch {
+	case flag.Arg(0) == "migrate":
+		required = []string{"dsn"}
+	case !*devMode && !*printConfig:
+		required = []string{"port", "dsn", "jwt_secret_key"}
+		serving = true
 	}
-	log.Printf("No config file specified, using default: %s", configFilePath)
-	return configFilePath
+	cfg, err := config.Load(configPath, configFlags, required...)
+	if err == nil && serving && cfg.RedisAddress == "" {
+		// SQLite can do without Redis, PostgreSQL deployments cannot
+		if backend, _ := repository.ParseDSN(cfg.DSN); backend != repository.BackendSQLite {
+			return nil, &config.ValidationError{Problems: []string{"redis_address: is required unless dsn is a SQLite database"}}
+		}
+	}
+	if err != nil || !*devMode {
+		return cfg, err
+	}
+
+	if cfg.Port == "" {
+		cfg.Port = ":8080"
+	}
+	if cfg.JwtSecretKey == "" && current != nil {
+		cfg.JwtSecretKey = current.JwtSecretKey
+	} else if cfg.JwtSecretKey == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate a JWT secret: %w", err)
+		}
+		cfg.JwtSecretKey = hex.EncodeToString(secret)
+	}
+	return cfg, nil
 }
 
 func main() {
 	// validates the command line arguments for config file,
 	// for production it can be improved with os.Executable() to find te relative path
+	flag.Parse()
+	cfg = loadConfig()
+	configs = config.NewManager(cfg, readConfig)
+	setLogLevel(cfg.LogLevel)
+
+	if *printConfig {
+		fmt.Print(cfg)
+		return
+	}
 
-	db := initDB()
-	rdb := initRedisDB()
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *devMode {
+		runDev()
+		return
+	}
 
-	defer db.Close()  // Ensure database connection is closed on exit
-	defer rdb.Close() // Closing the redis connection
+	backend, dsn := repository.ParseDSN(cfg.DSN)
+	db := initDB(backend, dsn)
+	defer db.Close() // Ensure database connection is closed on exit
+
+	// Without redis_address, which only SQLite allows, the server runs as in dev mode: requests are
+	// counted in memory, and there is no login throttling, idempotency store or todo cache
+	var rdb *redis.Client
+	if cfg.RedisAddress != "" {
+		rdb = initRedisDB()
+		defer rdb.Close() // Closing the redis connection
+	} else {
+		slog.Warn("no redis_address: rate limiting in memory, without login throttling, Idempotency-Key support or todo cache")
+	}
 
 	shutdown := setupSignalHandler()
 	jobChannel := make(chan worker.Job, 10) // Buffered channel for jobs
@@ -59,18 +159,57 @@ func main() {
 
 	pool := setupWorkerPool(ctx, jobChannel)
 
-	ratelLimiter := router.NewRedisRateLimiter(ctx, rdb, 100, 10*time.Second)
+	var features []router.Option
+	var rateLimits *router.RateLimits
+	if rdb != nil {
+		failover := initLimiterFailover()
+		rateLimits = initRateLimits(func(name string, limit int, period time.Duration) (router.RateLimiter, error) {
+			limiter, err := router.NewRedisLimiter(ctx, rdb, configs.Current().RateLimitAlgorithm, name, limit, period)
+			if err != nil {
+				return nil, err
+			}
+			return failover.Wrap(limiter, limit, period), nil
+		})
+		features = append(features,
+			router.WithMetrics("rate_limiter", failover),
+			router.WithLoginThrottler(router.NewRedisLoginThrottler(rdb, router.DefaultUserLoginPolicy, router.DefaultIPLoginPolicy)),
+			router.WithIdempotencyStore(router.NewRedisIdempotencyStore(rdb, router.IdempotencyKeyTTL)),
+		)
+	} else {
+		// Counting in memory is always a fixed window, whatever the configured algorithm
+		rateLimits = initRateLimits(func(name string, limit int, period time.Duration) (router.RateLimiter, error) {
+			return router.NewMemoryRateLimiter(limit, period), nil
+		})
+	}
 
-	userRepo := repository.NewPostgresUserRepository(db)
-	todoRepo := repository.NewPostgresToDoRepository(db)
+	replicas := initReplicas(ctx, backend, db)
+	if replicas != nil {
+		defer replicas.Close()
+	}
 
-	userService := service.NewUserService(userRepo)
-	todoService := service.NewTodoService(todoRepo)
+	var todoCache *service.TodoCache
+	if cfg.TodoCache && rdb != nil {
+		todoCache = service.NewTodoCache(rdb, time.Duration(cfg.TodoCacheTTLSeconds)*time.Second)
+	}
+
+	todoService, userService, backendFeatures := setupServices(ctx, backend, db, replicas, todoCache)
+	features = append(features, backendFeatures...)
+	healthProbe := repository.NewHealthProbe(db, time.Duration(cfg.DBHealthCheckIntervalSeconds)*time.Second, 2*time.Second)
+	healthProbe.Start(ctx)
 	jwtService := service.NewJWTService(cfg.JwtSecretKey)
+	startRetentionJob(ctx, "trash", cfg.TrashRetentionDays, todoService.PurgeTrash)
 
 	emailSender := &mocks.MockEmailSender{}
 
-	todoHandler := setupServer(todoService, userService, jwtService, ratelLimiter, pool, emailSender)
+	if todoCache != nil {
+		features = append(features, router.WithMetrics("todo_cache", todoCache))
+	}
+	features = append(features,
+		router.WithRateLimits(rateLimits),
+		router.WithHealthCheck("database", healthProbe),
+	)
+	todoHandler := setupServer(todoService, userService, jwtService, rateLimits.Limiter("", ""), pool, emailSender, features...)
+	watchConfig(ctx, pool, rateLimits)
 
 	srv := startHTTPServer(todoHandler)
 
@@ -80,23 +219,158 @@ func main() {
 	shutdownServer(srv, pool, cancel)
 }
 
-// initDB initializes the database connection.
-func initDB() *sql.DB {
-	db, err := sql.Open("postgres", cfg.DSN)
+// runDev serves users and todos from memory. Like SQLite, dev mode has no shared lists, comments,
+// API keys, account emails or audit log, and it skips login throttling and idempotency keys,
+// which need Redis.
+func runDev() {
+	slog.Warn("dev mode: storing data in memory, nothing is persisted")
+
+	shutdown := setupSignalHandler()
+	jobChannel := make(chan worker.Job, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pool := setupWorkerPool(ctx, jobChannel)
+
+	store := repository.NewMemoryStore()
+	userService := service.NewUserService(repository.NewMemoryUserRepository(store))
+	todoService := service.NewTodoService(repository.NewMemoryToDoRepository(store))
+	jwtService := service.NewJWTService(cfg.JwtSecretKey)
+	startRetentionJob(ctx, "trash", cfg.TrashRetentionDays, todoService.PurgeTrash)
+
+	// Counting in memory is always a fixed window, whatever the configured algorithm
+	rateLimits := initRateLimits(func(name string, limit int, period time.Duration) (router.RateLimiter, error) {
+		return router.NewMemoryRateLimiter(limit, period), nil
+	})
+	todoHandler := setupServer(todoService, userService, jwtService, rateLimits.Limiter("", ""), pool, &mocks.MockEmailSender{},
+		router.WithRateLimits(rateLimits))
+	watchConfig(ctx, pool, rateLimits)
+
+	srv := startHTTPServer(todoHandler)
+
+	<-shutdown
+
+	shutdownServer(srv, pool, cancel)
+}
+
+// watchConfig reloads the config when its file changes or the process gets SIGHUP, and applies the
+// settings that can change live. The router reads the rest of them from configs on every request.
+func watchConfig(ctx context.Context, pool *worker.WorkerPool, rateLimits *router.RateLimits) {
+	configs.Subscribe(func(old, new *config.Config) {
+		if new.LogLevel != old.LogLevel {
+			setLogLevel(new.LogLevel)
+		}
+		if new.NumOfWorkers != old.NumOfWorkers {
+			pool.Resize(new.NumOfWorkers)
+		}
+		if new.RateLimitAlgorithm != old.RateLimitAlgorithm || !reflect.DeepEqual(new.RateLimits, old.RateLimits) {
+			if err := rateLimits.Replace(new.RateLimits); err != nil {
+				slog.Error("rate limits not reloaded", "error", err)
+			}
+		}
+	})
+	if err := configs.Watch(ctx, configPath); err != nil {
+		slog.Warn("config reloading is off", "error", err)
+	}
+}
+
+// setLogLevel drops log/slog records below level. Fatal errors and the output of migrate status are
+// written with the log package, so they are always kept.
+func setLogLevel(level string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		log.Printf("invalid log level %q: %v", level, err)
+		return
+	}
+	slog.SetLogLoggerLevel(l)
+}
+
+// initRateLimits creates a limiter with newLimiter for each configured rate limit.
+// Defaults guarantee a rule for every request, so the limits always have a catch-all limiter.
+func initRateLimits(newLimiter func(name string, limit int, period time.Duration) (router.RateLimiter, error)) *router.RateLimits {
+	limits, err := router.NewRateLimits(cfg.RateLimits, newLimiter)
 	if err != nil {
-		log.Fatalf("failed to connect to the database: %s", err)
+		log.Fatalf("invalid rate limits: %s", err)
+	}
+	return limits
+}
+
+// initLimiterFailover creates the failover that keeps rate limiting going, by the configured
+// policy, while Redis is unavailable
+func initLimiterFailover() *router.LimiterFailover {
+	breaker := router.NewCircuitBreaker("rate limiter redis", cfg.RateLimitBreakerFailures,
+		time.Duration(cfg.RateLimitBreakerCooldownSeconds)*time.Second)
+	failover, err := router.NewLimiterFailover(cfg.RateLimitFailurePolicy, breaker)
+	if err != nil {
+		log.Fatalf("invalid rate limits: %s", err)
 	}
+	return failover
+}
 
-	// Ping the database to verify the connection is alive
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping the database: %s", err)
+// initDB initializes the database connection for the backend chosen by the DSN scheme.
+// SQLite databases are created and migrated on the spot; PostgreSQL is waited for while it starts up.
+func initDB(backend repository.Backend, dsn string) *sql.DB {
+	if backend == repository.BackendSQLite {
+		db, err := repository.OpenSQLite(context.Background(), dsn)
+		if err != nil {
+			log.Fatalf("failed to open the SQLite database: %s", err)
+		}
+		slog.Info("SQLite database opened successfully")
+		return db
+	}
+
+	db, err := repository.ConnectPostgres(context.Background(), dsn, poolConfig())
+	if err != nil {
+		log.Fatalf("failed to connect to the database: %s", err)
 	}
 
-	log.Println("Database connection established successfully")
+	slog.Info("Database connection established successfully")
+
+	// PostgreSQL is migrated by the migrate subcommand, so a schema left behind is refused here
+	// instead of surfacing as failed queries
+	if err := repository.NewBackendMigrator(db, backend).Check(context.Background()); err != nil {
+		log.Fatalf("refusing to serve: %s", err)
+	}
 
 	return db
 }
 
+// poolConfig returns the PostgreSQL connection pool settings from the config
+func poolConfig() repository.PoolConfig {
+	return repository.PoolConfig{
+		MaxOpenConns:     cfg.DBMaxOpenConns,
+		MaxIdleConns:     cfg.DBMaxIdleConns,
+		ConnMaxLifetime:  time.Duration(cfg.DBConnMaxLifetimeSeconds) * time.Second,
+		StatementTimeout: time.Duration(cfg.DBStatementTimeoutSeconds) * time.Second,
+		ConnectTimeout:   time.Duration(cfg.DBConnectTimeoutSeconds) * time.Second,
+	}
+}
+
+// initReplicas opens the read replicas in the config and starts checking their health.
+// A replica that cannot be reached is not fatal; it takes reads once its health check passes.
+func initReplicas(ctx context.Context, backend repository.Backend, primary *sql.DB) *repository.ReplicaSet {
+	if len(cfg.ReplicaDSNs) == 0 {
+		return nil
+	}
+	if backend != repository.BackendPostgres {
+		slog.Warn("replica_dsns is ignored: read replicas need PostgreSQL")
+		return nil
+	}
+
+	var dbs []*sql.DB
+	for i, dsn := range cfg.ReplicaDSNs {
+		db, err := repository.OpenPostgres(dsn, poolConfig())
+		if err != nil {
+			log.Fatalf("failed to open read replica %d: %s", i+1, err)
+		}
+		dbs = append(dbs, db)
+	}
+
+	replicas := repository.NewReplicaSet(primary, dbs, time.Duration(cfg.DBHealthCheckIntervalSeconds)*time.Second)
+	replicas.Start(ctx)
+	slog.Info("routing reads over read replicas", "replicas", len(dbs))
+	return replicas
+}
+
 // initRedisDB initializes the redis connection
 func initRedisDB() *redis.Client {
 	rdb := redis.NewClient(&redis.Options{
@@ -108,10 +382,65 @@ func initRedisDB() *redis.Client {
 		log.Fatalf("Failed to connect to Redis: %s", err)
 	}
 
-	log.Println("Redis connection established successfully")
+	slog.Info("Redis connection established successfully")
 	return rdb
 }
 
+// setupServices creates the todo and user services for the backend, along with the router options
+// of the features it supports. SQLite only stores users and todos; shared lists, comments, API keys,
+// account emails and the audit log need PostgreSQL, and answer 501 Not Implemented without it.
+// A nil cache leaves todo reads uncached.
+func setupServices(ctx context.Context, backend repository.Backend, db *sql.DB, replicas *repository.ReplicaSet, cache *service.TodoCache) (service.ToDoService, service.UserService, []router.Option) {
+	if backend == repository.BackendSQLite {
+		userService := service.NewUserService(repository.NewSQLiteUserRepository(db))
+		var todoService service.ToDoService = service.NewTodoService(repository.NewSQLiteToDoRepository(db))
+		if cache != nil {
+			todoService = service.NewCachedToDoService(todoService, cache, nil)
+		}
+		return todoService, userService, nil
+	}
+
+	userRepo := repository.NewPostgresUserRepository(db)
+	todoRepo := repository.NewPostgresToDoRepository(db)
+	userRepo.Replicas, todoRepo.Replicas = replicas, replicas
+	tokenRepo := repository.NewPostgresUserTokenRepository(db)
+	apiKeyRepo := repository.NewPostgresAPIKeyRepository(db)
+	listRepo := repository.NewPostgresListRepository(db)
+	commentRepo := repository.NewPostgresCommentRepository(db)
+	auditRepo := repository.NewPostgresAuditRepository(db)
+
+	var userService service.UserService = service.NewUserService(userRepo)
+	var todoService service.ToDoService = service.NewTodoService(todoRepo)
+	var listService service.ListService = service.NewListService(listRepo, todoRepo, userRepo)
+	if cache != nil {
+		userService = service.NewCachedUserService(userService, cache, listRepo)
+		todoService = service.NewCachedToDoService(todoService, cache, listRepo)
+		listService = service.NewCachedListService(listService, cache, listRepo)
+	}
+
+	// Every service that mutates data is wrapped so its changes land in the audit log
+	auditService := service.NewAuditService(auditRepo)
+	userService = service.NewAuditedUserService(userService, auditService)
+	todoService = service.NewAuditedToDoService(todoService, auditService)
+	accountService := service.NewAuditedAccountService(service.NewAccountService(userRepo, tokenRepo, cfg.JwtSecretKey), auditService)
+	apiKeyService := service.NewAuditedAPIKeyService(service.NewAPIKeyService(apiKeyRepo), auditService)
+	listService = service.NewAuditedListService(listService, auditService)
+	commentService := service.NewAuditedCommentService(service.NewCommentService(commentRepo, todoRepo, listRepo, userRepo), auditService)
+	startRetentionJob(ctx, "audit", cfg.AuditRetentionDays, auditService.PurgeExpired)
+
+	options := []router.Option{
+		router.WithAccountService(accountService),
+		router.WithAPIKeyService(apiKeyService),
+		router.WithListService(listService),
+		router.WithCommentService(commentService),
+		router.WithAuditService(auditService),
+	}
+	if replicas != nil {
+		options = append(options, router.WithReadYourWrites(time.Duration(cfg.ReadYourWritesSeconds)*time.Second))
+	}
+	return todoService, userService, options
+}
+
 // setupSignalHandler sets up a channel to listen for OS interrupt signals.
 func setupSignalHandler() chan os.Signal {
 	shutdown := make(chan os.Signal, 1)
@@ -126,13 +455,42 @@ func setupWorkerPool(ctx context.Context, jobChannel chan worker.Job) *worker.Wo
 	return pool
 }
 
+// startRetentionJob permanently deletes records older than the retention period once an hour.
+// A retention of zero days keeps records forever.
+func startRetentionJob(ctx context.Context, name string, retentionDays int, purge func(context.Context, time.Duration) (int64, error)) {
+	if retentionDays <= 0 {
+		return
+	}
+	retention := time.Duration(retentionDays) * 24 * time.Hour
+
+	go func() {
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+		for {
+			purged, err := purge(ctx, retention)
+			if err != nil {
+				slog.Error(name+" retention failed", "error", err)
+			} else if purged > 0 {
+				slog.Info(name+" retention removed records", "records", purged)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
 // setupServer initializes the HTTP server with the router and services.
+// Optional features are enabled through router options.
 func setupServer(todoService service.ToDoService, userService service.UserService,
 	jwtService service.JWTValidator, rateLimiter router.RateLimiter, pool *worker.WorkerPool,
-	emailSender *mocks.MockEmailSender) *router.Router {
+	emailSender worker.EmailSender, features ...router.Option) *router.Router {
 
-	configOption := router.WithConfig(cfg)
-	todoHandler := router.NewRouter(todoService, userService, jwtService, rateLimiter, pool, emailSender, configOption)
+	options := append([]router.Option{router.WithConfigManager(configs)}, features...)
+	todoHandler := router.NewRouter(todoService, userService, jwtService, rateLimiter, pool, emailSender, options...)
 	todoHandler.InitRoutes()
 	return todoHandler
 }
@@ -150,13 +508,13 @@ func startHTTPServer(todoHandler *router.Router) *http.Server {
 		}
 	}()
 
-	log.Println("server started on", cfg.Port)
+	slog.Info("server started", "port", cfg.Port)
 	return srv
 }
 
 // shutdownServer handles graceful shutdown of the server.
 func shutdownServer(srv *http.Server, pool *worker.WorkerPool, cancel context.CancelFunc) {
-	log.Println("shutting down the server...")
+	slog.Info("shutting down the server...")
 
 	// Signal the worker pool to stop
 	cancel() // Cancel the context for workers
@@ -174,5 +532,5 @@ func shutdownServer(srv *http.Server, pool *worker.WorkerPool, cancel context.Ca
 		log.Fatalf("server forced to shutdown: %s", err)
 	}
 
-	log.Println("server gracefully stopped")
+	slog.Info("server gracefully stopped")
 }
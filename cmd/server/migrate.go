@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+const migrateUsage = "usage: todo-server [--config file] migrate up | down [N] | status | force VERSION"
+
+// runMigrate runs the migrate subcommand against the database in the config:
+//
+//	migrate up            apply every pending migration
+//	migrate down [N]      roll back the latest N migrations, 1 by default
+//	migrate status        print the applied version and the pending migrations
+//	migrate force VERSION record VERSION as applied and clear the dirty flag, running nothing
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	backend, dsn := repository.ParseDSN(cfg.DSN)
+	conn, err := connectDB(backend, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	migrator := repository.NewBackendMigrator(conn, backend)
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("down takes a positive number of migrations, got %q", args[1])
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil || version < 0 {
+			return fmt.Errorf("force takes a version number, got %q", args[1])
+		}
+		err = migrator.Force(ctx, version)
+	case "status":
+	default:
+		return errors.New(migrateUsage)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	printMigrationStatus(status)
+	return nil
+}
+
+// connectDB opens the database without migrating it, unlike initDB does for SQLite
+func connectDB(backend repository.Backend, dsn string) (*sql.DB, error) {
+	if backend == repository.BackendSQLite {
+		return repository.ConnectSQLite(dsn)
+	}
+	return repository.ConnectPostgres(context.Background(), dsn, poolConfig())
+}
+
+func printMigrationStatus(status repository.MigrationStatus) {
+	pending := "none"
+	if len(status.Pending) > 0 {
+		versions := make([]string, len(status.Pending))
+		for i, version := range status.Pending {
+			versions[i] = strconv.Itoa(version)
+		}
+		pending = strings.Join(versions, ", ")
+	}
+	dirty := ""
+	if status.Dirty {
+		dirty = " (dirty)"
+	}
+	log.Printf("schema version %d%s, latest %d, pending: %s", status.Version, dirty, status.Latest, pending)
+}
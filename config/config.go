@@ -1,13 +1,7 @@
 package config
 
-import (
-	"fmt"
-	"os"
-
-	"sigs.k8s.io/kustomize/kyaml/yaml"
-)
-
 // Config holds the configuration settings for the application.
+// Settings tagged reload:"live" can change while the server runs; the others need a restart.
 type Config struct {
 	// Port is the server port
 	Port string `yaml:"port"`
@@ -22,11 +16,14 @@ type Config struct {
 	RedisAddress string `yaml:"redis_address"`
 
 	// HtmlAssetsPath to store the html files
-	HtmlAssetsPath string `yaml:"html_assets_path"`
+	HtmlAssetsPath string `yaml:"html_assets_path" reload:"live"`
 
 	// NumOfWorkers specifies the number of worker threads to be used.
 	// It determines how many concurrent tasks can be processed.
-	NumOfWorkers int `yaml:"num_of_workers"`
+	NumOfWorkers int `yaml:"num_of_workers" reload:"live"`
+
+	// LogLevel is the lowest level logged: "debug", "info", "warn" or "error". Defaults to info.
+	LogLevel string `yaml:"log_level" reload:"live"`
 
 	// SmtpHost is the address of the SMTP server used for sending emails.
 	// This should be set to the hostname or IP address of the SMTP server.
@@ -44,42 +41,162 @@ type Config struct {
 	// It is used for authenticating to the SMTP server and should be kept secure.
 	SmtpPassword string `yaml:"smtp_password"`
 
-	PDFOutputPath string `yaml:"pdf_output_path"`
+	PDFOutputPath string `yaml:"pdf_output_path" reload:"live"`
+
+	// BaseURL is the public address of the server, used to build links in emails.
+	// When empty, links are built from the Host header of the incoming request.
+	BaseURL string `yaml:"base_url" reload:"live"`
+
+	// RequireEmailVerification blocks login until the user has verified their email address.
+	RequireEmailVerification bool `yaml:"require_email_verification" reload:"live"`
+
+	// AuditRetentionDays is how long audit events are kept. Zero keeps them forever.
+	AuditRetentionDays int `yaml:"audit_retention_days"`
+
+	// TrashRetentionDays is how long deleted todos stay in the trash before they are purged.
+	// Zero keeps them until they are restored.
+	TrashRetentionDays int `yaml:"trash_retention_days"`
+
+	// DBMaxOpenConns caps the open database connections. Defaults to 25.
+	DBMaxOpenConns int `yaml:"db_max_open_conns"`
+
+	// DBMaxIdleConns is how many idle connections are kept for reuse. Defaults to 5.
+	DBMaxIdleConns int `yaml:"db_max_idle_conns"`
+
+	// DBConnMaxLifetimeSeconds is how long a connection is reused before it is replaced,
+	// so that connections follow failovers and load balancer changes. Defaults to 30 minutes.
+	DBConnMaxLifetimeSeconds int `yaml:"db_conn_max_lifetime_seconds"`
+
+	// DBStatementTimeoutSeconds makes PostgreSQL cancel statements that run longer. Zero means no limit.
+	DBStatementTimeoutSeconds int `yaml:"db_statement_timeout_seconds"`
+
+	// DBConnectTimeoutSeconds is how long startup keeps retrying an unreachable database. Defaults to 30.
+	DBConnectTimeoutSeconds int `yaml:"db_connect_timeout_seconds"`
+
+	// DBHealthCheckIntervalSeconds is how often the database is pinged for the readiness endpoint. Defaults to 10.
+	DBHealthCheckIntervalSeconds int `yaml:"db_health_check_interval_seconds"`
+
+	// ReplicaDSNs lists PostgreSQL read replicas of the DSN database. Reads that tolerate a little
+	// replication lag are spread over the healthy ones.
+	ReplicaDSNs []string `yaml:"replica_dsns"`
+
+	// ReadYourWritesSeconds is how long a user's reads go to the primary after they change something,
+	// so they see their change before the replicas catch up. Defaults to 5.
+	ReadYourWritesSeconds int `yaml:"read_your_writes_seconds"`
+
+	// TodoCache keeps todo lists and single todos in Redis, so repeated reads skip the database.
+	TodoCache bool `yaml:"todo_cache"`
+
+	// TodoCacheTTLSeconds is the longest a cached read is served; it bounds how stale a read can be
+	// if an invalidation is lost. Defaults to 60.
+	TodoCacheTTLSeconds int `yaml:"todo_cache_ttl_seconds"`
+
+	// RateLimitAlgorithm is how requests are counted against the rate limits: "fixed_window",
+	// "sliding_window" or "token_bucket". Defaults to fixed_window.
+	RateLimitAlgorithm string `yaml:"rate_limit_algorithm" reload:"live"`
+
+	// RateLimits are the request limits per route group and user tier. A request is counted
+	// against the most specific rule that matches it. Without a rule matching every request,
+	// one allowing 100 requests per 10 seconds is added.
+	RateLimits []RateLimitRule `yaml:"rate_limits" reload:"live"`
+
+	// RateLimitFailurePolicy is what happens to requests while Redis cannot count them: "fail_closed"
+	// refuses them, "fail_open" lets them through unlimited and "local" counts them in memory, per
+	// server, with a token bucket of each rule's size. Defaults to fail_closed.
+	RateLimitFailurePolicy string `yaml:"rate_limit_failure_policy"`
+
+	// RateLimitBreakerFailures is how many Redis errors in a row stop the rate limiters from calling
+	// Redis. Defaults to 5.
+	RateLimitBreakerFailures int `yaml:"rate_limit_breaker_failures"`
+
+	// RateLimitBreakerCooldownSeconds is how long the rate limiters leave Redis alone before trying it
+	// again. Defaults to 10.
+	RateLimitBreakerCooldownSeconds int `yaml:"rate_limit_breaker_cooldown_seconds"`
 }
 
-// GetDefaultConfig returns a Config instance with default values.
-func GetDefaultConfig() *Config {
-	return &Config{
+// RateLimitRule allows Requests requests per PeriodSeconds for each user. Requests matched by
+// the same rule share one allowance, so a rule without a group limits all groups together.
+type RateLimitRule struct {
+	// Group is the route group the rule applies to: "todos", "lists" or "batch". Empty matches every group.
+	Group string `yaml:"group"`
+
+	// Tier is the user tier the rule applies to, which is the user's role. Empty matches every tier.
+	Tier string `yaml:"tier"`
+
+	Requests      int `yaml:"requests"`
+	PeriodSeconds int `yaml:"period_seconds"`
+}
 
+// setDefaults fills in the database, cache and rate limit settings that were left out
+func (c *Config) setDefaults() {
+	if c.DBMaxOpenConns == 0 {
+		c.DBMaxOpenConns = 25
+	}
+	if c.DBMaxIdleConns == 0 {
+		c.DBMaxIdleConns = 5
+	}
+	if c.DBConnMaxLifetimeSeconds == 0 {
+		c.DBConnMaxLifetimeSeconds = 30 * 60
+	}
+	if c.DBConnectTimeoutSeconds == 0 {
+		c.DBConnectTimeoutSeconds = 30
+	}
+	if c.DBHealthCheckIntervalSeconds == 0 {
+		c.DBHealthCheckIntervalSeconds = 10
+	}
+	if c.ReadYourWritesSeconds == 0 {
+		c.ReadYourWritesSeconds = 5
+	}
+	if c.TodoCacheTTLSeconds == 0 {
+		c.TodoCacheTTLSeconds = 60
+	}
+	if c.LogLevel == "" {
+		c.LogLevel = "info"
+	}
+	if c.RateLimitAlgorithm == "" {
+		c.RateLimitAlgorithm = "fixed_window"
+	}
+	if c.RateLimitFailurePolicy == "" {
+		c.RateLimitFailurePolicy = "fail_closed"
+	}
+	if c.RateLimitBreakerFailures == 0 {
+		c.RateLimitBreakerFailures = 5
+	}
+	if c.RateLimitBreakerCooldownSeconds == 0 {
+		c.RateLimitBreakerCooldownSeconds = 10
+	}
+	catchAll := false
+	for _, rule := range c.RateLimits {
+		catchAll = catchAll || (rule.Group == "" && rule.Tier == "")
+	}
+	if !catchAll {
+		c.RateLimits = append(c.RateLimits, RateLimitRule{Requests: 100, PeriodSeconds: 10})
+	}
+}
+
+// defaultConfig returns the config that Load starts from, holding the defaults of the settings
+// that cannot be left at zero. The others are filled in by setDefaults once every layer is read.
+func defaultConfig() *Config {
+	return &Config{
 		NumOfWorkers: 3,
 		SmtpHost:     "localhost",
 		SmtpPort:     587,
-		SmtpUserName: "test",
-		SmtpPassword: "test",
 	}
 }
 
-// NewConfig reads a YAML configuration file and returns the corresponding Config.
+// GetDefaultConfig returns a Config instance with default values, as Load reads it when nothing
+// is set.
+func GetDefaultConfig() *Config {
+	config := defaultConfig()
+	config.setDefaults()
+	return config
+}
+
+// NewConfig reads a YAML configuration file, with TODO_* environment variables applied on top.
+// See Load.
 func NewConfig(filePath string) (*Config, error) {
 	if filePath == "" {
 		filePath = "./config.yaml"
 	}
-
-	configData, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("error reading config file: %w", err)
-	}
-
-	config := &Config{}
-	err = yaml.Unmarshal(configData, config)
-	if err != nil {
-		return nil, fmt.Errorf("error unmarshalling config data: %w", err)
-	}
-
-	// Basic validation
-	if config.SmtpHost == "" || config.SmtpUserName == "" {
-		return GetDefaultConfig(), nil // fall back to defaults if necessary
-	}
-
-	return config, nil
+	return Load(filePath, nil)
 }
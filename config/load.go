@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// EnvPrefix starts the environment variable of every setting: TODO_ followed by its YAML key in
+// upper case, such as TODO_JWT_SECRET_KEY
+const EnvPrefix = "TODO_"
+
+// Overrides are setting values by YAML key, as given on the command line
+type Overrides map[string]string
+
+// RegisterFlags adds a flag for every setting that takes a single value to fs, named after its
+// YAML key with dashes, such as -jwt-secret-key. The values of the flags set are collected in the
+// returned Overrides once fs is parsed.
+func RegisterFlags(fs *flag.FlagSet) Overrides {
+	overrides := Overrides{}
+	for _, s := range settings() {
+		if s.field.Type.Kind() == reflect.Slice {
+			continue
+		}
+		key := s.key
+		fs.Func(strings.ReplaceAll(key, "_", "-"), "overrides "+key+" from the config file", func(value string) error {
+			overrides[key] = value
+			return nil
+		})
+	}
+	return overrides
+}
+
+// Load builds the configuration in layers, each overriding the one before: defaults, the YAML file
+// at filePath unless it is empty, TODO_* environment variables and then flags. Every invalid
+// setting is reported at once, in a *ValidationError, as are the settings named in required that
+// are left empty.
+func Load(filePath string, flags Overrides, required ...string) (*Config, error) {
+	config := defaultConfig()
+
+	var problems []string
+	if filePath != "" {
+		configData, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading config file: %w", err)
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(configData))
+		decoder.KnownFields(true)
+		var typeErr *yaml.TypeError
+		if err := decoder.Decode(config); errors.As(err, &typeErr) {
+			// Unknown keys and values of the wrong type; the rest of the file is still read
+			for _, problem := range typeErr.Errors {
+				problems = append(problems, filePath+": "+problem)
+			}
+		} else if err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error unmarshalling config data: %w", err)
+		}
+	}
+
+	for _, s := range settings() {
+		name := EnvPrefix + strings.ToUpper(s.key)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := s.set(config, value); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", name, err))
+			}
+		}
+	}
+	for _, s := range settings() {
+		if value, ok := flags[s.key]; ok {
+			if err := s.set(config, value); err != nil {
+				problems = append(problems, fmt.Sprintf("-%s: %s", strings.ReplaceAll(s.key, "_", "-"), err))
+			}
+		}
+	}
+
+	config.setDefaults()
+	problems = append(problems, config.validate(required)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return config, nil
+}
+
+// setting is a field of Config and its YAML key
+type setting struct {
+	key   string
+	field reflect.StructField
+}
+
+func settings() []setting {
+	t := reflect.TypeOf(Config{})
+	settings := make([]setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		// Lists of rules only come from the file
+		if key == "" || (field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.String) {
+			continue
+		}
+		settings = append(settings, setting{key: key, field: field})
+	}
+	return settings
+}
+
+// value returns the setting's field in config
+func (s setting) value(config *Config) reflect.Value {
+	return reflect.ValueOf(config).Elem().FieldByIndex(s.field.Index)
+}
+
+// set parses value into the setting's field. Lists are separated by commas.
+func (s setting) set(config *Config, value string) error {
+	field := s.value(config)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// RestartRequiredError rejects a reload that changes settings which only take effect on restart
+type RestartRequiredError struct {
+	Keys []string
+}
+
+func (e *RestartRequiredError) Error() string {
+	return fmt.Sprintf("%s cannot change without a restart", strings.Join(e.Keys, ", "))
+}
+
+// Manager holds the current configuration and replaces it on reload. Readers get the config in
+// force with Current, and subscribers are told about every change so they can apply it.
+// It is safe for concurrent use.
+type Manager struct {
+	current atomic.Pointer[Config]
+	load    func(current *Config) (*Config, error)
+	// mu serialises reloads, so subscribers see changes one at a time and in order
+	mu          sync.Mutex
+	subscribers []func(old, new *Config)
+}
+
+// NewManager creates a manager starting with initial. load reads the configuration anew; it is
+// given the config in force, for settings derived from it.
+func NewManager(initial *Config, load func(current *Config) (*Config, error)) *Manager {
+	m := &Manager{load: load}
+	m.current.Store(initial)
+	return m
+}
+
+// Current returns the configuration in force. It must not be modified.
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// Subscribe calls fn after every reload that changes a setting, with the old and new config
+func (m *Manager) Subscribe(fn func(old, new *Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// Reload reads the configuration and swaps it in. An invalid configuration is rejected, as is one
+// that changes a setting which needs a restart, with a *RestartRequiredError; the config in force
+// is then kept whole.
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.Current()
+	next, err := m.load(current)
+	if err != nil {
+		return err
+	}
+
+	var changed, restart []string
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if reflect.DeepEqual(reflect.ValueOf(*current).FieldByIndex(field.Index).Interface(),
+			reflect.ValueOf(*next).FieldByIndex(field.Index).Interface()) {
+			continue
+		}
+		changed = append(changed, key)
+		if field.Tag.Get("reload") != "live" {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		return &RestartRequiredError{Keys: restart}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	m.current.Store(next)
+	slog.Info("config reloaded", "changed", strings.Join(changed, ", "))
+	for _, fn := range m.subscribers {
+		fn(current, next)
+	}
+	return nil
+}
+
+// Watch reloads the configuration when the file at path changes and when the process gets SIGHUP,
+// until ctx is done. Reloads that fail are logged. The file's directory is watched rather than the
+// file, so that files replaced by editors or Kubernetes config maps are still followed; an empty
+// path watches for SIGHUP only.
+func (m *Manager) Watch(ctx context.Context, path string) error {
+	var events chan fsnotify.Event
+	var watchErrors chan error
+	if path != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			watcher.Close()
+		}()
+		events, watchErrors = watcher.Events, watcher.Errors
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(hangup)
+
+		// Editors write a file in several steps, so reload once the events have settled
+		settle := time.NewTimer(0)
+		<-settle.C
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				slog.Info("SIGHUP received, reloading the config")
+				m.logReload()
+			case event, ok := <-events:
+				if !ok {
+					// The watcher was closed as ctx ended
+					events = nil
+				} else if affects(event, path) {
+					settle.Reset(100 * time.Millisecond)
+				}
+			case <-settle.C:
+				m.logReload()
+			case err, ok := <-watchErrors:
+				if !ok {
+					watchErrors = nil
+				} else {
+					slog.Warn("watching the config file failed", "path", path, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// affects reports whether the event may have changed the file at path. Kubernetes replaces a
+// config map by swapping a symlink in its directory, which shows up as events for ..data.
+func affects(event fsnotify.Event, path string) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	return filepath.Clean(event.Name) == filepath.Clean(path) || filepath.Base(event.Name) == "..data"
+}
+
+func (m *Manager) logReload() {
+	if err := m.Reload(); err != nil {
+		slog.Error("config not reloaded", "error", err)
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestManagerReload(t *testing.T) {
+	path := writeConfig(t, `
+port: ":9000"
+num_of_workers: 2
+`)
+	load := func(*Config) (*Config, error) { return Load(path, nil) }
+	initial, err := load(nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	manager := NewManager(initial, load)
+
+	var notified []*Config
+	manager.Subscribe(func(old, new *Config) {
+		if old != initial {
+			t.Errorf("expected subscribers to get the config in force as old")
+		}
+		notified = append(notified, new)
+	})
+
+	if err := manager.Reload(); err != nil || len(notified) != 0 {
+		t.Errorf("expected an unchanged file to reload quietly, got %v and %d notifications", err, len(notified))
+	}
+
+	os.WriteFile(path, []byte("port: \":9001\"\ndsn: postgres://localhost/todo\nnum_of_workers: 4\n"), 0o600)
+	var restart *RestartRequiredError
+	if err := manager.Reload(); !errors.As(err, &restart) || len(restart.Keys) != 2 || restart.Keys[0] != "port" || restart.Keys[1] != "dsn" {
+		t.Errorf("expected port and dsn to need a restart, got %v", err)
+	}
+	if manager.Current() != initial || len(notified) != 0 {
+		t.Errorf("expected the rejected config to leave the config in force alone")
+	}
+
+	os.WriteFile(path, []byte("port: \":9000\"\nnum_of_workers: 2\nsmtp_host: mail.example.com\n"), 0o600)
+	if err := manager.Reload(); !errors.As(err, &restart) || len(restart.Keys) != 1 || restart.Keys[0] != "smtp_host" {
+		t.Errorf("expected smtp_host to need a restart, got %v", err)
+	}
+
+	os.WriteFile(path, []byte("port: \":9000\"\nnum_of_workers: 0\n"), 0o600)
+	var invalid *ValidationError
+	if err := manager.Reload(); !errors.As(err, &invalid) || manager.Current() != initial {
+		t.Errorf("expected an invalid config to be rejected, got %v", err)
+	}
+
+	os.WriteFile(path, []byte("port: \":9000\"\nnum_of_workers: 4\nlog_level: debug\n"), 0o600)
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("expected live settings to reload, got %v", err)
+	}
+	if current := manager.Current(); current.NumOfWorkers != 4 || current.LogLevel != "debug" || len(notified) != 1 || notified[0] != current {
+		t.Errorf("expected the new config in force and announced, got %+v", current)
+	}
+	if initial.NumOfWorkers != 2 {
+		t.Errorf("expected the old config to stay as it was, got %d workers", initial.NumOfWorkers)
+	}
+}
+
+func TestManagerWatch(t *testing.T) {
+	path := writeConfig(t, "num_of_workers: 2\n")
+	load := func(*Config) (*Config, error) { return Load(path, nil) }
+	initial, _ := load(nil)
+	manager := NewManager(initial, load)
+
+	reloaded := make(chan *Config, 1)
+	manager.Subscribe(func(old, new *Config) { reloaded <- new })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := manager.Watch(ctx, path); err != nil {
+		t.Fatalf("expected the file to be watched, got %v", err)
+	}
+
+	os.WriteFile(path, []byte("num_of_workers: 5\n"), 0o600)
+	select {
+	case config := <-reloaded:
+		if config.NumOfWorkers != 5 {
+			t.Errorf("expected 5 workers after the file changed, got %d", config.NumOfWorkers)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the config to reload when its file changed")
+	}
+}
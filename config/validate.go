@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"sigs.k8s.io/kustomize/kyaml/yaml"
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// validate returns a problem for every invalid setting and every setting in required left empty
+func (c *Config) validate(required []string) []string {
+	var problems []string
+	invalid := func(key, format string, args ...any) {
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+
+	for _, s := range settings() {
+		value := s.value(c)
+		if slices.Contains(required, s.key) && value.IsZero() {
+			invalid(s.key, "is required")
+		}
+		if value.Kind() == reflect.Int && value.Int() < 0 {
+			invalid(s.key, "must not be negative")
+		}
+	}
+
+	for _, address := range []struct{ key, value string }{{"port", c.Port}, {"redis_address", c.RedisAddress}} {
+		if address.value == "" {
+			continue
+		}
+		if _, port, err := net.SplitHostPort(address.value); err != nil || !validPort(port) {
+			invalid(address.key, "%q is not a host:port address, such as :8080", address.value)
+		}
+	}
+	if c.NumOfWorkers < 1 {
+		invalid("num_of_workers", "must be at least 1")
+	}
+	if !validPort(strconv.Itoa(c.SmtpPort)) {
+		invalid("smtp_port", "%d is not a port number", c.SmtpPort)
+	}
+	if c.BaseURL != "" {
+		if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			invalid("base_url", "%q is not an http or https URL", c.BaseURL)
+		}
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		invalid("log_level", "must be debug, info, warn or error, not %q", c.LogLevel)
+	}
+	switch c.RateLimitAlgorithm {
+	case "fixed_window", "sliding_window", "token_bucket":
+	default:
+		invalid("rate_limit_algorithm", "must be fixed_window, sliding_window or token_bucket, not %q", c.RateLimitAlgorithm)
+	}
+	switch c.RateLimitFailurePolicy {
+	case "fail_closed", "fail_open", "local":
+	default:
+		invalid("rate_limit_failure_policy", "must be fail_closed, fail_open or local, not %q", c.RateLimitFailurePolicy)
+	}
+	seen := map[RateLimitRule]bool{}
+	for i, rule := range c.RateLimits {
+		key := fmt.Sprintf("rate_limits[%d]", i)
+		switch rule.Group {
+		case "", "todos", "lists", "batch":
+		default:
+			invalid(key, "group must be todos, lists or batch, not %q", rule.Group)
+		}
+		if rule.Tier != "" && !entity.ValidRole(rule.Tier) {
+			invalid(key, "tier must be a role, not %q", rule.Tier)
+		}
+		if rule.Requests <= 0 || rule.PeriodSeconds <= 0 {
+			invalid(key, "requests and period_seconds must be positive")
+		}
+		scope := RateLimitRule{Group: rule.Group, Tier: rule.Tier}
+		if seen[scope] {
+			invalid(key, "repeats the rule for group %q and tier %q", rule.Group, rule.Tier)
+		}
+		seen[scope] = true
+	}
+	return problems
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// String returns the configuration as YAML with its secrets redacted, so that it can be printed
+func (c *Config) String() string {
+	safe := *c
+	safe.JwtSecretKey = redact(c.JwtSecretKey)
+	safe.SmtpPassword = redact(c.SmtpPassword)
+	safe.DSN = redactDSN(c.DSN)
+	safe.ReplicaDSNs = nil
+	for _, dsn := range c.ReplicaDSNs {
+		safe.ReplicaDSNs = append(safe.ReplicaDSNs, redactDSN(dsn))
+	}
+
+	out, err := yaml.Marshal(&safe)
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	return string(out)
+}
+
+const redacted = "REDACTED"
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// dsnPassword matches the password of a key=value PostgreSQL DSN
+var dsnPassword = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactDSN hides the password of a PostgreSQL URL or key=value DSN
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			return u.String()
+		}
+		return dsn
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}"+redacted)
+}
@@ -0,0 +1,29 @@
+// Package db embeds the schema migrations so the server binary can apply them itself
+package db
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var postgresFiles embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFiles embed.FS
+
+// PostgresMigrations holds the migrations of the PostgreSQL backend, from migrations/
+var PostgresMigrations = sub(postgresFiles, "migrations")
+
+// SQLiteMigrations holds the migrations of the SQLite backend, from sqlite/
+var SQLiteMigrations = sub(sqliteFiles, "sqlite")
+
+// sub returns the files of one embedded directory. The directories are fixed at build time,
+// so failing here is a programming error.
+func sub(files embed.FS, dir string) fs.FS {
+	migrations, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	return migrations
+}
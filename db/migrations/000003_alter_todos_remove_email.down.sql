@@ -1,2 +1,4 @@
--- Down Migration: Add the email column back to todos
-ALTER TABLE todos ADD COLUMN email VARCHAR(300) UNIQUE NOT NULL;
+-- Down Migration: Add the email column back to todos.
+-- The dropped addresses cannot be recovered, so the column comes back nullable;
+-- adding it as UNIQUE NOT NULL fails as soon as the table has a row.
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS email VARCHAR(300);
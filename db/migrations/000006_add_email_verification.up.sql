@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN sessions_revoked_at TIMESTAMPTZ;
+
+-- Accounts created before verification existed are trusted as-is.
+UPDATE users SET email_verified = TRUE;
+
+CREATE TABLE IF NOT EXISTS user_tokens(
+   token_id VARCHAR(64) PRIMARY KEY,
+   user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   purpose VARCHAR(32) NOT NULL,
+   expires_at TIMESTAMPTZ NOT NULL,
+   used_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_purpose ON user_tokens(user_id, purpose);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS api_keys(
+   key_id serial PRIMARY KEY,
+   user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   name VARCHAR(100) NOT NULL,
+   prefix VARCHAR(16) UNIQUE NOT NULL,
+   key_hash VARCHAR(64) NOT NULL,
+   scopes TEXT[] NOT NULL DEFAULT '{}',
+   expires_at TIMESTAMPTZ,
+   last_used_at TIMESTAMPTZ,
+   revoked_at TIMESTAMPTZ,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys(user_id);
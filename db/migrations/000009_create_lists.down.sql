@@ -0,0 +1,4 @@
+ALTER TABLE todos DROP COLUMN IF EXISTS list_id;
+DROP TABLE IF EXISTS list_invitations;
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
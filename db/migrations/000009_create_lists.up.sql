@@ -0,0 +1,55 @@
+CREATE TABLE IF NOT EXISTS lists(
+   list_id serial PRIMARY KEY,
+   name VARCHAR(100) NOT NULL,
+   owner_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   personal BOOLEAN NOT NULL DEFAULT FALSE,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Every user has exactly one personal list
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lists_personal_owner ON lists(owner_id) WHERE personal;
+
+CREATE TABLE IF NOT EXISTS list_members(
+   list_id INT NOT NULL REFERENCES lists(list_id) ON DELETE CASCADE,
+   user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+   PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_members_user ON list_members(user_id);
+
+CREATE TABLE IF NOT EXISTS list_invitations(
+   invitation_id serial PRIMARY KEY,
+   list_id INT NOT NULL REFERENCES lists(list_id) ON DELETE CASCADE,
+   email VARCHAR(300) NOT NULL,
+   role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+   invited_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+   status VARCHAR(16) NOT NULL DEFAULT 'pending',
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   responded_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_invitations_email ON list_invitations(email) WHERE status = 'pending';
+
+-- Move every existing todo into a personal list owned by its user
+INSERT INTO lists (name, owner_id, personal) SELECT 'Personal', user_id, TRUE FROM users;
+INSERT INTO list_members (list_id, user_id, role) SELECT list_id, owner_id, 'owner' FROM lists WHERE personal;
+
+ALTER TABLE todos ADD COLUMN list_id INT REFERENCES lists(list_id) ON DELETE CASCADE;
+UPDATE todos SET list_id = lists.list_id FROM lists WHERE lists.owner_id = todos.user_id AND lists.personal;
+-- Todos without a user have no list to go to. Rather than drop them, stop here and leave the
+-- schema as it was, so that they can be given an owner or removed by hand first.
+DO $$
+DECLARE
+   orphans BIGINT;
+BEGIN
+   SELECT count(*) INTO orphans FROM todos WHERE list_id IS NULL;
+   IF orphans > 0 THEN
+      RAISE EXCEPTION '% todos have no user_id and cannot be moved into a personal list', orphans
+         USING HINT = 'Set their user_id to an existing user, or delete them, then run migrate up again.';
+   END IF;
+END
+$$;
+ALTER TABLE todos ALTER COLUMN list_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_list ON todos(list_id);
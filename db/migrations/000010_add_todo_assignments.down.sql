@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS todo_assignments;
+ALTER TABLE todos DROP COLUMN IF EXISTS assignee_id;
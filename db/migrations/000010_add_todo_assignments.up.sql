@@ -0,0 +1,15 @@
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS assignee_id INT REFERENCES users(user_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_assignee ON todos(assignee_id) WHERE assignee_id IS NOT NULL;
+
+-- Every change of assignee, oldest first
+CREATE TABLE IF NOT EXISTS todo_assignments(
+   assignment_id serial PRIMARY KEY,
+   todo_id INT NOT NULL REFERENCES todos(todo_id) ON DELETE CASCADE,
+   previous_assignee_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+   assignee_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+   assigned_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+   assigned_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_assignments_todo ON todo_assignments(todo_id);
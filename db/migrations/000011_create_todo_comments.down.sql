@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS todo_comment_revisions;
+DROP TABLE IF EXISTS todo_comments;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS todo_comments(
+   comment_id serial PRIMARY KEY,
+   todo_id INT NOT NULL REFERENCES todos(todo_id) ON DELETE CASCADE,
+   user_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+   body TEXT NOT NULL,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   edited_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_comments_todo ON todo_comments(todo_id, comment_id);
+
+-- Previous bodies of edited comments
+CREATE TABLE IF NOT EXISTS todo_comment_revisions(
+   revision_id serial PRIMARY KEY,
+   comment_id INT NOT NULL REFERENCES todo_comments(comment_id) ON DELETE CASCADE,
+   body TEXT NOT NULL,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_comment_revisions_comment ON todo_comment_revisions(comment_id);
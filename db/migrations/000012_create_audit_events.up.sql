@@ -0,0 +1,28 @@
+-- No foreign keys: events have to outlive the users and records they mention
+CREATE TABLE IF NOT EXISTS audit_events(
+   event_id bigserial PRIMARY KEY,
+   actor_id INT,
+   subject_id INT,
+   action VARCHAR(64) NOT NULL,
+   target_type VARCHAR(32) NOT NULL,
+   target_id VARCHAR(64) NOT NULL DEFAULT '',
+   before JSONB,
+   after JSONB,
+   ip VARCHAR(64) NOT NULL DEFAULT '',
+   request_id VARCHAR(64) NOT NULL DEFAULT '',
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_created ON audit_events(created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events(actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_events_subject ON audit_events(subject_id, created_at);
+
+-- Events are append-only; the retention job may delete old ones but nothing may rewrite them
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+   RAISE EXCEPTION 'audit events cannot be modified';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE ON audit_events
+   FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
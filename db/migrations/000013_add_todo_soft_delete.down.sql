@@ -0,0 +1,4 @@
+DELETE FROM todos WHERE deleted_at IS NOT NULL;
+ALTER TABLE todos DROP COLUMN IF EXISTS deletion_id;
+ALTER TABLE todos DROP COLUMN IF EXISTS deleted_at;
+DROP TABLE IF EXISTS todo_deletions;
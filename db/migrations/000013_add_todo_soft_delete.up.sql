@@ -0,0 +1,15 @@
+-- Each "delete all" is recorded so it can be undone as a whole within the undo window
+CREATE TABLE IF NOT EXISTS todo_deletions(
+   deletion_id serial PRIMARY KEY,
+   user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   list_id INT NOT NULL REFERENCES lists(list_id) ON DELETE CASCADE,
+   deleted_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_deletions_user ON todo_deletions(user_id, deleted_at);
+
+-- Deleted todos stay in the trash until they are restored or purged
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS deletion_id INT REFERENCES todo_deletions(deletion_id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_deleted ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
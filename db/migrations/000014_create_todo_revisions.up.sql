@@ -0,0 +1,17 @@
+-- Every version of a todo's editable fields, numbered per todo from 1
+CREATE TABLE IF NOT EXISTS todo_revisions(
+   revision_id serial PRIMARY KEY,
+   todo_id INT NOT NULL REFERENCES todos(todo_id) ON DELETE CASCADE,
+   revision INT NOT NULL,
+   title TEXT,
+   description TEXT,
+   datetime TIMESTAMPTZ NOT NULL,
+   changed_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+   created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+   UNIQUE (todo_id, revision)
+);
+
+-- Existing todos start their history at their current state
+INSERT INTO todo_revisions (todo_id, revision, title, description, datetime, changed_by)
+SELECT todo_id, 1, title, description, datetime, user_id FROM todos
+ON CONFLICT DO NOTHING;
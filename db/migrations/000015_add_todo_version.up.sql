@@ -0,0 +1,2 @@
+-- Incremented on every change so clients can make conditional requests
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
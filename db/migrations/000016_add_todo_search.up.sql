@@ -0,0 +1,8 @@
+-- Weighted full-text document for each todo: title matches rank above description matches
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_todos_search ON todos USING GIN (search);
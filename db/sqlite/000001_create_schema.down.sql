@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS todos_fts_update;
+DROP TRIGGER IF EXISTS todos_fts_delete;
+DROP TRIGGER IF EXISTS todos_fts_insert;
+DROP TABLE IF EXISTS todos_fts;
+DROP TABLE IF EXISTS todo_revisions;
+DROP TABLE IF EXISTS todo_assignments;
+DROP TABLE IF EXISTS todos;
+DROP TABLE IF EXISTS todo_deletions;
+DROP TABLE IF EXISTS list_members;
+DROP TABLE IF EXISTS lists;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,103 @@
+-- The SQLite backend stores users and todos, mirroring the PostgreSQL schema those repositories use.
+-- Timestamps are UTC text in the format the driver writes, so they sort and compare as text.
+
+CREATE TABLE IF NOT EXISTS users(
+   user_id INTEGER PRIMARY KEY AUTOINCREMENT,
+   username VARCHAR(50) UNIQUE NOT NULL,
+   password VARCHAR(100) NOT NULL,
+   email VARCHAR(300) UNIQUE NOT NULL,
+   email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+   sessions_revoked_at TIMESTAMP,
+   role VARCHAR(16) NOT NULL DEFAULT 'user',
+   disabled BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS lists(
+   list_id INTEGER PRIMARY KEY AUTOINCREMENT,
+   name VARCHAR(100) NOT NULL,
+   owner_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   personal BOOLEAN NOT NULL DEFAULT FALSE,
+   created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+-- Every user has exactly one personal list
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lists_personal_owner ON lists(owner_id) WHERE personal;
+
+CREATE TABLE IF NOT EXISTS list_members(
+   list_id INT NOT NULL REFERENCES lists(list_id) ON DELETE CASCADE,
+   user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   role VARCHAR(16) NOT NULL CHECK (role IN ('viewer', 'editor', 'owner')),
+   PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_list_members_user ON list_members(user_id);
+
+-- Each "delete all" is recorded so it can be undone as a whole within the undo window
+CREATE TABLE IF NOT EXISTS todo_deletions(
+   deletion_id INTEGER PRIMARY KEY AUTOINCREMENT,
+   user_id INT NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+   list_id INT NOT NULL REFERENCES lists(list_id) ON DELETE CASCADE,
+   deleted_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_deletions_user ON todo_deletions(user_id, deleted_at);
+
+CREATE TABLE IF NOT EXISTS todos(
+   todo_id INTEGER PRIMARY KEY AUTOINCREMENT,
+   user_id INT REFERENCES users(user_id) ON DELETE CASCADE,
+   list_id INT NOT NULL REFERENCES lists(list_id) ON DELETE CASCADE,
+   title VARCHAR(50),
+   description VARCHAR(50),
+   datetime TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+   assignee_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+   deleted_at TIMESTAMP,
+   deletion_id INT REFERENCES todo_deletions(deletion_id) ON DELETE SET NULL,
+   version INT NOT NULL DEFAULT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_todos_list ON todos(list_id);
+CREATE INDEX IF NOT EXISTS idx_todos_assignee ON todos(assignee_id) WHERE assignee_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_todos_deleted ON todos(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Every change of assignee, oldest first
+CREATE TABLE IF NOT EXISTS todo_assignments(
+   assignment_id INTEGER PRIMARY KEY AUTOINCREMENT,
+   todo_id INT NOT NULL REFERENCES todos(todo_id) ON DELETE CASCADE,
+   previous_assignee_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+   assignee_id INT REFERENCES users(user_id) ON DELETE SET NULL,
+   assigned_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+   assigned_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_assignments_todo ON todo_assignments(todo_id);
+
+-- Every version of a todo's editable fields, numbered per todo from 1
+CREATE TABLE IF NOT EXISTS todo_revisions(
+   revision_id INTEGER PRIMARY KEY AUTOINCREMENT,
+   todo_id INT NOT NULL REFERENCES todos(todo_id) ON DELETE CASCADE,
+   revision INT NOT NULL,
+   title TEXT,
+   description TEXT,
+   datetime TIMESTAMP NOT NULL,
+   changed_by INT REFERENCES users(user_id) ON DELETE SET NULL,
+   created_at TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')),
+   UNIQUE (todo_id, revision)
+);
+
+-- Full-text index over todos, kept in step by triggers; title matches are weighted at query time
+CREATE VIRTUAL TABLE IF NOT EXISTS todos_fts USING fts5(
+   title, description, content='todos', content_rowid='todo_id', tokenize='porter unicode61'
+);
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_insert AFTER INSERT ON todos BEGIN
+   INSERT INTO todos_fts(rowid, title, description) VALUES (new.todo_id, new.title, new.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_delete AFTER DELETE ON todos BEGIN
+   INSERT INTO todos_fts(todos_fts, rowid, title, description) VALUES ('delete', old.todo_id, old.title, old.description);
+END;
+
+CREATE TRIGGER IF NOT EXISTS todos_fts_update AFTER UPDATE OF title, description ON todos BEGIN
+   INSERT INTO todos_fts(todos_fts, rowid, title, description) VALUES ('delete', old.todo_id, old.title, old.description);
+   INSERT INTO todos_fts(rowid, title, description) VALUES (new.todo_id, new.title, new.description);
+END;
@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// APIKey is a long-lived credential a user creates for scripts and integrations.
+// Only a hash of the secret is stored; Prefix is kept in clear to find the key again.
+type APIKey struct {
+	KeyID      int        `json:"id"`
+	UserID     int        `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	return HasScope(k.Scopes, scope)
+}
@@ -0,0 +1,32 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records one mutation: who did what to which target, and how it looked before and after.
+// ActorID is nil for anonymous requests such as failed logins; SubjectID is the user whose data changed.
+type AuditEvent struct {
+	EventID    int64           `json:"id"`
+	ActorID    *int            `json:"actor_id"`
+	SubjectID  *int            `json:"subject_id"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip"`
+	RequestID  string          `json:"request_id"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows an audit query; zero values match everything
+type AuditFilter struct {
+	UserID int // events the user performed or that changed their data
+	Action string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,27 @@
+package entity
+
+// Batch operation kinds
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// BatchOperation is one create, update or delete in a batch request.
+// Creates use the todo fields and ListID; updates change the todo fields that are present;
+// updates and deletes name the todo with ID and may require its current Version.
+type BatchOperation struct {
+	Op      string `json:"op"`
+	ID      int    `json:"id,omitempty"`
+	Version int    `json:"version,omitempty"`
+	ListID  int    `json:"list_id,omitempty"`
+	TodoUpdate
+}
+
+// BatchOutcome is the result of one batch operation. Todo is set for successful creates and updates,
+// and Before, the todo as it was, for successful updates and deletes.
+type BatchOutcome struct {
+	Todo   *ToDo
+	Before *ToDo
+	Err    error
+}
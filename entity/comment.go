@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+// Comment is a Markdown message in the discussion thread of a todo
+type Comment struct {
+	CommentID int        `json:"id"`
+	TodoID    int        `json:"todo_id"`
+	UserID    int        `json:"user_id"`
+	UserName  string     `json:"user_name"`
+	Body      string     `json:"body"`
+	BodyHTML  string     `json:"body_html"` // rendered and sanitized on output, never stored
+	CreatedAt time.Time  `json:"created_at"`
+	EditedAt  *time.Time `json:"edited_at"`
+}
+
+// CommentRevision is an earlier body of an edited comment
+type CommentRevision struct {
+	RevisionID int       `json:"id"`
+	CommentID  int       `json:"comment_id"`
+	Body       string    `json:"body"`
+	BodyHTML   string    `json:"body_html"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,65 @@
+package entity
+
+import "time"
+
+// Roles a member can hold within a todo list
+const (
+	ListRoleViewer = "viewer"
+	ListRoleEditor = "editor"
+	ListRoleOwner  = "owner"
+)
+
+// Invitation states
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationDeclined = "declined"
+)
+
+// listRoleRanks orders list roles so permission checks can compare them
+var listRoleRanks = map[string]int{
+	ListRoleViewer: 1,
+	ListRoleEditor: 2,
+	ListRoleOwner:  3,
+}
+
+// ValidListRole reports whether role is one of the list roles
+func ValidListRole(role string) bool {
+	_, ok := listRoleRanks[role]
+	return ok
+}
+
+// ListRoleAtLeast reports whether role grants at least the access of minimum
+func ListRoleAtLeast(role, minimum string) bool {
+	return listRoleRanks[role] >= listRoleRanks[minimum] && listRoleRanks[role] > 0
+}
+
+// TodoList groups todos and is shared between its members
+type TodoList struct {
+	ListID    int       `json:"id"`
+	Name      string    `json:"name"`
+	OwnerID   int       `json:"owner_id"`
+	Personal  bool      `json:"personal"`
+	Role      string    `json:"role,omitempty"` // the requesting user's role in the list
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ListMember is a user with access to a list
+type ListMember struct {
+	ListID   int    `json:"list_id"`
+	UserID   int    `json:"user_id"`
+	UserName string `json:"user_name"`
+	Role     string `json:"role"`
+}
+
+// ListInvitation offers a role in a list to whoever owns the invited email address
+type ListInvitation struct {
+	InvitationID int       `json:"id"`
+	ListID       int       `json:"list_id"`
+	ListName     string    `json:"list_name"`
+	Email        string    `json:"email"`
+	Role         string    `json:"role"`
+	InvitedBy    int       `json:"invited_by"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package entity
+
+// Roles a user can hold
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permission names an action that is only granted to some roles
+type Permission string
+
+const (
+	PermissionManageUsers Permission = "users:manage"
+	PermissionViewUsers   Permission = "users:view"
+)
+
+// rolePermissions lists what each role is allowed to do beyond managing its own data
+var rolePermissions = map[string][]Permission{
+	RoleUser:  {},
+	RoleAdmin: {PermissionManageUsers, PermissionViewUsers},
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether the role grants the permission
+func HasPermission(role string, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package entity
+
+// Scopes limit what a token or API key may be used for
+const (
+	ScopeTodosRead      = "todos:read"
+	ScopeTodosWrite     = "todos:write"
+	ScopeTodosDeleteAll = "todos:delete_all"
+	ScopeAccount        = "account" // manage API keys and other account settings
+	ScopeAdmin          = "admin"   // use the admin API, together with a role that allows it
+)
+
+// DefaultScopes returns every scope a session of the given role may hold
+func DefaultScopes(role string) []string {
+	scopes := []string{ScopeTodosRead, ScopeTodosWrite, ScopeTodosDeleteAll, ScopeAccount}
+	if role == RoleAdmin {
+		scopes = append(scopes, ScopeAdmin)
+	}
+	return scopes
+}
+
+// HasScope reports whether scope is among scopes
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,17 @@
+package entity
+
+// SearchTerm is one part of a todo search: a single word, a quoted phrase whose words must appear
+// next to each other in order, or a word prefix written with a trailing '*'
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// TodoSearchResult is a todo matching a search, with its relevance and the matched text highlighted.
+// Highlights are HTML-escaped, with matches wrapped in <mark> elements.
+type TodoSearchResult struct {
+	Todo    ToDo    `json:"todo"`
+	Rank    float64 `json:"rank"`
+	Title   string  `json:"title_highlight"`
+	Snippet string  `json:"snippet"`
+}
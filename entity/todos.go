@@ -4,9 +4,63 @@ import "time"
 
 // ToDo represents a todo task linked to a specific user
 type ToDo struct {
-	ToDoID      int       `json:"id"`
+	ToDoID      int        `json:"id"`
+	Title       string     `json:"title"`
+	DateTime    time.Time  `json:"datetime"`
+	Description string     `json:"description"`
+	UserID      int        `json:"user_id"`
+	ListID      int        `json:"list_id"`
+	AssigneeID  *int       `json:"assignee_id"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+	Version     int        `json:"version"`
+}
+
+// TodoAssignment records one change of a todo's assignee; a nil assignee means unassigned
+type TodoAssignment struct {
+	AssignmentID       int       `json:"id"`
+	TodoID             int       `json:"todo_id"`
+	PreviousAssigneeID *int      `json:"previous_assignee_id"`
+	AssigneeID         *int      `json:"assignee_id"`
+	AssignedBy         int       `json:"assigned_by"`
+	AssignedAt         time.Time `json:"assigned_at"`
+}
+
+// TodoUpdate holds the fields of a todo to change; nil fields are left as they are
+type TodoUpdate struct {
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	DateTime    *time.Time `json:"datetime"`
+}
+
+// TodoRevision is one version of a todo's editable fields, numbered per todo from 1
+type TodoRevision struct {
+	Revision    int       `json:"revision"`
+	TodoID      int       `json:"todo_id"`
 	Title       string    `json:"title"`
-	DateTime    time.Time `json:"datetime"`
 	Description string    `json:"description"`
-	UserID      int       `json:"user_id"`
+	DateTime    time.Time `json:"datetime"`
+	ChangedBy   int       `json:"changed_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// FieldChange is one field that differs between two revisions
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Diff lists the fields that changed from this revision to the other one
+func (r TodoRevision) Diff(other TodoRevision) []FieldChange {
+	changes := []FieldChange{}
+	if r.Title != other.Title {
+		changes = append(changes, FieldChange{Field: "title", From: r.Title, To: other.Title})
+	}
+	if r.Description != other.Description {
+		changes = append(changes, FieldChange{Field: "description", From: r.Description, To: other.Description})
+	}
+	if !r.DateTime.Equal(other.DateTime) {
+		changes = append(changes, FieldChange{Field: "datetime", From: r.DateTime, To: other.DateTime})
+	}
+	return changes
 }
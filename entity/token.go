@@ -0,0 +1,27 @@
+package entity
+
+import "time"
+
+// TokenPurpose identifies what a single-use user token may be redeemed for.
+type TokenPurpose string
+
+const (
+	TokenPurposeEmailVerification TokenPurpose = "email_verification"
+	TokenPurposePasswordReset     TokenPurpose = "password_reset"
+)
+
+// UserToken is the server-side record of a single-use token sent to a user.
+type UserToken struct {
+	TokenID   string
+	UserID    int
+	Purpose   TokenPurpose
+	ExpiresAt time.Time
+	UsedAt    time.Time
+}
+
+// TokenClaims holds the values carried by an access token.
+type TokenClaims struct {
+	UserID   int
+	IssuedAt time.Time
+	Scopes   []string // nil for tokens issued before scopes existed
+}
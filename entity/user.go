@@ -1,9 +1,38 @@
 package entity
 
+import "time"
+
 // User represents a user in the system
 type User struct {
-	UserID   int    `json:"user_id"`
-	UserName string `json:"user_name"`
-	Password string `json:"password"`
-	Email    string `json:"email"` //ignoring storing password
+	UserID            int       `json:"user_id"`
+	UserName          string    `json:"user_name"`
+	Password          string    `json:"password"`
+	Email             string    `json:"email"` //ignoring storing password
+	EmailVerified     bool      `json:"email_verified"`
+	Role              string    `json:"role"`
+	Disabled          bool      `json:"disabled"`
+	SessionsRevokedAt time.Time `json:"-"` // tokens issued before this instant are rejected
+}
+
+// UserProfile is the representation of a user returned by the API.
+// It deliberately has no password field so hashes never leave the server.
+type UserProfile struct {
+	UserID        int    `json:"user_id"`
+	UserName      string `json:"user_name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
+	Disabled      bool   `json:"disabled"`
+}
+
+// Profile returns the public view of the user
+func (u *User) Profile() UserProfile {
+	return UserProfile{
+		UserID:        u.UserID,
+		UserName:      u.UserName,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Role:          u.Role,
+		Disabled:      u.Disabled,
+	}
 }
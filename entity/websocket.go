@@ -1,12 +1,68 @@
 package entity
 
-import "github.com/gorilla/websocket"
+import (
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
 
 type WebSocketConnection struct {
 	Conn *websocket.Conn
+	mu   sync.Mutex // gorilla/websocket allows only one concurrent writer
 }
 
 // SendMessage sends a message through the WebSocket connection.
 func (ws *WebSocketConnection) SendMessage(message []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 	return ws.Conn.WriteMessage(websocket.TextMessage, message)
 }
+
+// WebSocketRegistry keeps track of the open connections of each signed-in user
+type WebSocketRegistry struct {
+	mu          sync.RWMutex
+	connections map[int]map[*WebSocketConnection]struct{}
+}
+
+// NewWebSocketRegistry creates an empty WebSocketRegistry
+func NewWebSocketRegistry() *WebSocketRegistry {
+	return &WebSocketRegistry{connections: make(map[int]map[*WebSocketConnection]struct{})}
+}
+
+// Register adds a connection for the user
+func (r *WebSocketRegistry) Register(userID int, conn *WebSocketConnection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.connections[userID] == nil {
+		r.connections[userID] = make(map[*WebSocketConnection]struct{})
+	}
+	r.connections[userID][conn] = struct{}{}
+}
+
+// Unregister removes a connection of the user
+func (r *WebSocketRegistry) Unregister(userID int, conn *WebSocketConnection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.connections[userID], conn)
+	if len(r.connections[userID]) == 0 {
+		delete(r.connections, userID)
+	}
+}
+
+// SendToUser sends a message to every open connection of the user and returns how many received it
+func (r *WebSocketRegistry) SendToUser(userID int, message []byte) int {
+	r.mu.RLock()
+	conns := make([]*WebSocketConnection, 0, len(r.connections[userID]))
+	for conn := range r.connections[userID] {
+		conns = append(conns, conn)
+	}
+	r.mu.RUnlock()
+
+	sent := 0
+	for _, conn := range conns {
+		if err := conn.SendMessage(message); err == nil {
+			sent++
+		}
+	}
+	return sent
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of the APIKeyRepository
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, key *entity.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	args := m.Called(ctx, userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, keyID int) error {
+	args := m.Called(ctx, keyID)
+	return args.Error(0)
+}
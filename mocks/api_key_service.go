@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock APIKeyService for testing
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	args := m.Called(ctx, userID, name, scopes, expiresAt)
+	if args.Get(0) == nil {
+		return nil, args.String(1), args.Error(2)
+	}
+	return args.Get(0).(*entity.APIKey), args.String(1), args.Error(2)
+}
+
+func (m *MockAPIKeyService) ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	args := m.Called(ctx, userID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyService) Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error) {
+	args := m.Called(ctx, rawKey)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.APIKey), args.Error(1)
+}
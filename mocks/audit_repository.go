@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock AuditRepository for testing
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) CreateAuditEvent(ctx context.Context, event *entity.AuditEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) ListAuditEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditRepository) DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock AuditService for testing
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) Record(ctx context.Context, event *entity.AuditEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockAuditService) ListEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]entity.AuditEvent), args.Error(1)
+}
+
+func (m *MockAuditService) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(ctx, retention)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,46 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of the CommentRepository
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) CreateComment(ctx context.Context, comment *entity.Comment) error {
+	args := m.Called(ctx, comment)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetComment(ctx context.Context, todoID, commentID int) (*entity.Comment, error) {
+	args := m.Called(ctx, todoID, commentID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListComments(ctx context.Context, todoID, limit, offset int) ([]entity.Comment, error) {
+	args := m.Called(ctx, todoID, limit, offset)
+	return args.Get(0).([]entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) UpdateComment(ctx context.Context, comment *entity.Comment, body string) error {
+	args := m.Called(ctx, comment, body)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	args := m.Called(ctx, todoID, commentID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) GetCommentRevisions(ctx context.Context, commentID int) ([]entity.CommentRevision, error) {
+	args := m.Called(ctx, commentID)
+	return args.Get(0).([]entity.CommentRevision), args.Error(1)
+}
@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock CommentService for testing
+type MockCommentService struct {
+	mock.Mock
+}
+
+func (m *MockCommentService) CreateComment(ctx context.Context, userID, todoID int, body string) (*entity.Comment, []entity.User, error) {
+	args := m.Called(ctx, userID, todoID, body)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*entity.Comment), args.Get(1).([]entity.User), args.Error(2)
+}
+
+func (m *MockCommentService) ListComments(ctx context.Context, userID, todoID, limit, offset int) ([]entity.Comment, error) {
+	args := m.Called(ctx, userID, todoID, limit, offset)
+	return args.Get(0).([]entity.Comment), args.Error(1)
+}
+
+func (m *MockCommentService) UpdateComment(ctx context.Context, userID, todoID, commentID int, body string) (*entity.Comment, []entity.User, error) {
+	args := m.Called(ctx, userID, todoID, commentID, body)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*entity.Comment), args.Get(1).([]entity.User), args.Error(2)
+}
+
+func (m *MockCommentService) DeleteComment(ctx context.Context, userID, todoID, commentID int) error {
+	args := m.Called(ctx, userID, todoID, commentID)
+	return args.Error(0)
+}
+
+func (m *MockCommentService) GetCommentRevisions(ctx context.Context, userID, todoID, commentID int) ([]entity.CommentRevision, error) {
+	args := m.Called(ctx, userID, todoID, commentID)
+	return args.Get(0).([]entity.CommentRevision), args.Error(1)
+}
@@ -1,10 +1,16 @@
 package mocks
 
-import "github.com/stretchr/testify/mock"
+import (
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
 
 // MockJWTValidator is the mock implementation of JWTValidator
 type MockJWTValidator struct {
 	mock.Mock
+	Scopes []string // scopes carried by every parsed token, nil behaves like a token without the claim
 }
 
 // Mock implementation of ValidateToken
@@ -13,7 +19,12 @@ func (m *MockJWTValidator) ValidateToken(tokenString string) (int, error) {
 }
 
 // Mock implementation of GenerateToken
-func (m *MockJWTValidator) GenerateToken(userID int) (string, error) {
-	args := m.Called(userID)
+func (m *MockJWTValidator) GenerateToken(userID int, scopes []string) (string, error) {
+	args := m.Called(userID, scopes)
 	return args.String(0), args.Error(1)
 }
+
+// Mock implementation of ParseToken
+func (m *MockJWTValidator) ParseToken(tokenString string) (*entity.TokenClaims, error) {
+	return &entity.TokenClaims{UserID: 1, IssuedAt: time.Now(), Scopes: m.Scopes}, nil
+}
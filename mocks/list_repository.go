@@ -0,0 +1,79 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of the ListRepository
+type MockListRepository struct {
+	mock.Mock
+}
+
+func (m *MockListRepository) CreateList(ctx context.Context, list *entity.TodoList) error {
+	args := m.Called(ctx, list)
+	return args.Error(0)
+}
+
+func (m *MockListRepository) EnsurePersonalList(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockListRepository) GetLists(ctx context.Context, userID int) ([]entity.TodoList, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.TodoList), args.Error(1)
+}
+
+func (m *MockListRepository) GetList(ctx context.Context, userID, listID int) (*entity.TodoList, error) {
+	args := m.Called(ctx, userID, listID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.TodoList), args.Error(1)
+}
+
+func (m *MockListRepository) DeleteList(ctx context.Context, listID int) error {
+	args := m.Called(ctx, listID)
+	return args.Error(0)
+}
+
+func (m *MockListRepository) GetMemberRole(ctx context.Context, listID, userID int) (string, error) {
+	args := m.Called(ctx, listID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockListRepository) GetMembers(ctx context.Context, listID int) ([]entity.ListMember, error) {
+	args := m.Called(ctx, listID)
+	return args.Get(0).([]entity.ListMember), args.Error(1)
+}
+
+func (m *MockListRepository) SetMemberRole(ctx context.Context, listID, userID int, role string) error {
+	args := m.Called(ctx, listID, userID, role)
+	return args.Error(0)
+}
+
+func (m *MockListRepository) RemoveMember(ctx context.Context, listID, userID int) error {
+	args := m.Called(ctx, listID, userID)
+	return args.Error(0)
+}
+
+func (m *MockListRepository) CreateInvitation(ctx context.Context, invitation *entity.ListInvitation) error {
+	args := m.Called(ctx, invitation)
+	return args.Error(0)
+}
+
+func (m *MockListRepository) GetPendingInvitations(ctx context.Context, email string) ([]entity.ListInvitation, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).([]entity.ListInvitation), args.Error(1)
+}
+
+func (m *MockListRepository) RespondToInvitation(ctx context.Context, invitationID, userID int, email string, accept bool) (*entity.ListInvitation, error) {
+	args := m.Called(ctx, invitationID, userID, email, accept)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ListInvitation), args.Error(1)
+}
@@ -0,0 +1,75 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock ListService for testing
+type MockListService struct {
+	mock.Mock
+}
+
+func (m *MockListService) CreateList(ctx context.Context, userID int, name string) (*entity.TodoList, error) {
+	args := m.Called(ctx, userID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.TodoList), args.Error(1)
+}
+
+func (m *MockListService) GetLists(ctx context.Context, userID int) ([]entity.TodoList, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.TodoList), args.Error(1)
+}
+
+func (m *MockListService) GetList(ctx context.Context, userID, listID int) (*entity.TodoList, []entity.ListMember, error) {
+	args := m.Called(ctx, userID, listID)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*entity.TodoList), args.Get(1).([]entity.ListMember), args.Error(2)
+}
+
+func (m *MockListService) GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error) {
+	args := m.Called(ctx, userID, listID)
+	return args.Get(0).([]entity.ToDo), args.Error(1)
+}
+
+func (m *MockListService) DeleteList(ctx context.Context, userID, listID int) error {
+	args := m.Called(ctx, userID, listID)
+	return args.Error(0)
+}
+
+func (m *MockListService) InviteMember(ctx context.Context, userID, listID int, email, role string) (*entity.ListInvitation, error) {
+	args := m.Called(ctx, userID, listID, email, role)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ListInvitation), args.Error(1)
+}
+
+func (m *MockListService) GetInvitations(ctx context.Context, userID int) ([]entity.ListInvitation, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.ListInvitation), args.Error(1)
+}
+
+func (m *MockListService) RespondToInvitation(ctx context.Context, userID, invitationID int, accept bool) (*entity.ListInvitation, error) {
+	args := m.Called(ctx, userID, invitationID, accept)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.ListInvitation), args.Error(1)
+}
+
+func (m *MockListService) SetMemberRole(ctx context.Context, userID, listID, memberID int, role string) error {
+	args := m.Called(ctx, userID, listID, memberID, role)
+	return args.Error(0)
+}
+
+func (m *MockListService) RemoveMember(ctx context.Context, userID, listID, memberID int) error {
+	args := m.Called(ctx, userID, listID, memberID)
+	return args.Error(0)
+}
@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLoginThrottler is the mock implementation of LoginThrottler
+type MockLoginThrottler struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottler) Blocked(username, ip string) (time.Duration, error) {
+	args := m.Called(username, ip)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MockLoginThrottler) RecordFailure(username, ip string) (bool, error) {
+	args := m.Called(username, ip)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockLoginThrottler) RecordSuccess(username string) error {
+	args := m.Called(username)
+	return args.Error(0)
+}
@@ -2,7 +2,7 @@ package mocks
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 )
 
 // MockEmailSender is a mock implementation of the EmailSender interface for testing purposes.
@@ -15,6 +15,6 @@ func (m *MockEmailSender) SendEmail(to []string, subject, body string) error {
 	// Simulate the email sending by appending to the SentEmails slice.
 	emailDetails := fmt.Sprintf("To: %s, Subject: %s, Body: %s", to[0], subject, body)
 	m.SentEmails = append(m.SentEmails, emailDetails)
-	log.Println("sending...", emailDetails)
+	slog.Info("sending...", "email", emailDetails)
 	return nil // Return nil to indicate success.
 }
@@ -17,7 +17,37 @@ func (m *MockRedisClient) Incr(key string) *redis.IntCmd {
 	return args.Get(0).(*redis.IntCmd)
 }
 
+func (m *MockRedisClient) IncrBy(key string, value int64) *redis.IntCmd {
+	args := m.Called(key, value)
+	return args.Get(0).(*redis.IntCmd)
+}
+
 func (m *MockRedisClient) Expire(key string, expiration time.Duration) *redis.BoolCmd {
 	args := m.Called(key, expiration)
 	return args.Get(0).(*redis.BoolCmd)
 }
+
+func (m *MockRedisClient) Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	args := m.Called(key, value, expiration)
+	return args.Get(0).(*redis.StatusCmd)
+}
+
+func (m *MockRedisClient) Del(keys ...string) *redis.IntCmd {
+	args := m.Called(keys)
+	return args.Get(0).(*redis.IntCmd)
+}
+
+func (m *MockRedisClient) SetNX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	args := m.Called(key, value, expiration)
+	return args.Get(0).(*redis.BoolCmd)
+}
+
+func (m *MockRedisClient) Get(key string) *redis.StringCmd {
+	args := m.Called(key)
+	return args.Get(0).(*redis.StringCmd)
+}
+
+func (m *MockRedisClient) PTTL(key string) *redis.DurationCmd {
+	args := m.Called(key)
+	return args.Get(0).(*redis.DurationCmd)
+}
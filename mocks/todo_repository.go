@@ -2,8 +2,10 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -27,8 +29,8 @@ func (m *MockToDoRepository) GetTodo(ctx context.Context, userID, todoID int) (e
 	return args.Get(0).(entity.ToDo), args.Error(1)
 }
 
-func (m *MockToDoRepository) DeleteToDo(ctx context.Context, userID, todoID int) error {
-	args := m.Called(ctx, userID, todoID)
+func (m *MockToDoRepository) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	args := m.Called(ctx, userID, todoID, expectedVersion)
 	return args.Error(0)
 }
 
@@ -36,3 +38,78 @@ func (m *MockToDoRepository) DeleteAllTodos(ctx context.Context, userID int) err
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+
+func (m *MockToDoRepository) GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error) {
+	args := m.Called(ctx, userID, listID)
+	return args.Get(0).([]entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoRepository) GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoRepository) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	args := m.Called(ctx, userID, todoID, assigneeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.TodoAssignment), args.Error(1)
+}
+
+func (m *MockToDoRepository) GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error) {
+	args := m.Called(ctx, userID, todoID)
+	return args.Get(0).([]entity.TodoAssignment), args.Error(1)
+}
+
+func (m *MockToDoRepository) GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoRepository) RestoreToDo(ctx context.Context, userID, todoID int) error {
+	args := m.Called(ctx, userID, todoID)
+	return args.Error(0)
+}
+
+func (m *MockToDoRepository) UndoDeleteAll(ctx context.Context, userID int, since time.Time) (int, error) {
+	args := m.Called(ctx, userID, since)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockToDoRepository) PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockToDoRepository) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	args := m.Called(ctx, userID, todoID, update, expectedVersion)
+	return args.Get(0).(entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoRepository) GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error) {
+	args := m.Called(ctx, userID, todoID)
+	return args.Get(0).([]entity.TodoRevision), args.Error(1)
+}
+
+func (m *MockToDoRepository) GetRevision(ctx context.Context, userID, todoID, revision int) (entity.TodoRevision, error) {
+	args := m.Called(ctx, userID, todoID, revision)
+	return args.Get(0).(entity.TodoRevision), args.Error(1)
+}
+
+func (m *MockToDoRepository) SearchTodos(ctx context.Context, userID int, terms []entity.SearchTerm, limit, offset int) ([]entity.TodoSearchResult, error) {
+	args := m.Called(ctx, userID, terms, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]entity.TodoSearchResult), args.Error(1)
+}
+
+// WithTx runs fn against the mock itself, as if all of its calls shared one transaction
+func (m *MockToDoRepository) WithTx(ctx context.Context, fn func(repo repository.ToDoRepository) error) error {
+	args := m.Called(ctx)
+	if err := args.Error(0); err != nil {
+		return err
+	}
+	return fn(m)
+}
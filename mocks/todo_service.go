@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/stretchr/testify/mock"
@@ -27,8 +28,8 @@ func (m *MockToDoService) GetTodo(ctx context.Context, userID, todoID int) (enti
 	return args.Get(0).(entity.ToDo), args.Error(1)
 }
 
-func (m *MockToDoService) DeleteToDo(ctx context.Context, userID, todoID int) error {
-	args := m.Called(ctx, userID, todoID)
+func (m *MockToDoService) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	args := m.Called(ctx, userID, todoID, expectedVersion)
 	return args.Error(0)
 }
 
@@ -36,3 +37,80 @@ func (m *MockToDoService) DeleteAllTodos(ctx context.Context, userID int) error
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+
+func (m *MockToDoService) GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoService) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	args := m.Called(ctx, userID, todoID, assigneeID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.TodoAssignment), args.Error(1)
+}
+
+func (m *MockToDoService) GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error) {
+	args := m.Called(ctx, userID, todoID)
+	return args.Get(0).([]entity.TodoAssignment), args.Error(1)
+}
+
+func (m *MockToDoService) GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoService) RestoreToDo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	args := m.Called(ctx, userID, todoID)
+	return args.Get(0).(entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoService) UndoDeleteAll(ctx context.Context, userID int) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockToDoService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	args := m.Called(ctx, retention)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockToDoService) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	args := m.Called(ctx, userID, todoID, update, expectedVersion)
+	return args.Get(0).(entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoService) GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error) {
+	args := m.Called(ctx, userID, todoID)
+	return args.Get(0).([]entity.TodoRevision), args.Error(1)
+}
+
+func (m *MockToDoService) DiffRevisions(ctx context.Context, userID, todoID, from, to int) ([]entity.FieldChange, error) {
+	args := m.Called(ctx, userID, todoID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]entity.FieldChange), args.Error(1)
+}
+
+func (m *MockToDoService) RevertToDo(ctx context.Context, userID, todoID, revision, expectedVersion int) (entity.ToDo, error) {
+	args := m.Called(ctx, userID, todoID, revision, expectedVersion)
+	return args.Get(0).(entity.ToDo), args.Error(1)
+}
+
+func (m *MockToDoService) RunBatch(ctx context.Context, userID int, ops []entity.BatchOperation, atomic bool) ([]entity.BatchOutcome, error) {
+	args := m.Called(ctx, userID, ops, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]entity.BatchOutcome), args.Error(1)
+}
+
+func (m *MockToDoService) SearchTodos(ctx context.Context, userID int, query string, limit, offset int) ([]entity.TodoSearchResult, error) {
+	args := m.Called(ctx, userID, query, limit, offset)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]entity.TodoSearchResult), args.Error(1)
+}
@@ -36,3 +36,33 @@ func (m *MockUserRepository) DeleteUser(ctx context.Context, userID int) error {
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error) {
+	args := m.Called(ctx, search, limit, offset)
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	args := m.Called(ctx, userID, disabled)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/stretchr/testify/mock"
@@ -47,3 +48,36 @@ func (m *MockUserService) CheckPasswordHash(password, hash string) bool {
 	args := m.Called(password, hash)
 	return args.Bool(0)
 }
+
+// Mock method for ValidateSession
+func (m *MockUserService) ValidateSession(ctx context.Context, userID int, issuedAt time.Time) (*entity.User, error) {
+	args := m.Called(ctx, userID, issuedAt)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.User), args.Error(1)
+}
+
+// Mock method for ListUsers
+func (m *MockUserService) ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error) {
+	args := m.Called(ctx, search, limit, offset)
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
+// Mock method for SetUserDisabled
+func (m *MockUserService) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	args := m.Called(ctx, userID, disabled)
+	return args.Error(0)
+}
+
+// Mock method for SetUserRole
+func (m *MockUserService) SetUserRole(ctx context.Context, userID int, role string) error {
+	args := m.Called(ctx, userID, role)
+	return args.Error(0)
+}
+
+// Mock method for SetPassword
+func (m *MockUserService) SetPassword(ctx context.Context, userID int, password string) error {
+	args := m.Called(ctx, userID, password)
+	return args.Error(0)
+}
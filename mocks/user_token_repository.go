@@ -0,0 +1,31 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/stretchr/testify/mock"
+)
+
+// Mock implementation of the UserTokenRepository
+type MockUserTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserTokenRepository) CreateToken(ctx context.Context, token *entity.UserToken) error {
+	args := m.Called(ctx, token)
+	return args.Error(0)
+}
+
+func (m *MockUserTokenRepository) ConsumeToken(ctx context.Context, tokenID string, purpose entity.TokenPurpose) (*entity.UserToken, error) {
+	args := m.Called(ctx, tokenID, purpose)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*entity.UserToken), args.Error(1)
+}
+
+func (m *MockUserTokenRepository) InvalidateTokens(ctx context.Context, userID int, purpose entity.TokenPurpose) error {
+	args := m.Called(ctx, userID, purpose)
+	return args.Error(0)
+}
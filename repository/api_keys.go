@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// APIKeyRepository defines the interface for API key operations
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *entity.APIKey) error
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	TouchAPIKey(ctx context.Context, keyID int) error
+}
+
+const apiKeyColumns = "key_id, user_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at"
+
+// PostgresAPIKeyRepository implements the APIKeyRepository interface using PostgreSQL
+type PostgresAPIKeyRepository struct {
+	DB *sql.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new PostgresAPIKeyRepository
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{DB: db}
+}
+
+// CreateAPIKey inserts a new API key and sets its generated ID and creation time
+func (r *PostgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key *entity.APIKey) error {
+	return r.DB.QueryRowContext(ctx,
+		`INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6) RETURNING key_id, created_at`,
+		key.UserID, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.ExpiresAt,
+	).Scan(&key.KeyID, &key.CreatedAt)
+}
+
+// GetAPIKeyByPrefix retrieves an API key by its lookup prefix
+func (r *PostgresAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*entity.APIKey, error) {
+	key, err := scanAPIKey(r.DB.QueryRowContext(ctx, "SELECT "+apiKeyColumns+" FROM api_keys WHERE prefix = $1", prefix))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("api key")
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// ListAPIKeys retrieves all API keys of a user, newest first
+func (r *PostgresAPIKeyRepository) ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []entity.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes one of the user's API keys
+func (r *PostgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	result, err := r.DB.ExecContext(ctx,
+		"UPDATE api_keys SET revoked_at = NOW() WHERE key_id = $1 AND user_id = $2 AND revoked_at IS NULL",
+		keyID, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound("api key")
+	}
+	return nil
+}
+
+// TouchAPIKey records that the key was just used.
+// Writes are skipped when the stored timestamp is less than a minute old to keep busy keys cheap.
+func (r *PostgresAPIKeyRepository) TouchAPIKey(ctx context.Context, keyID int) error {
+	_, err := r.DB.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = NOW()
+		 WHERE key_id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`,
+		keyID)
+	return err
+}
+
+// scanAPIKey reads a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.KeyID, &key.UserID, &key.Name, &key.Prefix, &key.Hash, pq.Array(&key.Scopes),
+		&expiresAt, &lastUsedAt, &revokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.ExpiresAt = nullTimePtr(expiresAt)
+	key.LastUsedAt = nullTimePtr(lastUsedAt)
+	key.RevokedAt = nullTimePtr(revokedAt)
+	return &key, nil
+}
+
+// nullTimePtr converts a nullable column into an optional time
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// AuditRepository defines the interface for the append-only audit log
+type AuditRepository interface {
+	CreateAuditEvent(ctx context.Context, event *entity.AuditEvent) error
+	ListAuditEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error)
+	DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+const auditColumns = "event_id, actor_id, subject_id, action, target_type, target_id, before, after, ip, request_id, created_at"
+
+// PostgresAuditRepository implements the AuditRepository interface using PostgreSQL
+type PostgresAuditRepository struct {
+	DB *sql.DB
+}
+
+// NewPostgresAuditRepository creates a new PostgresAuditRepository
+func NewPostgresAuditRepository(db *sql.DB) *PostgresAuditRepository {
+	return &PostgresAuditRepository{DB: db}
+}
+
+// CreateAuditEvent appends an event and sets its generated ID and time
+func (r *PostgresAuditRepository) CreateAuditEvent(ctx context.Context, event *entity.AuditEvent) error {
+	return r.DB.QueryRowContext(ctx,
+		`INSERT INTO audit_events (actor_id, subject_id, action, target_type, target_id, before, after, ip, request_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING event_id, created_at`,
+		event.ActorID, event.SubjectID, event.Action, event.TargetType, event.TargetID,
+		nullJSON(event.Before), nullJSON(event.After), event.IP, event.RequestID,
+	).Scan(&event.EventID, &event.CreatedAt)
+}
+
+// ListAuditEvents retrieves events matching the filter, newest first
+func (r *PostgresAuditRepository) ListAuditEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	var conditions []string
+	var args []interface{}
+	where := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserID != 0 {
+		where("(actor_id = $%[1]d OR subject_id = $%[1]d)", filter.UserID)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if !filter.Since.IsZero() {
+		where("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		where("created_at < $%d", filter.Until)
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_events"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY event_id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []entity.AuditEvent{}
+	for rows.Next() {
+		var event entity.AuditEvent
+		var actorID, subjectID sql.NullInt64
+		var before, after []byte
+		if err := rows.Scan(&event.EventID, &actorID, &subjectID, &event.Action, &event.TargetType, &event.TargetID,
+			&before, &after, &event.IP, &event.RequestID, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		event.ActorID = nullIntPtr(actorID)
+		event.SubjectID = nullIntPtr(subjectID)
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// DeleteAuditEventsBefore removes events older than the retention cutoff and returns how many were removed
+func (r *PostgresAuditRepository) DeleteAuditEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.DB.ExecContext(ctx, "DELETE FROM audit_events WHERE created_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// nullJSON stores empty snapshots as NULL rather than invalid JSON
+func nullJSON(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// CommentRepository defines the interface for todo comment operations.
+// Access to the todo is checked by the caller.
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment *entity.Comment) error
+	GetComment(ctx context.Context, todoID, commentID int) (*entity.Comment, error)
+	ListComments(ctx context.Context, todoID, limit, offset int) ([]entity.Comment, error)
+	UpdateComment(ctx context.Context, comment *entity.Comment, body string) error
+	DeleteComment(ctx context.Context, todoID, commentID int) error
+	GetCommentRevisions(ctx context.Context, commentID int) ([]entity.CommentRevision, error)
+}
+
+const commentColumns = "c.comment_id, c.todo_id, COALESCE(c.user_id, 0), COALESCE(u.username, ''), c.body, c.created_at, c.edited_at"
+
+const commentFrom = " FROM todo_comments c LEFT JOIN users u ON u.user_id = c.user_id"
+
+// PostgresCommentRepository implements the CommentRepository interface using PostgreSQL
+type PostgresCommentRepository struct {
+	DB *sql.DB
+}
+
+// NewPostgresCommentRepository creates a new PostgresCommentRepository
+func NewPostgresCommentRepository(db *sql.DB) *PostgresCommentRepository {
+	return &PostgresCommentRepository{DB: db}
+}
+
+// CreateComment inserts a comment and sets its generated ID and creation time
+func (r *PostgresCommentRepository) CreateComment(ctx context.Context, comment *entity.Comment) error {
+	return r.DB.QueryRowContext(ctx,
+		"INSERT INTO todo_comments (todo_id, user_id, body) VALUES ($1, $2, $3) RETURNING comment_id, created_at",
+		comment.TodoID, comment.UserID, comment.Body,
+	).Scan(&comment.CommentID, &comment.CreatedAt)
+}
+
+// GetComment retrieves a comment of a todo
+func (r *PostgresCommentRepository) GetComment(ctx context.Context, todoID, commentID int) (*entity.Comment, error) {
+	comment, err := scanComment(r.DB.QueryRowContext(ctx,
+		"SELECT "+commentColumns+commentFrom+" WHERE c.todo_id = $1 AND c.comment_id = $2", todoID, commentID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("comment")
+		}
+		return nil, err
+	}
+	return comment, nil
+}
+
+// ListComments retrieves a page of a todo's comments, oldest first
+func (r *PostgresCommentRepository) ListComments(ctx context.Context, todoID, limit, offset int) ([]entity.Comment, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT "+commentColumns+commentFrom+" WHERE c.todo_id = $1 ORDER BY c.comment_id LIMIT $2 OFFSET $3",
+		todoID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []entity.Comment{}
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, *comment)
+	}
+	return comments, rows.Err()
+}
+
+// UpdateComment replaces the body of a comment, keeping the previous body as a revision
+func (r *PostgresCommentRepository) UpdateComment(ctx context.Context, comment *entity.Comment, body string) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Keep the stored body, not the caller's copy, so concurrent edits cannot lose a revision
+	var previous string
+	err = tx.QueryRowContext(ctx,
+		"SELECT body FROM todo_comments WHERE comment_id = $1 FOR UPDATE", comment.CommentID).Scan(&previous)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return notFound("comment")
+		}
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO todo_comment_revisions (comment_id, body) VALUES ($1, $2)", comment.CommentID, previous); err != nil {
+		return err
+	}
+
+	var editedAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		"UPDATE todo_comments SET body = $1, edited_at = NOW() WHERE comment_id = $2 RETURNING edited_at",
+		body, comment.CommentID,
+	).Scan(&editedAt)
+	if err != nil {
+		return err
+	}
+	comment.Body = body
+	comment.EditedAt = nullTimePtr(editedAt)
+	return tx.Commit()
+}
+
+// DeleteComment deletes a comment and its revisions
+func (r *PostgresCommentRepository) DeleteComment(ctx context.Context, todoID, commentID int) error {
+	result, err := r.DB.ExecContext(ctx,
+		"DELETE FROM todo_comments WHERE todo_id = $1 AND comment_id = $2", todoID, commentID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound("comment")
+	}
+	return nil
+}
+
+// GetCommentRevisions retrieves the earlier bodies of a comment, oldest first
+func (r *PostgresCommentRepository) GetCommentRevisions(ctx context.Context, commentID int) ([]entity.CommentRevision, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT revision_id, comment_id, body, created_at FROM todo_comment_revisions WHERE comment_id = $1 ORDER BY revision_id",
+		commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []entity.CommentRevision{}
+	for rows.Next() {
+		var revision entity.CommentRevision
+		if err := rows.Scan(&revision.RevisionID, &revision.CommentID, &revision.Body, &revision.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// scanComment reads a row selected with commentColumns
+func scanComment(row rowScanner) (*entity.Comment, error) {
+	var comment entity.Comment
+	var editedAt sql.NullTime
+	err := row.Scan(&comment.CommentID, &comment.TodoID, &comment.UserID, &comment.UserName,
+		&comment.Body, &comment.CreatedAt, &editedAt)
+	if err != nil {
+		return nil, err
+	}
+	comment.EditedAt = nullTimePtr(editedAt)
+	return &comment, nil
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+var (
+	// ErrNotFound is wrapped by the errors for records that do not exist or that the caller cannot see,
+	// such as "todo not found"
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is wrapped by the errors for writes that clash with the stored data,
+	// such as a username that is already taken or a change against a stale version
+	ErrConflict = errors.New("conflict")
+)
+
+// notFound returns the ErrNotFound for a kind of record, such as "todo not found"
+func notFound(record string) error {
+	return fmt.Errorf("%s %w", record, ErrNotFound)
+}
+
+// uniqueViolation wraps a unique constraint violation reported by either database in ErrConflict
+// and returns any other error unchanged
+func uniqueViolation(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: %s", ErrConflict, pqErr.Message)
+	}
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return fmt.Errorf("%w: %s", ErrConflict, sqliteErr.Error())
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// errNotChecked is the state of a HealthProbe before its first check
+var errNotChecked = errors.New("database has not been checked yet")
+
+// pinger is the part of *sql.DB a HealthProbe needs
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// HealthProbe pings a database periodically and remembers the outcome, so that readiness checks
+// answer instantly instead of queueing behind a struggling database
+type HealthProbe struct {
+	db       pinger
+	interval time.Duration
+	timeout  time.Duration
+
+	mu  sync.RWMutex
+	err error
+}
+
+// NewHealthProbe creates a HealthProbe that pings db every interval, giving up on a ping after timeout
+func NewHealthProbe(db pinger, interval, timeout time.Duration) *HealthProbe {
+	return &HealthProbe{db: db, interval: interval, timeout: timeout, err: errNotChecked}
+}
+
+// Start checks the database right away and then every interval until ctx is cancelled
+func (p *HealthProbe) Start(ctx context.Context) {
+	p.Check(ctx)
+	go func() {
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.Check(ctx)
+			}
+		}
+	}()
+}
+
+// Check pings the database once and records the outcome, logging changes between healthy and not
+func (p *HealthProbe) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	err := p.db.PingContext(ctx)
+
+	p.mu.Lock()
+	previous := p.err
+	p.err = err
+	p.mu.Unlock()
+
+	if err != nil && previous == nil {
+		slog.Error("database health check failed", "error", err)
+	} else if err == nil && previous != nil && previous != errNotChecked {
+		slog.Info("database health check recovered")
+	}
+	return err
+}
+
+// Healthy returns nil when the last check reached the database, and its error otherwise
+func (p *HealthProbe) Healthy() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.err
+}
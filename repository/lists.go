@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// ListRepository defines the interface for todo list, membership and invitation operations
+type ListRepository interface {
+	CreateList(ctx context.Context, list *entity.TodoList) error
+	EnsurePersonalList(ctx context.Context, userID int) (int, error)
+	GetLists(ctx context.Context, userID int) ([]entity.TodoList, error)
+	GetList(ctx context.Context, userID, listID int) (*entity.TodoList, error)
+	DeleteList(ctx context.Context, listID int) error
+	GetMemberRole(ctx context.Context, listID, userID int) (string, error)
+	GetMembers(ctx context.Context, listID int) ([]entity.ListMember, error)
+	SetMemberRole(ctx context.Context, listID, userID int, role string) error
+	RemoveMember(ctx context.Context, listID, userID int) error
+	CreateInvitation(ctx context.Context, invitation *entity.ListInvitation) error
+	GetPendingInvitations(ctx context.Context, email string) ([]entity.ListInvitation, error)
+	RespondToInvitation(ctx context.Context, invitationID, userID int, email string, accept bool) (*entity.ListInvitation, error)
+}
+
+const listColumns = "l.list_id, l.name, l.owner_id, l.personal, m.role, l.created_at"
+
+const invitationColumns = "i.invitation_id, i.list_id, l.name, i.email, i.role, COALESCE(i.invited_by, 0), i.status, i.created_at"
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// PostgresListRepository implements the ListRepository interface using PostgreSQL
+type PostgresListRepository struct {
+	DB *sql.DB
+}
+
+// NewPostgresListRepository creates a new PostgresListRepository
+func NewPostgresListRepository(db *sql.DB) *PostgresListRepository {
+	return &PostgresListRepository{DB: db}
+}
+
+// CreateList inserts a list and makes its creator the owner
+func (r *PostgresListRepository) CreateList(ctx context.Context, list *entity.TodoList) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO lists (name, owner_id) VALUES ($1, $2) RETURNING list_id, created_at",
+		list.Name, list.OwnerID,
+	).Scan(&list.ListID, &list.CreatedAt)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO list_members (list_id, user_id, role) VALUES ($1, $2, 'owner')", list.ListID, list.OwnerID); err != nil {
+		return err
+	}
+	list.Role = entity.ListRoleOwner
+	return tx.Commit()
+}
+
+// EnsurePersonalList returns the ID of the user's personal list, creating it on first use
+func (r *PostgresListRepository) EnsurePersonalList(ctx context.Context, userID int) (int, error) {
+	return ensurePersonalList(ctx, r.DB, userID)
+}
+
+// GetLists retrieves every list the user is a member of, personal list first
+func (r *PostgresListRepository) GetLists(ctx context.Context, userID int) ([]entity.TodoList, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT "+listColumns+" FROM lists l JOIN list_members m ON m.list_id = l.list_id AND m.user_id = $1"+
+			" ORDER BY l.personal DESC, l.name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []entity.TodoList{}
+	for rows.Next() {
+		var list entity.TodoList
+		if err := rows.Scan(&list.ListID, &list.Name, &list.OwnerID, &list.Personal, &list.Role, &list.CreatedAt); err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+	}
+	return lists, rows.Err()
+}
+
+// GetList retrieves a list the user is a member of, along with the user's role in it
+func (r *PostgresListRepository) GetList(ctx context.Context, userID, listID int) (*entity.TodoList, error) {
+	var list entity.TodoList
+	err := r.DB.QueryRowContext(ctx,
+		"SELECT "+listColumns+" FROM lists l JOIN list_members m ON m.list_id = l.list_id AND m.user_id = $1"+
+			" WHERE l.list_id = $2", userID, listID,
+	).Scan(&list.ListID, &list.Name, &list.OwnerID, &list.Personal, &list.Role, &list.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("list")
+		}
+		return nil, err
+	}
+	return &list, nil
+}
+
+// DeleteList deletes a list together with its todos, members and invitations
+func (r *PostgresListRepository) DeleteList(ctx context.Context, listID int) error {
+	return r.execOne(ctx, "list", "DELETE FROM lists WHERE list_id = $1", listID)
+}
+
+// GetMemberRole returns the user's role in the list, or an empty string when they are not a member
+func (r *PostgresListRepository) GetMemberRole(ctx context.Context, listID, userID int) (string, error) {
+	var role string
+	err := r.DB.QueryRowContext(ctx,
+		"SELECT role FROM list_members WHERE list_id = $1 AND user_id = $2", listID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// GetMembers retrieves the members of a list
+func (r *PostgresListRepository) GetMembers(ctx context.Context, listID int) ([]entity.ListMember, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		`SELECT m.list_id, m.user_id, u.username, m.role FROM list_members m
+		 JOIN users u ON u.user_id = m.user_id WHERE m.list_id = $1 ORDER BY u.username`, listID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []entity.ListMember{}
+	for rows.Next() {
+		var member entity.ListMember
+		if err := rows.Scan(&member.ListID, &member.UserID, &member.UserName, &member.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// SetMemberRole changes the role of an existing member
+func (r *PostgresListRepository) SetMemberRole(ctx context.Context, listID, userID int, role string) error {
+	return r.execOne(ctx, "member",
+		"UPDATE list_members SET role = $3 WHERE list_id = $1 AND user_id = $2", listID, userID, role)
+}
+
+// RemoveMember removes a user from a list
+func (r *PostgresListRepository) RemoveMember(ctx context.Context, listID, userID int) error {
+	return r.execOne(ctx, "member",
+		"DELETE FROM list_members WHERE list_id = $1 AND user_id = $2", listID, userID)
+}
+
+// CreateInvitation inserts a pending invitation and sets its generated ID and creation time
+func (r *PostgresListRepository) CreateInvitation(ctx context.Context, invitation *entity.ListInvitation) error {
+	invitation.Status = entity.InvitationPending
+	return r.DB.QueryRowContext(ctx,
+		`INSERT INTO list_invitations (list_id, email, role, invited_by)
+		 VALUES ($1, $2, $3, $4) RETURNING invitation_id, created_at`,
+		invitation.ListID, invitation.Email, invitation.Role, invitation.InvitedBy,
+	).Scan(&invitation.InvitationID, &invitation.CreatedAt)
+}
+
+// GetPendingInvitations retrieves the open invitations addressed to email
+func (r *PostgresListRepository) GetPendingInvitations(ctx context.Context, email string) ([]entity.ListInvitation, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT "+invitationColumns+" FROM list_invitations i JOIN lists l ON l.list_id = i.list_id"+
+			" WHERE i.email = $1 AND i.status = 'pending' ORDER BY i.created_at DESC", email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []entity.ListInvitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *invitation)
+	}
+	return invitations, rows.Err()
+}
+
+// RespondToInvitation closes a pending invitation addressed to email.
+// Accepting adds the user to the list, or changes their role if they already belong to it.
+func (r *PostgresListRepository) RespondToInvitation(ctx context.Context, invitationID, userID int, email string, accept bool) (*entity.ListInvitation, error) {
+	status := entity.InvitationDeclined
+	if accept {
+		status = entity.InvitationAccepted
+	}
+
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invitation, err := scanInvitation(tx.QueryRowContext(ctx,
+		`WITH updated AS (
+		   UPDATE list_invitations SET status = $3, responded_at = NOW()
+		   WHERE invitation_id = $1 AND email = $2 AND status = 'pending' RETURNING *
+		 )
+		 SELECT `+invitationColumns+` FROM updated i JOIN lists l ON l.list_id = i.list_id`,
+		invitationID, email, status))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("invitation")
+		}
+		return nil, err
+	}
+
+	if accept {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO list_members (list_id, user_id, role) VALUES ($1, $2, $3)
+			 ON CONFLICT (list_id, user_id) DO UPDATE SET role = EXCLUDED.role`,
+			invitation.ListID, userID, invitation.Role); err != nil {
+			return nil, err
+		}
+	}
+	return invitation, tx.Commit()
+}
+
+// scanInvitation reads a row selected with invitationColumns
+func scanInvitation(row rowScanner) (*entity.ListInvitation, error) {
+	var invitation entity.ListInvitation
+	err := row.Scan(&invitation.InvitationID, &invitation.ListID, &invitation.ListName, &invitation.Email,
+		&invitation.Role, &invitation.InvitedBy, &invitation.Status, &invitation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// ensurePersonalList returns the user's personal list ID, creating the list and its owner membership if missing.
+// It is a single statement so concurrent callers cannot create two personal lists.
+func ensurePersonalList(ctx context.Context, q queryer, userID int) (int, error) {
+	var listID int
+	err := q.QueryRowContext(ctx,
+		`WITH created AS (
+		   INSERT INTO lists (name, owner_id, personal) VALUES ('Personal', $1, TRUE)
+		   ON CONFLICT (owner_id) WHERE personal DO NOTHING RETURNING list_id, owner_id
+		 ), membership AS (
+		   INSERT INTO list_members (list_id, user_id, role) SELECT list_id, owner_id, 'owner' FROM created
+		 )
+		 SELECT list_id FROM created
+		 UNION ALL SELECT list_id FROM lists WHERE owner_id = $1 AND personal
+		 LIMIT 1`, userID).Scan(&listID)
+	return listID, err
+}
+
+// execOne runs a statement that is expected to touch exactly one row, reporting the record as not found otherwise
+func (r *PostgresListRepository) execOne(ctx context.Context, record string, query string, args ...interface{}) error {
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound(record)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// MemoryStore keeps users and todos in memory, for tests and dev mode. Repositories built on the
+// same store share its data and follow the same rules as the database-backed ones: unique
+// usernames and emails, a personal list per user, and list membership deciding who can see and
+// change a todo. It is safe for concurrent use.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	data *memoryData
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: newMemoryData()}
+}
+
+// read runs fn with the store's data locked for reading
+func (s *MemoryStore) read(fn func(d *memoryData) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.data)
+}
+
+// write runs fn with the store's data locked for writing
+func (s *MemoryStore) write(fn func(d *memoryData) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+type memoryList struct {
+	ownerID  int
+	personal bool
+}
+
+type memoryDeletion struct {
+	userID    int
+	listID    int
+	deletedAt time.Time
+}
+
+type memoryTodo struct {
+	entity.ToDo
+	deletionID int
+}
+
+// memoryData is everything a MemoryStore holds. Values are replaced rather than changed in place,
+// so a shallow copy of each map is enough to take a snapshot.
+type memoryData struct {
+	lastID      map[string]int
+	users       map[int]entity.User
+	lists       map[int]memoryList
+	members     map[int]map[int]string // list ID -> user ID -> role
+	todos       map[int]memoryTodo
+	deletions   map[int]memoryDeletion
+	revisions   map[int][]entity.TodoRevision // todo ID -> revisions, oldest first
+	assignments []entity.TodoAssignment
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		lastID:    map[string]int{},
+		users:     map[int]entity.User{},
+		lists:     map[int]memoryList{},
+		members:   map[int]map[int]string{},
+		todos:     map[int]memoryTodo{},
+		deletions: map[int]memoryDeletion{},
+		revisions: map[int][]entity.TodoRevision{},
+	}
+}
+
+// clone takes a snapshot that can be changed without affecting d
+func (d *memoryData) clone() *memoryData {
+	c := newMemoryData()
+	for k, v := range d.lastID {
+		c.lastID[k] = v
+	}
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.lists {
+		c.lists[k] = v
+	}
+	for k, v := range d.members {
+		roles := make(map[int]string, len(v))
+		for userID, role := range v {
+			roles[userID] = role
+		}
+		c.members[k] = roles
+	}
+	for k, v := range d.todos {
+		c.todos[k] = v
+	}
+	for k, v := range d.deletions {
+		c.deletions[k] = v
+	}
+	for k, v := range d.revisions {
+		c.revisions[k] = append([]entity.TodoRevision(nil), v...)
+	}
+	c.assignments = append([]entity.TodoAssignment(nil), d.assignments...)
+	return c
+}
+
+// nextID returns the next generated ID of a kind of record, starting from 1
+func (d *memoryData) nextID(kind string) int {
+	d.lastID[kind]++
+	return d.lastID[kind]
+}
+
+// ensurePersonalList returns the user's personal list ID, creating the list and its owner membership if missing
+func (d *memoryData) ensurePersonalList(userID int) int {
+	for listID, list := range d.lists {
+		if list.personal && list.ownerID == userID {
+			return listID
+		}
+	}
+	listID := d.nextID("list")
+	d.lists[listID] = memoryList{ownerID: userID, personal: true}
+	d.members[listID] = map[int]string{userID: entity.ListRoleOwner}
+	return listID
+}
+
+// role returns the user's role in a list, or "" when they are not a member
+func (d *memoryData) role(listID, userID int) string {
+	return d.members[listID][userID]
+}
+
+// visibleTodo returns a todo that is not in the trash from a list the user is a member of
+func (d *memoryData) visibleTodo(userID, todoID int) (memoryTodo, error) {
+	todo, ok := d.todos[todoID]
+	if !ok || todo.DeletedAt != nil || d.role(todo.ListID, userID) == "" {
+		return memoryTodo{}, notFound("todo")
+	}
+	return todo, nil
+}
+
+// deleteUser removes a user with everything that belongs to them, as the database's foreign keys do
+func (d *memoryData) deleteUser(userID int) {
+	delete(d.users, userID)
+	for listID, list := range d.lists {
+		if list.ownerID == userID {
+			delete(d.lists, listID)
+			delete(d.members, listID)
+		}
+	}
+	for _, roles := range d.members {
+		delete(roles, userID)
+	}
+	for id, deletion := range d.deletions {
+		if _, ok := d.lists[deletion.listID]; !ok || deletion.userID == userID {
+			delete(d.deletions, id)
+		}
+	}
+	for todoID, todo := range d.todos {
+		if _, ok := d.lists[todo.ListID]; !ok || todo.UserID == userID {
+			delete(d.todos, todoID)
+			delete(d.revisions, todoID)
+			continue
+		}
+		if todo.AssigneeID != nil && *todo.AssigneeID == userID {
+			todo.AssigneeID = nil
+			d.todos[todoID] = todo
+		}
+	}
+
+	kept := make([]entity.TodoAssignment, 0, len(d.assignments))
+	for _, assignment := range d.assignments {
+		if _, ok := d.todos[assignment.TodoID]; !ok {
+			continue
+		}
+		if sameAssignee(assignment.PreviousAssigneeID, &userID) {
+			assignment.PreviousAssigneeID = nil
+		}
+		if sameAssignee(assignment.AssigneeID, &userID) {
+			assignment.AssigneeID = nil
+		}
+		if assignment.AssignedBy == userID {
+			assignment.AssignedBy = 0
+		}
+		kept = append(kept, assignment)
+	}
+	d.assignments = kept
+}
+
+// MemoryUserRepository implements the UserRepository interface on a MemoryStore
+type MemoryUserRepository struct {
+	store *MemoryStore
+}
+
+// NewMemoryUserRepository creates a new MemoryUserRepository
+func NewMemoryUserRepository(store *MemoryStore) *MemoryUserRepository {
+	return &MemoryUserRepository{store: store}
+}
+
+// CreateUser stores a new user together with their personal list and sets its generated ID
+func (r *MemoryUserRepository) CreateUser(ctx context.Context, user *entity.User) error {
+	return r.store.write(func(d *memoryData) error {
+		if err := uniqueUser(d, *user); err != nil {
+			return err
+		}
+		user.UserID = d.nextID("user")
+		d.users[user.UserID] = *user
+		d.ensurePersonalList(user.UserID)
+		return nil
+	})
+}
+
+// GetUserByID retrieves a user by their ID
+func (r *MemoryUserRepository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+	return r.findUser(func(u entity.User) bool { return u.UserID == userID })
+}
+
+// GetUserByUserName retrieves a user by their UserName
+func (r *MemoryUserRepository) GetUserByUserName(ctx context.Context, UserName string) (*entity.User, error) {
+	return r.findUser(func(u entity.User) bool { return u.UserName == UserName })
+}
+
+// GetUserByEmail retrieves a user by their email address
+func (r *MemoryUserRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return r.findUser(func(u entity.User) bool { return u.Email == email })
+}
+
+// findUser returns a copy of the first user matching match
+func (r *MemoryUserRepository) findUser(match func(entity.User) bool) (*entity.User, error) {
+	var found *entity.User
+	r.store.read(func(d *memoryData) error {
+		for _, user := range d.users {
+			if match(user) {
+				user := user
+				found = &user
+				break
+			}
+		}
+		return nil
+	})
+	if found == nil {
+		return &entity.User{}, notFound("user")
+	}
+	return found, nil
+}
+
+// ListUsers returns a page of users whose username or email contains search, ignoring case
+func (r *MemoryUserRepository) ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error) {
+	search = strings.ToLower(search)
+	users := []entity.User{}
+	r.store.read(func(d *memoryData) error {
+		for _, user := range d.users {
+			if strings.Contains(strings.ToLower(user.UserName), search) || strings.Contains(strings.ToLower(user.Email), search) {
+				users = append(users, user)
+			}
+		}
+		return nil
+	})
+	sort.Slice(users, func(i, j int) bool { return users[i].UserID < users[j].UserID })
+	return page(users, limit, offset), nil
+}
+
+// UpdateUser updates the user's username, email and password
+func (r *MemoryUserRepository) UpdateUser(ctx context.Context, user *entity.User) error {
+	return r.store.write(func(d *memoryData) error {
+		stored, ok := d.users[user.UserID]
+		if !ok {
+			return nil // like an UPDATE that matches no row
+		}
+		if err := uniqueUser(d, *user); err != nil {
+			return err
+		}
+		stored.UserName, stored.Email, stored.Password = user.UserName, user.Email, user.Password
+		d.users[user.UserID] = stored
+		return nil
+	})
+}
+
+// DeleteUser deletes a user along with their lists and todos
+func (r *MemoryUserRepository) DeleteUser(ctx context.Context, userID int) error {
+	return r.store.write(func(d *memoryData) error {
+		d.deleteUser(userID)
+		return nil
+	})
+}
+
+// MarkEmailVerified flags the user's email address as verified
+func (r *MemoryUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	r.updateUser(userID, func(u *entity.User) { u.EmailVerified = true })
+	return nil
+}
+
+// UpdatePassword stores a new password hash and revokes every session issued before now
+func (r *MemoryUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	r.updateUser(userID, func(u *entity.User) {
+		u.Password = passwordHash
+		u.SessionsRevokedAt = time.Now()
+	})
+	return nil
+}
+
+// SetDisabled enables or disables a user account
+func (r *MemoryUserRepository) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	return r.updateUser(userID, func(u *entity.User) { u.Disabled = disabled })
+}
+
+// SetRole changes the role of a user
+func (r *MemoryUserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	return r.updateUser(userID, func(u *entity.User) { u.Role = role })
+}
+
+// updateUser applies change to a stored user, reporting "user not found" when there is none
+func (r *MemoryUserRepository) updateUser(userID int, change func(u *entity.User)) error {
+	return r.store.write(func(d *memoryData) error {
+		user, ok := d.users[userID]
+		if !ok {
+			return notFound("user")
+		}
+		change(&user)
+		d.users[userID] = user
+		return nil
+	})
+}
+
+// uniqueUser checks that no other user has the same username or email
+func uniqueUser(d *memoryData, user entity.User) error {
+	for _, other := range d.users {
+		if other.UserID == user.UserID {
+			continue
+		}
+		if other.UserName == user.UserName {
+			return fmt.Errorf("%w: username %q is already taken", ErrConflict, user.UserName)
+		}
+		if other.Email == user.Email {
+			return fmt.Errorf("%w: email %q is already registered", ErrConflict, user.Email)
+		}
+	}
+	return nil
+}
+
+// page returns the items of one page of a sorted result
+func page[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// snippetWords is how many words of the description a search snippet shows
+const snippetWords = 24
+
+// searchToken is one word of a searched text and where it sits in the text
+type searchToken struct {
+	word       string
+	start, end int
+}
+
+// SearchTodos returns a page of the user's todos matching every term, most relevant first.
+// Matches in the title count ten times as much as matches in the description.
+func (r *MemoryToDoRepository) SearchTodos(ctx context.Context, userID int, terms []entity.SearchTerm, limit, offset int) ([]entity.TodoSearchResult, error) {
+	todos := r.visibleTodos(userID, func(entity.ToDo) bool { return true })
+
+	results := []entity.TodoSearchResult{}
+	for _, todo := range todos {
+		title, description := searchTokens(todo.Title), searchTokens(todo.Description)
+		var titleMatches, descriptionMatches []searchToken
+		matched := true
+		for _, term := range terms {
+			inTitle, inDescription := termMatches(title, term), termMatches(description, term)
+			if len(inTitle) == 0 && len(inDescription) == 0 {
+				matched = false
+				break
+			}
+			titleMatches = append(titleMatches, inTitle...)
+			descriptionMatches = append(descriptionMatches, inDescription...)
+		}
+		if !matched {
+			continue
+		}
+		results = append(results, entity.TodoSearchResult{
+			Todo:    todo,
+			Rank:    float64(10*len(titleMatches)+len(descriptionMatches)) / float64(1+len(title)+len(description)),
+			Title:   highlight(markMatches(todo.Title, titleMatches)),
+			Snippet: highlight(snippet(todo.Description, description, descriptionMatches)),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Rank > results[j].Rank })
+	return page(results, limit, offset), nil
+}
+
+// searchTokens splits text into lower-cased runs of letters and digits, like ParseSearchQuery does
+func searchTokens(text string) []searchToken {
+	var tokens []searchToken
+	start := -1
+	for i, c := range text + " " {
+		wordChar := unicode.IsLetter(c) || unicode.IsDigit(c)
+		if wordChar && start < 0 {
+			start = i
+		} else if !wordChar && start >= 0 {
+			tokens = append(tokens, searchToken{word: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	return tokens
+}
+
+// termMatches returns the span of every place the term's words follow each other in tokens.
+// Words are compared by their stems; the last word of a prefix term only has to start the token.
+func termMatches(tokens []searchToken, term entity.SearchTerm) []searchToken {
+	var matches []searchToken
+	n := len(term.Words)
+	for i := 0; n > 0 && i+n <= len(tokens); i++ {
+		matched := true
+		for k, word := range term.Words {
+			token := tokens[i+k].word
+			if term.Prefix && k == n-1 {
+				matched = strings.HasPrefix(token, word) || strings.HasPrefix(stem(token), stem(word))
+			} else {
+				matched = stem(token) == stem(word)
+			}
+			if !matched {
+				break
+			}
+		}
+		if matched {
+			matches = append(matches, searchToken{start: tokens[i].start, end: tokens[i+n-1].end})
+		}
+	}
+	return matches
+}
+
+// stem strips common English endings so that forms like "invoice", "invoices" and "invoiced" compare equal
+func stem(word string) string {
+	if len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") {
+		word = word[:len(word)-1]
+	}
+	for _, suffix := range []string{"ing", "ed"} {
+		if len(word) > len(suffix)+2 && strings.HasSuffix(word, suffix) {
+			word = word[:len(word)-len(suffix)]
+			break
+		}
+	}
+	if len(word) > 3 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// markMatches surrounds every match in text with the highlight markers
+func markMatches(text string, matches []searchToken) string {
+	sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+	var b strings.Builder
+	last := 0
+	for _, match := range matches {
+		if match.start < last {
+			continue // overlaps a match that is already marked
+		}
+		b.WriteString(text[last:match.start])
+		b.WriteString(highlightStart + text[match.start:match.end] + highlightStop)
+		last = match.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// snippet cuts the words of text around its first match and marks the matches inside them
+func snippet(text string, tokens, matches []searchToken) string {
+	if len(tokens) <= snippetWords {
+		return markMatches(text, matches)
+	}
+	first := 0
+	if len(matches) > 0 {
+		sort.Slice(matches, func(i, j int) bool { return matches[i].start < matches[j].start })
+		for first < len(tokens) && tokens[first].start < matches[0].start {
+			first++
+		}
+		first = max(0, min(first-snippetWords/3, len(tokens)-snippetWords))
+	}
+	from, to := tokens[first].start, tokens[first+snippetWords-1].end
+
+	var inside []searchToken
+	for _, match := range matches {
+		if match.start >= from && match.end <= to {
+			inside = append(inside, searchToken{start: match.start - from, end: match.end - from})
+		}
+	}
+	cut := markMatches(text[from:to], inside)
+	if from > 0 {
+		cut = "…" + cut
+	}
+	if to < len(text) {
+		cut += "…"
+	}
+	return cut
+}
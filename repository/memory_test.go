@@ -0,0 +1,61 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/repository/repotest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMemoryRepositories(t *testing.T) (repository.UserRepository, repository.ToDoRepository) {
+	store := repository.NewMemoryStore()
+	return repository.NewMemoryUserRepository(store), repository.NewMemoryToDoRepository(store)
+}
+
+func TestMemoryUserRepository(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, newMemoryRepositories)
+}
+
+func TestMemoryToDoRepository(t *testing.T) {
+	repotest.RunToDoRepositoryTests(t, newMemoryRepositories)
+}
+
+func TestMemoryRepositoriesAreSafeForConcurrentUse(t *testing.T) {
+	ctx := context.Background()
+	users, todos := newMemoryRepositories(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			user := &entity.User{UserName: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i)}
+			if !assert.NoError(t, users.CreateUser(ctx, user)) {
+				return
+			}
+			for j := 0; j < 10; j++ {
+				todo := &entity.ToDo{UserID: user.UserID, Title: fmt.Sprintf("todo %d", j)}
+				assert.NoError(t, todos.AddToDo(ctx, todo))
+				_, err := todos.GetAllTodos(ctx, user.UserID)
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, todos.DeleteAllTodos(ctx, user.UserID))
+		}(i)
+	}
+	wg.Wait()
+
+	all, err := users.ListUsers(ctx, "", 100, 0)
+	require.NoError(t, err)
+	require.Len(t, all, 20)
+	for _, user := range all {
+		trash, err := todos.GetTrash(ctx, user.UserID)
+		require.NoError(t, err)
+		assert.Len(t, trash, 10)
+	}
+}
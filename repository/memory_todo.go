@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// MemoryToDoRepository implements the ToDoRepository interface on a MemoryStore
+type MemoryToDoRepository struct {
+	store *MemoryStore
+	tx    *memoryData // the snapshot changed by the repositories handed out by WithTx
+}
+
+// NewMemoryToDoRepository creates a new MemoryToDoRepository
+func NewMemoryToDoRepository(store *MemoryStore) *MemoryToDoRepository {
+	return &MemoryToDoRepository{store: store}
+}
+
+// read runs fn against the transaction's snapshot, or the store's data locked for reading
+func (r *MemoryToDoRepository) read(fn func(d *memoryData) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.store.read(fn)
+}
+
+// write runs fn against the transaction's snapshot, or the store's data locked for writing.
+// Every operation checks what it needs before it changes anything, so a failed one changes nothing.
+func (r *MemoryToDoRepository) write(fn func(d *memoryData) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+	return r.store.write(fn)
+}
+
+// AddToDo stores a new todo and its first revision and sets its generated ID and version.
+// Todos without a list go to the user's personal list.
+func (r *MemoryToDoRepository) AddToDo(ctx context.Context, todo *entity.ToDo) error {
+	return r.write(func(d *memoryData) error {
+		listID := todo.ListID
+		if listID == 0 {
+			if _, ok := d.users[todo.UserID]; !ok {
+				return notFound("user")
+			}
+			listID = d.ensurePersonalList(todo.UserID)
+		}
+		if !entity.ListRoleAtLeast(d.role(listID, todo.UserID), entity.ListRoleEditor) {
+			return ErrListNotWritable
+		}
+
+		todo.ListID = listID
+		todo.ToDoID = d.nextID("todo")
+		todo.Version = 1
+		todo.AssigneeID, todo.DeletedAt = nil, nil
+		d.todos[todo.ToDoID] = memoryTodo{ToDo: *todo}
+		d.addRevision(*todo, todo.UserID)
+		return nil
+	})
+}
+
+// GetAllTodos retrieves the todos of every list the user is a member of
+func (r *MemoryToDoRepository) GetAllTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return r.visibleTodos(userID, func(entity.ToDo) bool { return true }), nil
+}
+
+// GetListTodos retrieves the todos of one list the user is a member of
+func (r *MemoryToDoRepository) GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error) {
+	return r.visibleTodos(userID, func(t entity.ToDo) bool { return t.ListID == listID }), nil
+}
+
+// GetAssignedTodos retrieves the todos assigned to the user in lists they still belong to
+func (r *MemoryToDoRepository) GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return r.visibleTodos(userID, func(t entity.ToDo) bool { return sameAssignee(t.AssigneeID, &userID) }), nil
+}
+
+// visibleTodos returns the todos outside the trash in the user's lists that match, ordered by ID
+func (r *MemoryToDoRepository) visibleTodos(userID int, match func(entity.ToDo) bool) []entity.ToDo {
+	var todos []entity.ToDo
+	r.read(func(d *memoryData) error {
+		for _, todo := range d.todos {
+			if todo.DeletedAt == nil && d.role(todo.ListID, userID) != "" && match(todo.ToDo) {
+				todos = append(todos, todo.ToDo)
+			}
+		}
+		return nil
+	})
+	sort.Slice(todos, func(i, j int) bool { return todos[i].ToDoID < todos[j].ToDoID })
+	return todos
+}
+
+// GetTodo retrieves a specific todo from a list the user is a member of
+func (r *MemoryToDoRepository) GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	var todo memoryTodo
+	err := r.read(func(d *memoryData) (err error) {
+		todo, err = d.visibleTodo(userID, todoID)
+		return err
+	})
+	return todo.ToDo, err
+}
+
+// UpdateToDo changes a todo in a list the user can edit and records the new version as a revision.
+// An update that changes nothing writes no revision and keeps the version.
+func (r *MemoryToDoRepository) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	var todo memoryTodo
+	err := r.write(func(d *memoryData) (err error) {
+		if todo, err = d.writableTodo(userID, todoID); err != nil {
+			return err
+		}
+		if expectedVersion != 0 && todo.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+
+		changed := false
+		if update.Title != nil && *update.Title != todo.Title {
+			todo.Title, changed = *update.Title, true
+		}
+		if update.Description != nil && *update.Description != todo.Description {
+			todo.Description, changed = *update.Description, true
+		}
+		if update.DateTime != nil && !update.DateTime.Equal(todo.DateTime) {
+			todo.DateTime, changed = *update.DateTime, true
+		}
+		if !changed {
+			return nil
+		}
+
+		todo.Version++
+		d.todos[todoID] = todo
+		d.addRevision(todo.ToDo, userID)
+		return nil
+	})
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	return todo.ToDo, nil
+}
+
+// DeleteToDo moves a specific todo from a list the user can edit to the trash
+func (r *MemoryToDoRepository) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	return r.write(func(d *memoryData) error {
+		todo, err := d.writableTodo(userID, todoID)
+		if err != nil {
+			return err
+		}
+		if expectedVersion != 0 && todo.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+		now := time.Now()
+		todo.DeletedAt = &now
+		todo.Version++
+		d.todos[todoID] = todo
+		return nil
+	})
+}
+
+// DeleteAllTodos moves every todo in the user's personal list to the trash as one deletion,
+// so that UndoDeleteAll can bring them back together.
+// Shared lists are left alone so one member cannot wipe a list for everybody.
+func (r *MemoryToDoRepository) DeleteAllTodos(ctx context.Context, userID int) error {
+	return r.write(func(d *memoryData) error {
+		listID := 0
+		for id, list := range d.lists {
+			if list.personal && list.ownerID == userID {
+				listID = id
+			}
+		}
+
+		now := time.Now()
+		var deleted []memoryTodo
+		for _, todo := range d.todos {
+			if listID != 0 && todo.ListID == listID && todo.DeletedAt == nil {
+				deleted = append(deleted, todo)
+			}
+		}
+		if len(deleted) == 0 {
+			return nil // an empty deletion would hide an earlier one from undo
+		}
+
+		deletionID := d.nextID("deletion")
+		d.deletions[deletionID] = memoryDeletion{userID: userID, listID: listID, deletedAt: now}
+		for _, todo := range deleted {
+			todo.DeletedAt, todo.deletionID = &now, deletionID
+			todo.Version++
+			d.todos[todo.ToDoID] = todo
+		}
+		return nil
+	})
+}
+
+// GetTrash retrieves the deleted todos of every list the user is a member of, most recently deleted first
+func (r *MemoryToDoRepository) GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	trash := []entity.ToDo{}
+	r.read(func(d *memoryData) error {
+		for _, todo := range d.todos {
+			if todo.DeletedAt != nil && d.role(todo.ListID, userID) != "" {
+				trash = append(trash, todo.ToDo)
+			}
+		}
+		return nil
+	})
+	sort.Slice(trash, func(i, j int) bool {
+		if !trash[i].DeletedAt.Equal(*trash[j].DeletedAt) {
+			return trash[i].DeletedAt.After(*trash[j].DeletedAt)
+		}
+		return trash[i].ToDoID < trash[j].ToDoID
+	})
+	return trash, nil
+}
+
+// RestoreToDo takes a todo out of the trash of a list the user can edit
+func (r *MemoryToDoRepository) RestoreToDo(ctx context.Context, userID, todoID int) error {
+	return r.write(func(d *memoryData) error {
+		todo, ok := d.todos[todoID]
+		if !ok || todo.DeletedAt == nil || !entity.ListRoleAtLeast(d.role(todo.ListID, userID), entity.ListRoleEditor) {
+			return notFound("todo")
+		}
+		todo.DeletedAt, todo.deletionID = nil, 0
+		todo.Version++
+		d.todos[todoID] = todo
+		return nil
+	})
+}
+
+// UndoDeleteAll restores the todos removed by the user's latest DeleteAllTodos, if it happened after since.
+// It returns how many todos came back; zero means there was nothing left to undo.
+func (r *MemoryToDoRepository) UndoDeleteAll(ctx context.Context, userID int, since time.Time) (int, error) {
+	restored := 0
+	err := r.write(func(d *memoryData) error {
+		latest := 0
+		for id, deletion := range d.deletions {
+			if deletion.userID == userID && id > latest {
+				latest = id
+			}
+		}
+		if latest == 0 || !d.deletions[latest].deletedAt.After(since) {
+			return nil
+		}
+
+		for id, todo := range d.todos {
+			if todo.deletionID == latest && todo.DeletedAt != nil {
+				todo.DeletedAt, todo.deletionID = nil, 0
+				todo.Version++
+				d.todos[id] = todo
+				restored++
+			}
+		}
+		if restored > 0 {
+			delete(d.deletions, latest)
+		}
+		return nil
+	})
+	return restored, err
+}
+
+// PurgeDeletedTodos permanently deletes todos that went to the trash before the given time,
+// along with their history
+func (r *MemoryToDoRepository) PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	err := r.write(func(d *memoryData) error {
+		for id, todo := range d.todos {
+			if todo.DeletedAt != nil && todo.DeletedAt.Before(before) {
+				delete(d.todos, id)
+				delete(d.revisions, id)
+				purged++
+			}
+		}
+		kept := make([]entity.TodoAssignment, 0, len(d.assignments))
+		for _, assignment := range d.assignments {
+			if _, ok := d.todos[assignment.TodoID]; ok {
+				kept = append(kept, assignment)
+			}
+		}
+		d.assignments = kept
+		for id, deletion := range d.deletions {
+			if deletion.deletedAt.Before(before) {
+				delete(d.deletions, id)
+			}
+		}
+		return nil
+	})
+	return purged, err
+}
+
+// AssignTodo sets or clears the assignee of a todo in a list the user can edit and records the change.
+// The assignee must be a member of the list. Assigning the current assignee again writes no history
+// and returns an assignment with a zero ID.
+func (r *MemoryToDoRepository) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	var assignment *entity.TodoAssignment
+	err := r.write(func(d *memoryData) error {
+		todo, err := d.writableTodo(userID, todoID)
+		if err != nil {
+			return err
+		}
+
+		assignment = &entity.TodoAssignment{TodoID: todoID, PreviousAssigneeID: todo.AssigneeID, AssigneeID: assigneeID, AssignedBy: userID}
+		if sameAssignee(todo.AssigneeID, assigneeID) {
+			return nil
+		}
+		if assigneeID != nil && d.role(todo.ListID, *assigneeID) == "" {
+			return ErrAssigneeNotMember
+		}
+
+		if assigneeID != nil {
+			id := *assigneeID
+			assigneeID = &id
+		}
+		todo.AssigneeID = assigneeID
+		todo.Version++
+		d.todos[todoID] = todo
+
+		assignment.AssignmentID = d.nextID("assignment")
+		assignment.AssignedAt = time.Now()
+		d.assignments = append(d.assignments, *assignment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// GetAssignmentHistory retrieves every assignee change of a todo the user can see, oldest first
+func (r *MemoryToDoRepository) GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error) {
+	history := []entity.TodoAssignment{}
+	err := r.read(func(d *memoryData) error {
+		if _, err := d.visibleTodo(userID, todoID); err != nil {
+			return err
+		}
+		for _, assignment := range d.assignments {
+			if assignment.TodoID == todoID {
+				history = append(history, assignment)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetRevisions retrieves every version of a todo the user can see, oldest first
+func (r *MemoryToDoRepository) GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error) {
+	var revisions []entity.TodoRevision
+	err := r.read(func(d *memoryData) error {
+		if _, err := d.visibleTodo(userID, todoID); err != nil {
+			return err
+		}
+		revisions = append([]entity.TodoRevision{}, d.revisions[todoID]...)
+		return nil
+	})
+	return revisions, err
+}
+
+// GetRevision retrieves one version of a todo the user can see
+func (r *MemoryToDoRepository) GetRevision(ctx context.Context, userID, todoID, revision int) (entity.TodoRevision, error) {
+	var found entity.TodoRevision
+	err := r.read(func(d *memoryData) error {
+		if _, err := d.visibleTodo(userID, todoID); err != nil {
+			return err
+		}
+		revisions := d.revisions[todoID]
+		if revision < 1 || revision > len(revisions) {
+			return notFound("revision")
+		}
+		found = revisions[revision-1]
+		return nil
+	})
+	return found, err
+}
+
+// WithTx runs fn against a snapshot of the store and swaps it in when fn returns nil.
+// The store stays locked meanwhile, so transactions never interleave with other writes.
+// Called on a repository that is already in a transaction, it joins it.
+func (r *MemoryToDoRepository) WithTx(ctx context.Context, fn func(repo ToDoRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	return r.store.write(func(d *memoryData) error {
+		snapshot := d.clone()
+		if err := fn(&MemoryToDoRepository{store: r.store, tx: snapshot}); err != nil {
+			return err
+		}
+		r.store.data = snapshot
+		return nil
+	})
+}
+
+// writableTodo returns a todo that is not in the trash from a list the user can edit
+func (d *memoryData) writableTodo(userID, todoID int) (memoryTodo, error) {
+	todo, err := d.visibleTodo(userID, todoID)
+	if err != nil {
+		return memoryTodo{}, err
+	}
+	if !entity.ListRoleAtLeast(d.role(todo.ListID, userID), entity.ListRoleEditor) {
+		return memoryTodo{}, ErrListNotWritable
+	}
+	return todo, nil
+}
+
+// addRevision records the current state of a todo as its next revision
+func (d *memoryData) addRevision(todo entity.ToDo, changedBy int) {
+	d.revisions[todo.ToDoID] = append(d.revisions[todo.ToDoID], entity.TodoRevision{
+		Revision:    len(d.revisions[todo.ToDoID]) + 1,
+		TodoID:      todo.ToDoID,
+		Title:       todo.Title,
+		Description: todo.Description,
+		DateTime:    todo.DateTime,
+		ChangedBy:   changedBy,
+		CreatedAt:   time.Now(),
+	})
+}
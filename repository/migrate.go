@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/db"
+)
+
+// Migrator applies and rolls back schema migrations. Files are named like the migrate CLI expects
+// (000001_name.up.sql and 000001_name.down.sql), and progress is kept in a schema_migrations table
+// of the same layout, so databases migrated with the CLI carry on where it left off.
+// Each migration runs in its own transaction together with the version change, so a failed
+// migration leaves the previous version in place rather than a dirty one.
+type Migrator struct {
+	DB         *sql.DB
+	migrations fs.FS
+	// advisoryLock makes Up, Down and Force take a PostgreSQL advisory lock, so that servers and
+	// migrate commands started side by side do not run the same migration twice
+	advisoryLock bool
+}
+
+// migrationLockID is the key of the advisory lock held while migrating; any constant will do as
+// long as nothing else in the database locks it
+const migrationLockID = 4_206_311_977
+
+// migrationConn is what migrating needs from a database: *sql.DB, or the *sql.Conn holding the lock
+type migrationConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// MigrationStatus is where a database stands against the migrations a build knows about
+type MigrationStatus struct {
+	Version int   // the applied version, 0 when nothing has been applied
+	Dirty   bool  // a migration failed part way and the schema needs fixing by hand
+	Latest  int   // the newest version the build knows about
+	Pending []int // the versions still to apply, oldest first
+}
+
+// NewMigrator creates a Migrator for the migrations in migrations
+func NewMigrator(db *sql.DB, migrations fs.FS) *Migrator {
+	return &Migrator{DB: db, migrations: migrations}
+}
+
+// NewBackendMigrator creates a Migrator for the migrations embedded for the backend
+func NewBackendMigrator(conn *sql.DB, backend Backend) *Migrator {
+	if backend == BackendSQLite {
+		return NewMigrator(conn, db.SQLiteMigrations)
+	}
+	m := NewMigrator(conn, db.PostgresMigrations)
+	m.advisoryLock = true
+	return m
+}
+
+// Status reports the applied version and the migrations still to apply
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	return m.status(ctx, m.DB)
+}
+
+func (m *Migrator) status(ctx context.Context, conn migrationConn) (MigrationStatus, error) {
+	var status MigrationStatus
+	versions, err := m.versions()
+	if err != nil {
+		return status, err
+	}
+	if status.Version, status.Dirty, err = m.current(ctx, conn); err != nil {
+		return status, err
+	}
+	for _, version := range versions {
+		if version > status.Version {
+			status.Pending = append(status.Pending, version)
+		}
+	}
+	if len(versions) > 0 {
+		status.Latest = versions[len(versions)-1]
+	}
+	return status, nil
+}
+
+// Check returns an error when the schema is dirty or older than this build expects,
+// so that the server refuses to serve rather than failing on missing columns
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return fmt.Errorf("schema version %d is dirty: fix it by hand, then run migrate force %d", status.Version, status.Version)
+	}
+	if len(status.Pending) > 0 {
+		return fmt.Errorf("schema is at version %d but this build needs %d: run migrate up", status.Version, status.Latest)
+	}
+	return nil
+}
+
+// Up applies, in order, every migration the database has not run yet
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.exclusive(ctx, func(conn migrationConn) error {
+		status, err := m.status(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if status.Dirty {
+			return fmt.Errorf("schema version %d is dirty: a migration failed part way and needs fixing by hand", status.Version)
+		}
+
+		for _, version := range status.Pending {
+			file, script, err := m.script(version, "up")
+			if err != nil {
+				return err
+			}
+			if err := m.apply(ctx, conn, script, version); err != nil {
+				return fmt.Errorf("error applying migration %s: %w", file, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the given number of migrations, newest first, stopping early once none are left
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	versions, err := m.versions()
+	if err != nil {
+		return err
+	}
+
+	return m.exclusive(ctx, func(conn migrationConn) error {
+		for ; steps > 0; steps-- {
+			current, dirty, err := m.current(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if dirty {
+				return fmt.Errorf("schema version %d is dirty: a migration failed part way and needs fixing by hand", current)
+			}
+			if current == 0 {
+				return nil
+			}
+
+			i := sort.SearchInts(versions, current)
+			if i == len(versions) || versions[i] != current {
+				return fmt.Errorf("schema version %d is not one of the known migrations", current)
+			}
+			previous := 0
+			if i > 0 {
+				previous = versions[i-1]
+			}
+
+			file, script, err := m.script(current, "down")
+			if err != nil {
+				return err
+			}
+			if err := m.apply(ctx, conn, script, previous); err != nil {
+				return fmt.Errorf("error rolling back migration %s: %w", file, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Force sets the recorded version and clears the dirty flag without running any migration.
+// It is for recovering after a schema was fixed by hand; version 0 records that nothing is applied.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	versions, err := m.versions()
+	if err != nil {
+		return err
+	}
+	if i := sort.SearchInts(versions, version); version != 0 && (i == len(versions) || versions[i] != version) {
+		return fmt.Errorf("version %d is not one of the known migrations", version)
+	}
+	return m.exclusive(ctx, func(conn migrationConn) error {
+		if _, _, err := m.current(ctx, conn); err != nil { // creates schema_migrations if it is missing
+			return err
+		}
+		return m.apply(ctx, conn, "", version)
+	})
+}
+
+// exclusive runs fn with the migration lock held. On PostgreSQL that is a session advisory lock,
+// so fn gets the connection holding it and runs everything on it; without the lock fn gets m.DB.
+func (m *Migrator) exclusive(ctx context.Context, fn func(conn migrationConn) error) error {
+	if !m.advisoryLock {
+		return fn(m.DB)
+	}
+
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("error taking the migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			// Drop the connection rather than return it to the pool still holding the lock
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+	return fn(conn)
+}
+
+// versions returns the version of every up migration, oldest first
+func (m *Migrator) versions() ([]int, error) {
+	files, err := fs.Glob(m.migrations, "*.up.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]int, 0, len(files))
+	for _, file := range files {
+		version, err := strconv.Atoi(strings.SplitN(file, "_", 2)[0])
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s has no version number", file)
+		}
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	for i := 1; i < len(versions); i++ {
+		if versions[i] == versions[i-1] {
+			return nil, fmt.Errorf("there are two migrations with version %d", versions[i])
+		}
+	}
+	return versions, nil
+}
+
+// script reads the up or down file of a migration
+func (m *Migrator) script(version int, direction string) (string, string, error) {
+	files, err := fs.Glob(m.migrations, fmt.Sprintf("%06d_*.%s.sql", version, direction))
+	if err != nil {
+		return "", "", err
+	}
+	if len(files) != 1 {
+		return "", "", fmt.Errorf("migration %d has no %s file", version, direction)
+	}
+	script, err := fs.ReadFile(m.migrations, files[0])
+	return files[0], string(script), err
+}
+
+// current returns the applied version, creating the schema_migrations table if it is missing
+func (m *Migrator) current(ctx context.Context, conn migrationConn) (int, bool, error) {
+	_, err := conn.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)")
+	if err != nil {
+		return 0, false, fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	var version int
+	var dirty bool
+	err = conn.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("error reading schema version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// apply runs one migration script and records the resulting version in the same transaction.
+// Version 0 is recorded as an empty table, as the migrate CLI does.
+func (m *Migrator) apply(ctx context.Context, conn migrationConn, script string, version int) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)", version); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
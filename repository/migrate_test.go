@@ -0,0 +1,123 @@
+package repository_test
+
+import (
+	"context"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/srikanthbhandary/todo-server/db"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMigrator(t *testing.T, migrations fs.FS) *repository.Migrator {
+	conn, err := repository.ConnectSQLite(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return repository.NewMigrator(conn, migrations)
+}
+
+func TestMigrator(t *testing.T) {
+	ctx := context.Background()
+	migrations := fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT)")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+		"000003_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INT)")},
+		"000003_create_b.down.sql": {Data: []byte("DROP TABLE b")},
+	}
+	m := newMigrator(t, migrations)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, repository.MigrationStatus{Version: 0, Latest: 3, Pending: []int{1, 3}}, status)
+	assert.ErrorContains(t, m.Check(ctx), "schema is at version 0 but this build needs 3")
+
+	require.NoError(t, m.Up(ctx))
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, repository.MigrationStatus{Version: 3, Latest: 3}, status)
+	assert.NoError(t, m.Check(ctx))
+	require.NoError(t, m.Up(ctx), "up with nothing pending is a no-op")
+
+	require.NoError(t, m.Down(ctx, 1))
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Version, "down steps back to the previous migration, not version-1")
+	_, err = m.DB.Exec("SELECT * FROM b")
+	assert.Error(t, err)
+
+	require.NoError(t, m.Down(ctx, 5), "down stops once nothing is left")
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Version)
+
+	require.NoError(t, m.Force(ctx, 3))
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Version)
+	assert.Error(t, m.Force(ctx, 2), "force only accepts known versions")
+}
+
+func TestMigratorLeavesFailedMigrationUnapplied(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t, fstest.MapFS{
+		"000001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INT)")},
+		"000002_broken.up.sql":   {Data: []byte("CREATE TABLE b (id INT); SELECT * FROM missing")},
+	})
+
+	assert.ErrorContains(t, m.Up(ctx), "000002_broken.up.sql")
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Version)
+	assert.False(t, status.Dirty)
+	_, err = m.DB.Exec("SELECT * FROM b")
+	assert.Error(t, err, "the failed migration is rolled back as a whole")
+}
+
+func TestMigratorRefusesDirtySchema(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t, fstest.MapFS{
+		"000001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INT)")},
+		"000001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+	})
+	_, err := m.Status(ctx)
+	require.NoError(t, err)
+	_, err = m.DB.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (1, TRUE)")
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, m.Check(ctx), "dirty")
+	assert.ErrorContains(t, m.Up(ctx), "dirty")
+	assert.ErrorContains(t, m.Down(ctx, 1), "dirty")
+
+	require.NoError(t, m.Force(ctx, 1))
+	assert.NoError(t, m.Check(ctx))
+}
+
+func TestSQLiteMigrationsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := newMigrator(t, db.SQLiteMigrations)
+
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Down(ctx, 1000))
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Version)
+	require.NoError(t, m.Up(ctx))
+	assert.NoError(t, m.Check(ctx))
+}
+
+func TestEmbeddedMigrationsArePaired(t *testing.T) {
+	for name, migrations := range map[string]fs.FS{"postgres": db.PostgresMigrations, "sqlite": db.SQLiteMigrations} {
+		ups, err := fs.Glob(migrations, "*.up.sql")
+		require.NoError(t, err)
+		downs, err := fs.Glob(migrations, "*.down.sql")
+		require.NoError(t, err)
+		require.NotEmpty(t, ups, name)
+		require.Len(t, downs, len(ups), name)
+		for i, up := range ups {
+			assert.Equal(t, up[:len(up)-len(".up.sql")], downs[i][:len(downs[i])-len(".down.sql")], name)
+		}
+	}
+}
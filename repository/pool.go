@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Backoff between connection attempts at startup
+const (
+	connectBackoffStart = 100 * time.Millisecond
+	connectBackoffMax   = 5 * time.Second
+)
+
+// PoolConfig tunes the connection pool of a PostgreSQL database
+type PoolConfig struct {
+	MaxOpenConns     int
+	MaxIdleConns     int
+	ConnMaxLifetime  time.Duration
+	StatementTimeout time.Duration // statements running longer are cancelled by the server; zero means no limit
+	ConnectTimeout   time.Duration // how long ConnectPostgres keeps retrying an unreachable database
+}
+
+// ConnectPostgres opens a pool on the PostgreSQL database in dsn and waits for it to answer,
+// retrying with exponential backoff until pool.ConnectTimeout runs out.
+// A database that is still starting up thus delays the server instead of crashing it.
+func ConnectPostgres(ctx context.Context, dsn string, pool PoolConfig) (*sql.DB, error) {
+	conn, err := OpenPostgres(dsn, pool)
+	if err != nil {
+		return nil, err
+	}
+	if err := waitForDB(ctx, conn, pool.ConnectTimeout); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// OpenPostgres opens a pool on the PostgreSQL database in dsn without waiting for it to answer
+func OpenPostgres(dsn string, pool PoolConfig) (*sql.DB, error) {
+	conn, err := sql.Open("postgres", withStatementTimeout(dsn, pool.StatementTimeout))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(pool.MaxOpenConns)
+	conn.SetMaxIdleConns(pool.MaxIdleConns)
+	conn.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	return conn, nil
+}
+
+// waitForDB pings the database until it answers or the timeout runs out
+func waitForDB(ctx context.Context, conn *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := connectBackoffStart
+	for attempt := 1; ; attempt++ {
+		err := conn.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		slog.Warn("database not reachable", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not reachable after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, connectBackoffMax)
+	}
+}
+
+// withStatementTimeout adds the statement_timeout run-time parameter to a PostgreSQL DSN,
+// in either the URL or the key=value form, unless the DSN already sets one
+func withStatementTimeout(dsn string, timeout time.Duration) string {
+	if timeout <= 0 || strings.Contains(dsn, "statement_timeout") {
+		return dsn
+	}
+	ms := fmt.Sprint(timeout.Milliseconds())
+
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return dsn // let the driver report the broken DSN
+		}
+		query := u.Query()
+		query.Set("statement_timeout", ms)
+		u.RawQuery = query.Encode()
+		return u.String()
+	}
+	return strings.TrimSpace(dsn + " statement_timeout=" + ms)
+}
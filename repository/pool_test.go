@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithStatementTimeout(t *testing.T) {
+	tests := []struct {
+		dsn  string
+		want string
+	}{
+		{"host=db user=todo", "host=db user=todo statement_timeout=5000"},
+		{"postgres://todo@db/todo?sslmode=disable", "postgres://todo@db/todo?sslmode=disable&statement_timeout=5000"},
+		{"host=db statement_timeout=100", "host=db statement_timeout=100"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, withStatementTimeout(tt.dsn, 5*time.Second), tt.dsn)
+	}
+	assert.Equal(t, "host=db", withStatementTimeout("host=db", 0))
+}
+
+func TestConnectPostgresGivesUpAfterTimeout(t *testing.T) {
+	start := time.Now()
+	_, err := ConnectPostgres(context.Background(), "host=127.0.0.1 port=1 sslmode=disable connect_timeout=1",
+		PoolConfig{MaxOpenConns: 1, ConnectTimeout: 300 * time.Millisecond})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database not reachable after 300ms")
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+type fakePinger struct {
+	err error
+}
+
+func (p *fakePinger) PingContext(ctx context.Context) error {
+	return p.err
+}
+
+func TestHealthProbe(t *testing.T) {
+	db := &fakePinger{}
+	probe := NewHealthProbe(db, time.Hour, time.Second)
+	assert.ErrorIs(t, probe.Healthy(), errNotChecked)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	probe.Start(ctx)
+	assert.NoError(t, probe.Healthy(), "Start checks right away")
+
+	db.err = errors.New("connection refused")
+	probe.Check(ctx)
+	assert.EqualError(t, probe.Healthy(), "connection refused")
+
+	db.err = nil
+	probe.Check(ctx)
+	assert.NoError(t, probe.Healthy())
+}
@@ -0,0 +1,83 @@
+package repository_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"sync"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/repository/repotest"
+	"github.com/stretchr/testify/require"
+)
+
+// connectPostgres connects to the database in TODO_TEST_POSTGRES_DSN.
+// The tests are skipped when it is not set.
+func connectPostgres(t *testing.T) *sql.DB {
+	dsn := os.Getenv("TODO_TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TODO_TEST_POSTGRES_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newPostgresRepositories connects to the migrated test database and empties it
+func newPostgresRepositories(t *testing.T) (repository.UserRepository, repository.ToDoRepository) {
+	db := connectPostgres(t)
+	_, err := db.Exec("TRUNCATE users RESTART IDENTITY CASCADE")
+	require.NoError(t, err)
+	return repository.NewPostgresUserRepository(db), repository.NewPostgresToDoRepository(db)
+}
+
+func TestPostgresUserRepository(t *testing.T) {
+	repotest.RunUserRepositoryTests(t, newPostgresRepositories)
+}
+
+func TestPostgresToDoRepository(t *testing.T) {
+	repotest.RunToDoRepositoryTests(t, newPostgresRepositories)
+}
+
+// TestPostgresMigrationsRoundTrip rolls the test database all the way back and migrates it up again,
+// leaving it migrated for the other tests
+func TestPostgresMigrationsRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := repository.NewBackendMigrator(connectPostgres(t), repository.BackendPostgres)
+
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Down(ctx, 1000))
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, status.Version)
+	require.NoError(t, m.Up(ctx))
+	require.NoError(t, m.Check(ctx))
+}
+
+// TestPostgresMigratorsTakeTurns migrates from two pools at once, as servers started side by side
+// do; without the advisory lock both would try to apply the same migrations
+func TestPostgresMigratorsTakeTurns(t *testing.T) {
+	ctx := context.Background()
+	first := repository.NewBackendMigrator(connectPostgres(t), repository.BackendPostgres)
+	second := repository.NewBackendMigrator(connectPostgres(t), repository.BackendPostgres)
+	first.DB.SetMaxOpenConns(1) // the lock's connection must be enough to migrate on
+	require.NoError(t, first.Up(ctx))
+	require.NoError(t, first.Down(ctx, 1000))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, m := range []*repository.Migrator{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = m.Up(ctx)
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, errs[0])
+	require.NoError(t, errs[1])
+	require.NoError(t, first.Check(ctx))
+}
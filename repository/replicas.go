@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// primaryKey marks contexts whose reads must see every committed write
+type primaryKey struct{}
+
+// WithPrimary returns a context whose reads go to the primary database, for callers that must see
+// their own writes rather than what a replica has caught up with
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// UsesPrimary reports whether reads made with ctx must go to the primary database
+func UsesPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// ReplicaSet spreads reads over the read replicas of a PostgreSQL primary, round robin among the
+// replicas whose last health check passed. Reads go to the primary when no replica is healthy or
+// the context asks for it with WithPrimary. Replicas lag behind the primary, so only reads that
+// can tolerate slightly stale data should use it.
+type ReplicaSet struct {
+	Primary  *sql.DB
+	replicas []replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	db    *sql.DB
+	probe *HealthProbe
+}
+
+// NewReplicaSet creates a ReplicaSet whose replicas are health checked every checkInterval once started
+func NewReplicaSet(primary *sql.DB, replicas []*sql.DB, checkInterval time.Duration) *ReplicaSet {
+	set := &ReplicaSet{Primary: primary}
+	for _, db := range replicas {
+		set.replicas = append(set.replicas, replica{db: db, probe: NewHealthProbe(db, checkInterval, 2*time.Second)})
+	}
+	return set
+}
+
+// Start checks every replica right away and then periodically until ctx is cancelled.
+// Replicas count as unhealthy until their first check passes.
+func (s *ReplicaSet) Start(ctx context.Context) {
+	for _, replica := range s.replicas {
+		replica.probe.Start(ctx)
+	}
+}
+
+// Reader returns the database a read made with ctx should go to
+func (s *ReplicaSet) Reader(ctx context.Context) *sql.DB {
+	if len(s.replicas) == 0 || UsesPrimary(ctx) {
+		return s.Primary
+	}
+	start := s.next.Add(1)
+	for i := range s.replicas {
+		replica := s.replicas[(start+uint64(i))%uint64(len(s.replicas))]
+		if replica.probe.Healthy() == nil {
+			return replica.db
+		}
+	}
+	return s.Primary
+}
+
+// Close closes the replicas; the primary is left to its owner
+func (s *ReplicaSet) Close() error {
+	var errs []error
+	for _, replica := range s.replicas {
+		errs = append(errs, replica.db.Close())
+	}
+	return errors.Join(errs...)
+}
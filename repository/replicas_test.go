@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReplicaSet(t *testing.T) {
+	ctx := context.Background()
+	open := func() *sql.DB {
+		db, err := ConnectSQLite(":memory:")
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	primary, first, second := open(), open(), open()
+
+	set := NewReplicaSet(primary, []*sql.DB{first, second}, time.Hour)
+	assert.Same(t, primary, set.Reader(ctx), "replicas are unhealthy until checked")
+
+	set.Start(ctx)
+	reads := map[*sql.DB]int{}
+	for i := 0; i < 4; i++ {
+		reads[set.Reader(ctx)]++
+	}
+	assert.Equal(t, map[*sql.DB]int{first: 2, second: 2}, reads, "reads alternate between healthy replicas")
+	assert.Same(t, primary, set.Reader(WithPrimary(ctx)))
+
+	first.Close()
+	set.replicas[0].probe.Check(ctx)
+	for i := 0; i < 2; i++ {
+		assert.Same(t, second, set.Reader(ctx), "unhealthy replicas are skipped")
+	}
+
+	second.Close()
+	set.replicas[1].probe.Check(ctx)
+	assert.Same(t, primary, set.Reader(ctx), "reads fall back to the primary")
+}
@@ -0,0 +1,396 @@
+// Package repotest is a conformance suite for implementations of the repository interfaces.
+// Every implementation runs the same tests, so they can be swapped without changing behaviour.
+package repotest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory returns empty repositories backed by the same store, so todos can belong to users created through it
+type Factory func(t *testing.T) (repository.UserRepository, repository.ToDoRepository)
+
+// createUser stores a user named name and returns it with its generated ID
+func createUser(t *testing.T, users repository.UserRepository, name string) *entity.User {
+	t.Helper()
+	user := &entity.User{UserName: name, Email: name + "@example.com", Password: "hash", Role: entity.RoleUser}
+	require.NoError(t, users.CreateUser(context.Background(), user))
+	require.NotZero(t, user.UserID)
+	return user
+}
+
+// addTodo stores a todo in the user's personal list and returns it with its generated ID
+func addTodo(t *testing.T, todos repository.ToDoRepository, userID int, title, description string) *entity.ToDo {
+	t.Helper()
+	todo := &entity.ToDo{UserID: userID, Title: title, Description: description, DateTime: time.Now().Truncate(time.Second)}
+	require.NoError(t, todos.AddToDo(context.Background(), todo))
+	require.NotZero(t, todo.ToDoID)
+	return todo
+}
+
+// RunUserRepositoryTests checks the UserRepository behaviour every implementation must share
+func RunUserRepositoryTests(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		users, _ := newRepos(t)
+		created := createUser(t, users, "alice")
+
+		for _, get := range []func() (*entity.User, error){
+			func() (*entity.User, error) { return users.GetUserByID(ctx, created.UserID) },
+			func() (*entity.User, error) { return users.GetUserByUserName(ctx, "alice") },
+			func() (*entity.User, error) { return users.GetUserByEmail(ctx, "alice@example.com") },
+		} {
+			found, err := get()
+			require.NoError(t, err)
+			assert.Equal(t, created.UserID, found.UserID)
+			assert.Equal(t, "alice", found.UserName)
+			assert.Equal(t, "hash", found.Password)
+			assert.Equal(t, entity.RoleUser, found.Role)
+			assert.False(t, found.Disabled)
+		}
+	})
+
+	t.Run("MissingUser", func(t *testing.T) {
+		users, _ := newRepos(t)
+
+		_, err := users.GetUserByID(ctx, 999)
+		assert.EqualError(t, err, "user not found")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		_, err = users.GetUserByUserName(ctx, "nobody")
+		assert.EqualError(t, err, "user not found")
+		assert.EqualError(t, users.SetRole(ctx, 999, entity.RoleAdmin), "user not found")
+		assert.EqualError(t, users.SetDisabled(ctx, 999, true), "user not found")
+	})
+
+	t.Run("UniqueUserNameAndEmail", func(t *testing.T) {
+		users, _ := newRepos(t)
+		createUser(t, users, "alice")
+
+		err := users.CreateUser(ctx, &entity.User{UserName: "alice", Email: "other@example.com", Password: "hash", Role: entity.RoleUser})
+		assert.ErrorIs(t, err, repository.ErrConflict)
+		err = users.CreateUser(ctx, &entity.User{UserName: "other", Email: "alice@example.com", Password: "hash", Role: entity.RoleUser})
+		assert.ErrorIs(t, err, repository.ErrConflict)
+
+		bob := createUser(t, users, "bob")
+		bob.UserName = "alice"
+		assert.ErrorIs(t, users.UpdateUser(ctx, bob), repository.ErrConflict)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		users, _ := newRepos(t)
+		user := createUser(t, users, "alice")
+
+		user.Email = "alice@example.org"
+		require.NoError(t, users.UpdateUser(ctx, user))
+		require.NoError(t, users.MarkEmailVerified(ctx, user.UserID))
+		require.NoError(t, users.SetRole(ctx, user.UserID, entity.RoleAdmin))
+		require.NoError(t, users.SetDisabled(ctx, user.UserID, true))
+		require.NoError(t, users.UpdatePassword(ctx, user.UserID, "new-hash"))
+
+		found, err := users.GetUserByEmail(ctx, "alice@example.org")
+		require.NoError(t, err)
+		assert.True(t, found.EmailVerified)
+		assert.Equal(t, entity.RoleAdmin, found.Role)
+		assert.True(t, found.Disabled)
+		assert.Equal(t, "new-hash", found.Password)
+		assert.WithinDuration(t, time.Now(), found.SessionsRevokedAt, time.Minute)
+	})
+
+	t.Run("ListUsers", func(t *testing.T) {
+		users, _ := newRepos(t)
+		for _, name := range []string{"alice", "bob", "alicia"} {
+			createUser(t, users, name)
+		}
+
+		found, err := users.ListUsers(ctx, "ALI", 10, 0)
+		require.NoError(t, err)
+		require.Len(t, found, 2)
+		assert.Equal(t, "alice", found[0].UserName)
+		assert.Equal(t, "alicia", found[1].UserName)
+
+		page, err := users.ListUsers(ctx, "", 2, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 2)
+		assert.Equal(t, "bob", page[0].UserName)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		users, todos := newRepos(t)
+		user := createUser(t, users, "alice")
+		addTodo(t, todos, user.UserID, "Buy milk", "")
+
+		require.NoError(t, users.DeleteUser(ctx, user.UserID))
+		_, err := users.GetUserByID(ctx, user.UserID)
+		assert.Error(t, err)
+	})
+}
+
+// RunToDoRepositoryTests checks the ToDoRepository behaviour every implementation must share
+func RunToDoRepositoryTests(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("AddAndGet", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		added := addTodo(t, todos, alice.UserID, "Buy milk", "Two litres")
+
+		assert.NotZero(t, added.ListID)
+		assert.Equal(t, 1, added.Version)
+
+		found, err := todos.GetTodo(ctx, alice.UserID, added.ToDoID)
+		require.NoError(t, err)
+		assert.Equal(t, "Buy milk", found.Title)
+		assert.Equal(t, "Two litres", found.Description)
+		assert.True(t, added.DateTime.Equal(found.DateTime))
+		assert.Equal(t, added.ListID, found.ListID)
+
+		all, err := todos.GetAllTodos(ctx, alice.UserID)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+
+		inList, err := todos.GetListTodos(ctx, alice.UserID, added.ListID)
+		require.NoError(t, err)
+		assert.Len(t, inList, 1)
+	})
+
+	t.Run("Ownership", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		bob := createUser(t, users, "bob")
+		todo := addTodo(t, todos, alice.UserID, "Buy milk", "")
+
+		_, err := todos.GetTodo(ctx, bob.UserID, todo.ToDoID)
+		assert.EqualError(t, err, "todo not found")
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		assert.EqualError(t, todos.DeleteToDo(ctx, bob.UserID, todo.ToDoID, 0), "todo not found")
+		_, err = todos.UpdateToDo(ctx, bob.UserID, todo.ToDoID, entity.TodoUpdate{Title: strPtr("Mine now")}, 0)
+		assert.EqualError(t, err, "todo not found")
+
+		all, err := todos.GetAllTodos(ctx, bob.UserID)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+
+		err = todos.AddToDo(ctx, &entity.ToDo{UserID: bob.UserID, ListID: todo.ListID, Title: "Sneaky"})
+		assert.ErrorIs(t, err, repository.ErrListNotWritable)
+	})
+
+	t.Run("UpdateAndRevisions", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		todo := addTodo(t, todos, alice.UserID, "Buy milk", "")
+
+		updated, err := todos.UpdateToDo(ctx, alice.UserID, todo.ToDoID, entity.TodoUpdate{Title: strPtr("Buy oat milk")}, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Buy oat milk", updated.Title)
+		assert.Equal(t, 2, updated.Version)
+
+		unchanged, err := todos.UpdateToDo(ctx, alice.UserID, todo.ToDoID, entity.TodoUpdate{Title: strPtr("Buy oat milk")}, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 2, unchanged.Version)
+
+		_, err = todos.UpdateToDo(ctx, alice.UserID, todo.ToDoID, entity.TodoUpdate{Title: strPtr("Stale")}, 1)
+		assert.ErrorIs(t, err, repository.ErrVersionConflict)
+		assert.ErrorIs(t, err, repository.ErrConflict)
+
+		revisions, err := todos.GetRevisions(ctx, alice.UserID, todo.ToDoID)
+		require.NoError(t, err)
+		require.Len(t, revisions, 2)
+		assert.Equal(t, "Buy milk", revisions[0].Title)
+		assert.Equal(t, "Buy oat milk", revisions[1].Title)
+		assert.Equal(t, alice.UserID, revisions[1].ChangedBy)
+
+		first, err := todos.GetRevision(ctx, alice.UserID, todo.ToDoID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "Buy milk", first.Title)
+		_, err = todos.GetRevision(ctx, alice.UserID, todo.ToDoID, 3)
+		assert.EqualError(t, err, "revision not found")
+	})
+
+	t.Run("TrashAndRestore", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		todo := addTodo(t, todos, alice.UserID, "Buy milk", "")
+
+		assert.ErrorIs(t, todos.DeleteToDo(ctx, alice.UserID, todo.ToDoID, 5), repository.ErrVersionConflict)
+		require.NoError(t, todos.DeleteToDo(ctx, alice.UserID, todo.ToDoID, 1))
+		_, err := todos.GetTodo(ctx, alice.UserID, todo.ToDoID)
+		assert.Error(t, err)
+
+		trash, err := todos.GetTrash(ctx, alice.UserID)
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.NotNil(t, trash[0].DeletedAt)
+
+		require.NoError(t, todos.RestoreToDo(ctx, alice.UserID, todo.ToDoID))
+		restored, err := todos.GetTodo(ctx, alice.UserID, todo.ToDoID)
+		require.NoError(t, err)
+		assert.Equal(t, 3, restored.Version)
+		assert.EqualError(t, todos.RestoreToDo(ctx, alice.UserID, todo.ToDoID), "todo not found")
+	})
+
+	t.Run("DeleteAllAndUndo", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		addTodo(t, todos, alice.UserID, "Buy milk", "")
+		addTodo(t, todos, alice.UserID, "Call Sam", "")
+
+		require.NoError(t, todos.DeleteAllTodos(ctx, alice.UserID))
+		all, err := todos.GetAllTodos(ctx, alice.UserID)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+
+		restored, err := todos.UndoDeleteAll(ctx, alice.UserID, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, restored, "deletions before since are out of the undo window")
+
+		restored, err = todos.UndoDeleteAll(ctx, alice.UserID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 2, restored)
+
+		restored, err = todos.UndoDeleteAll(ctx, alice.UserID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, restored)
+	})
+
+	t.Run("DeleteAllNothingLeftInTx", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		addTodo(t, todos, alice.UserID, "Buy milk", "")
+		require.NoError(t, todos.DeleteAllTodos(ctx, alice.UserID))
+
+		// Deleting again finds nothing, and must not hide the first deletion from undo
+		require.NoError(t, todos.WithTx(ctx, func(tx repository.ToDoRepository) error {
+			return tx.DeleteAllTodos(ctx, alice.UserID)
+		}))
+		restored, err := todos.UndoDeleteAll(ctx, alice.UserID, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, 1, restored)
+	})
+
+	t.Run("PurgeDeletedTodos", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		todo := addTodo(t, todos, alice.UserID, "Buy milk", "")
+		addTodo(t, todos, alice.UserID, "Call Sam", "")
+		require.NoError(t, todos.DeleteToDo(ctx, alice.UserID, todo.ToDoID, 0))
+
+		purged, err := todos.PurgeDeletedTodos(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = todos.PurgeDeletedTodos(ctx, time.Now().Add(time.Hour))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), purged)
+
+		trash, err := todos.GetTrash(ctx, alice.UserID)
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+	})
+
+	t.Run("Assign", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		bob := createUser(t, users, "bob")
+		todo := addTodo(t, todos, alice.UserID, "Buy milk", "")
+
+		_, err := todos.AssignTodo(ctx, alice.UserID, todo.ToDoID, &bob.UserID)
+		assert.ErrorIs(t, err, repository.ErrAssigneeNotMember)
+
+		assignment, err := todos.AssignTodo(ctx, alice.UserID, todo.ToDoID, &alice.UserID)
+		require.NoError(t, err)
+		assert.NotZero(t, assignment.AssignmentID)
+		assert.Nil(t, assignment.PreviousAssigneeID)
+
+		again, err := todos.AssignTodo(ctx, alice.UserID, todo.ToDoID, &alice.UserID)
+		require.NoError(t, err)
+		assert.Zero(t, again.AssignmentID)
+
+		assigned, err := todos.GetAssignedTodos(ctx, alice.UserID)
+		require.NoError(t, err)
+		require.Len(t, assigned, 1)
+		assert.Equal(t, 2, assigned[0].Version)
+
+		history, err := todos.GetAssignmentHistory(ctx, alice.UserID, todo.ToDoID)
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+		assert.Equal(t, alice.UserID, *history[0].AssigneeID)
+		assert.Equal(t, alice.UserID, history[0].AssignedBy)
+	})
+
+	t.Run("WithTxRollsBack", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+
+		err := todos.WithTx(ctx, func(tx repository.ToDoRepository) error {
+			if err := tx.AddToDo(ctx, &entity.ToDo{UserID: alice.UserID, Title: "Buy milk"}); err != nil {
+				return err
+			}
+			return fmt.Errorf("abort")
+		})
+		assert.EqualError(t, err, "abort")
+
+		all, err := todos.GetAllTodos(ctx, alice.UserID)
+		require.NoError(t, err)
+		assert.Empty(t, all)
+
+		err = todos.WithTx(ctx, func(tx repository.ToDoRepository) error {
+			return tx.AddToDo(ctx, &entity.ToDo{UserID: alice.UserID, Title: "Buy milk"})
+		})
+		require.NoError(t, err)
+		all, err = todos.GetAllTodos(ctx, alice.UserID)
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		users, todos := newRepos(t)
+		alice := createUser(t, users, "alice")
+		bob := createUser(t, users, "bob")
+		inTitle := addTodo(t, todos, alice.UserID, "Pay March invoice", "Before the 5th")
+		inDescription := addTodo(t, todos, alice.UserID, "Accounting", "Send the invoice template to Sam")
+		addTodo(t, todos, alice.UserID, "Buy milk", "")
+		deleted := addTodo(t, todos, alice.UserID, "Old invoice", "")
+		require.NoError(t, todos.DeleteToDo(ctx, alice.UserID, deleted.ToDoID, 0))
+
+		search := func(userID int, terms ...entity.SearchTerm) []entity.TodoSearchResult {
+			results, err := todos.SearchTodos(ctx, userID, terms, 10, 0)
+			require.NoError(t, err)
+			return results
+		}
+		word := func(words ...string) entity.SearchTerm { return entity.SearchTerm{Words: words} }
+
+		results := search(alice.UserID, word("invoices"))
+		require.Len(t, results, 2, "matches stemmed words and skips the trash")
+		assert.Equal(t, inTitle.ToDoID, results[0].Todo.ToDoID, "title matches rank first")
+		assert.Equal(t, inDescription.ToDoID, results[1].Todo.ToDoID)
+		assert.Greater(t, results[0].Rank, results[1].Rank)
+		assert.Contains(t, results[0].Title, "<mark>invoice</mark>")
+		assert.Contains(t, results[1].Snippet, "<mark>invoice</mark>")
+
+		results = search(alice.UserID, word("march", "invoice"))
+		require.Len(t, results, 1)
+		assert.Equal(t, inTitle.ToDoID, results[0].Todo.ToDoID)
+		assert.Empty(t, search(alice.UserID, word("invoice", "march")), "phrases keep their word order")
+
+		assert.Len(t, search(alice.UserID, entity.SearchTerm{Words: []string{"acc"}, Prefix: true}), 1)
+		assert.Len(t, search(alice.UserID, word("invoice"), word("sam")), 1)
+		assert.Empty(t, search(bob.UserID, word("invoice")))
+
+		page, err := todos.SearchTodos(ctx, alice.UserID, []entity.SearchTerm{word("invoice")}, 1, 1)
+		require.NoError(t, err)
+		require.Len(t, page, 1)
+		assert.Equal(t, inDescription.ToDoID, page[0].Todo.ToDoID)
+	})
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// Backend names the database the repositories are stored in
+type Backend string
+
+const (
+	BackendPostgres Backend = "postgres"
+	BackendSQLite   Backend = "sqlite"
+)
+
+// sqliteNow is the current time in the format timestamps are stored in, for use in SQLite statements
+const sqliteNow = "strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')"
+
+// ParseDSN picks the backend from the scheme of dsn and returns the DSN to hand to its driver.
+// sqlite:path and sqlite://path name a SQLite database file, or :memory: for one that lives only
+// as long as the process. Anything else goes to PostgreSQL, which accepts both postgres:// URLs
+// and key=value strings.
+func ParseDSN(dsn string) (Backend, string) {
+	for _, prefix := range []string{"sqlite://", "sqlite:"} {
+		if strings.HasPrefix(dsn, prefix) {
+			return BackendSQLite, strings.TrimPrefix(dsn, prefix)
+		}
+	}
+	return BackendPostgres, dsn
+}
+
+// OpenSQLite opens the SQLite database at path and applies any migrations it has not run yet
+func OpenSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	conn, err := ConnectSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := NewBackendMigrator(conn, BackendSQLite).Up(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// ConnectSQLite opens the SQLite database at path without migrating it, for the migrate command.
+// SQLite allows a single writer, so the pool holds one connection; that also keeps an
+// in-memory database alive for as long as the pool.
+func ConnectSQLite(path string) (*sql.DB, error) {
+	conn, err := sql.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	conn.SetConnMaxIdleTime(0)
+	return conn, nil
+}
+
+// sqliteDSN adds the connection settings the repositories rely on to a database path:
+// enforced foreign keys, waiting on locks instead of failing, and timestamps that sort as text
+func sqliteDSN(path string) string {
+	if path == ":memory:" {
+		path = "file::memory:"
+	}
+	settings := "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite"
+	if !strings.Contains(path, ":memory:") && !strings.Contains(path, "mode=memory") {
+		settings += "&_pragma=journal_mode(WAL)"
+	}
+	if strings.Contains(path, "?") {
+		return path + "&" + settings
+	}
+	return path + "?" + settings
+}
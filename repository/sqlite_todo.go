@@ -0,0 +1,318 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// sqliteWritable restricts a todo statement to lists where the user in $2 is an editor or owner
+const sqliteWritable = " AND list_id IN (SELECT list_id FROM list_members WHERE user_id = $2 AND role IN ('editor', 'owner'))"
+
+// SQLiteToDoRepository implements the ToDoRepository interface using SQLite
+type SQLiteToDoRepository struct {
+	DB *sql.DB
+	tx *sql.Tx // set on the repositories handed out by WithTx
+}
+
+// NewSQLiteToDoRepository creates a new SQLiteToDoRepository
+func NewSQLiteToDoRepository(db *sql.DB) *SQLiteToDoRepository {
+	return &SQLiteToDoRepository{DB: db}
+}
+
+// AddToDo inserts a new todo and its first revision into the database and sets its generated ID and version.
+// Todos without a list go to the user's personal list.
+func (r *SQLiteToDoRepository) AddToDo(ctx context.Context, todo *entity.ToDo) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if todo.ListID == 0 {
+		listID, err := sqliteEnsurePersonalList(ctx, tx, todo.UserID)
+		if err != nil {
+			return err
+		}
+		todo.ListID = listID
+	}
+
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO todos (title, datetime, description, user_id, list_id)
+		 SELECT $1, $2, $3, $4, $5
+		 WHERE EXISTS (SELECT 1 FROM list_members WHERE list_id = $5 AND user_id = $4 AND role IN ('editor', 'owner'))
+		 RETURNING todo_id, version`,
+		todo.Title, todo.DateTime.UTC(), todo.Description, todo.UserID, todo.ListID,
+	).Scan(&todo.ToDoID, &todo.Version)
+	if err == sql.ErrNoRows {
+		return ErrListNotWritable
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO todo_revisions (todo_id, revision, title, description, datetime, changed_by) VALUES ($1, 1, $2, $3, $4, $5)",
+		todo.ToDoID, todo.Title, todo.Description, todo.DateTime.UTC(), todo.UserID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetAllTodos retrieves the todos of every list the user is a member of
+func (r *SQLiteToDoRepository) GetAllTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return queryTodos(ctx, r.conn(), "SELECT "+todoColumns+todoAccess, userID)
+}
+
+// GetListTodos retrieves the todos of one list the user is a member of
+func (r *SQLiteToDoRepository) GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error) {
+	return queryTodos(ctx, r.conn(), "SELECT "+todoColumns+todoAccess+" WHERE t.list_id = $2", userID, listID)
+}
+
+// GetTodo retrieves a specific todo from a list the user is a member of
+func (r *SQLiteToDoRepository) GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	return getTodo(ctx, r.conn(), userID, todoID)
+}
+
+// DeleteToDo moves a specific todo from a list the user can edit to the trash
+func (r *SQLiteToDoRepository) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	err := execTodo(ctx, r.conn(),
+		"UPDATE todos SET deleted_at = "+sqliteNow+", version = version + 1"+
+			" WHERE todo_id = $1 AND deleted_at IS NULL AND ($3 = 0 OR version = $3)"+sqliteWritable,
+		todoID, userID, expectedVersion)
+	if err != nil && expectedVersion != 0 {
+		// Tell a stale version apart from a todo that is missing or not writable
+		if todo, getErr := r.GetTodo(ctx, userID, todoID); getErr == nil && todo.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+	}
+	return err
+}
+
+// DeleteAllTodos moves every todo in the user's personal list to the trash as one deletion,
+// so that UndoDeleteAll can bring them back together.
+// Shared lists are left alone so one member cannot wipe a list for everybody.
+func (r *SQLiteToDoRepository) DeleteAllTodos(ctx context.Context, userID int) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletionID int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO todo_deletions (user_id, list_id)
+		 SELECT owner_id, list_id FROM lists WHERE owner_id = $1 AND personal RETURNING deletion_id`, userID,
+	).Scan(&deletionID)
+	if err == sql.ErrNoRows {
+		return nil // no personal list yet, so nothing to delete
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		"UPDATE todos SET deleted_at = "+sqliteNow+", deletion_id = $1, version = version + 1"+
+			" WHERE list_id = (SELECT list_id FROM todo_deletions WHERE deletion_id = $1) AND deleted_at IS NULL",
+		deletionID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// An empty deletion would hide an earlier one from undo. It is dropped here, as rolling back
+		// is left to the owner of a joined transaction.
+		if _, err := tx.ExecContext(ctx, "DELETE FROM todo_deletions WHERE deletion_id = $1", deletionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTrash retrieves the deleted todos of every list the user is a member of, most recently deleted first
+func (r *SQLiteToDoRepository) GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return getTrash(ctx, r.conn(), userID)
+}
+
+// RestoreToDo takes a todo out of the trash of a list the user can edit
+func (r *SQLiteToDoRepository) RestoreToDo(ctx context.Context, userID, todoID int) error {
+	return execTodo(ctx, r.conn(),
+		"UPDATE todos SET deleted_at = NULL, deletion_id = NULL, version = version + 1"+
+			" WHERE todo_id = $1 AND deleted_at IS NOT NULL"+sqliteWritable,
+		todoID, userID)
+}
+
+// UndoDeleteAll restores the todos removed by the user's latest DeleteAllTodos, if it happened after since.
+// It returns how many todos came back; zero means there was nothing left to undo.
+func (r *SQLiteToDoRepository) UndoDeleteAll(ctx context.Context, userID int, since time.Time) (int, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deletionID int
+	err = tx.QueryRowContext(ctx,
+		"SELECT deletion_id FROM todo_deletions WHERE user_id = $1 ORDER BY deletion_id DESC LIMIT 1", userID,
+	).Scan(&deletionID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Only the latest deletion can be undone, and only within the window
+	result, err := tx.ExecContext(ctx,
+		`UPDATE todos SET deleted_at = NULL, deletion_id = NULL, version = version + 1
+		 WHERE deletion_id = $1 AND deleted_at IS NOT NULL
+		 AND EXISTS (SELECT 1 FROM todo_deletions WHERE deletion_id = $1 AND deleted_at > $2)`,
+		deletionID, since.UTC())
+	if err != nil {
+		return 0, err
+	}
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if restored == 0 {
+		return 0, nil
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM todo_deletions WHERE deletion_id = $1", deletionID); err != nil {
+		return 0, err
+	}
+	return int(restored), tx.Commit()
+}
+
+// PurgeDeletedTodos permanently deletes todos that went to the trash before the given time,
+// along with their history
+func (r *SQLiteToDoRepository) PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.conn().ExecContext(ctx, "DELETE FROM todos WHERE deleted_at < $1", before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.conn().ExecContext(ctx, "DELETE FROM todo_deletions WHERE deleted_at < $1", before.UTC()); err != nil {
+		return purged, err
+	}
+	return purged, nil
+}
+
+// GetAssignedTodos retrieves the todos assigned to the user in lists they still belong to
+func (r *SQLiteToDoRepository) GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return queryTodos(ctx, r.conn(), "SELECT "+todoColumns+todoAccess+" WHERE t.assignee_id = $1", userID)
+}
+
+// AssignTodo sets or clears the assignee of a todo in a list the user can edit and records the change.
+// The assignee must be a member of the list. Assigning the current assignee again writes no history
+// and returns an assignment with a zero ID.
+func (r *SQLiteToDoRepository) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var listID int
+	var role string
+	var current sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		"SELECT t.list_id, m.role, t.assignee_id"+todoAccess+" WHERE t.todo_id = $2", userID, todoID,
+	).Scan(&listID, &role, &current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("todo")
+		}
+		return nil, err
+	}
+	if !entity.ListRoleAtLeast(role, entity.ListRoleEditor) {
+		return nil, ErrListNotWritable
+	}
+
+	assignment := &entity.TodoAssignment{TodoID: todoID, PreviousAssigneeID: nullIntPtr(current), AssigneeID: assigneeID, AssignedBy: userID}
+	if sameAssignee(assignment.PreviousAssigneeID, assigneeID) {
+		return assignment, nil
+	}
+
+	if assigneeID != nil {
+		var member bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM list_members WHERE list_id = $1 AND user_id = $2)", listID, *assigneeID,
+		).Scan(&member)
+		if err != nil {
+			return nil, err
+		}
+		if !member {
+			return nil, ErrAssigneeNotMember
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE todos SET assignee_id = $1, version = version + 1 WHERE todo_id = $2", assigneeID, todoID); err != nil {
+		return nil, err
+	}
+	assignment.AssignedAt = time.Now().UTC()
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO todo_assignments (todo_id, previous_assignee_id, assignee_id, assigned_by, assigned_at)
+		 VALUES ($1, $2, $3, $4, $5) RETURNING assignment_id`,
+		todoID, assignment.PreviousAssigneeID, assigneeID, userID, assignment.AssignedAt,
+	).Scan(&assignment.AssignmentID)
+	if err != nil {
+		return nil, err
+	}
+	return assignment, tx.Commit()
+}
+
+// GetAssignmentHistory retrieves every assignee change of a todo the user can see, oldest first
+func (r *SQLiteToDoRepository) GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error) {
+	if _, err := r.GetTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return queryAssignments(ctx, r.conn(), todoID)
+}
+
+// WithTx runs fn inside a transaction; called on a repository that is already in one, it joins it
+func (r *SQLiteToDoRepository) WithTx(ctx context.Context, fn func(repo ToDoRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteToDoRepository{DB: r.DB, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn returns the transaction the repository is bound to, or the database
+func (r *SQLiteToDoRepository) conn() txConn {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.DB
+}
+
+// begin starts a transaction for a single operation. Inside WithTx the operation joins the
+// outer transaction instead, and leaves committing or rolling back to it.
+func (r *SQLiteToDoRepository) begin(ctx context.Context) (*scopedTx, error) {
+	if r.tx != nil {
+		return &scopedTx{Tx: r.tx, joined: true}, nil
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &scopedTx{Tx: tx}, nil
+}
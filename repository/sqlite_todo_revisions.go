@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// UpdateToDo changes a todo in a list the user can edit and records the new version as a revision
+// in the same transaction. An update that changes nothing writes no revision and keeps the version.
+func (r *SQLiteToDoRepository) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	defer tx.Rollback()
+
+	var role string
+	err = tx.QueryRowContext(ctx, "SELECT m.role"+todoAccess+" WHERE t.todo_id = $2", userID, todoID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.ToDo{}, notFound("todo")
+		}
+		return entity.ToDo{}, err
+	}
+	if !entity.ListRoleAtLeast(role, entity.ListRoleEditor) {
+		return entity.ToDo{}, ErrListNotWritable
+	}
+
+	todo, err := getTodo(ctx, tx, userID, todoID)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	if expectedVersion != 0 && todo.Version != expectedVersion {
+		return entity.ToDo{}, ErrVersionConflict
+	}
+
+	changed := false
+	if update.Title != nil && *update.Title != todo.Title {
+		todo.Title, changed = *update.Title, true
+	}
+	if update.Description != nil && *update.Description != todo.Description {
+		todo.Description, changed = *update.Description, true
+	}
+	if update.DateTime != nil && !update.DateTime.Equal(todo.DateTime) {
+		todo.DateTime, changed = update.DateTime.UTC(), true
+	}
+	if !changed {
+		return todo, nil
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE todos SET title = $1, description = $2, datetime = $3, version = version + 1 WHERE todo_id = $4 RETURNING version",
+		todo.Title, todo.Description, todo.DateTime, todoID,
+	).Scan(&todo.Version)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	// SQLite lets one transaction write at a time, so the next number cannot be taken twice
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO todo_revisions (todo_id, revision, title, description, datetime, changed_by)
+		 SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5 FROM todo_revisions WHERE todo_id = $1`,
+		todoID, todo.Title, todo.Description, todo.DateTime, userID)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	return todo, tx.Commit()
+}
+
+// GetRevisions retrieves every version of a todo the user can see, oldest first
+func (r *SQLiteToDoRepository) GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error) {
+	if _, err := r.GetTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return queryRevisions(ctx, r.conn(), todoID)
+}
+
+// GetRevision retrieves one version of a todo the user can see
+func (r *SQLiteToDoRepository) GetRevision(ctx context.Context, userID, todoID, revision int) (entity.TodoRevision, error) {
+	if _, err := r.GetTodo(ctx, userID, todoID); err != nil {
+		return entity.TodoRevision{}, err
+	}
+	return getRevision(ctx, r.conn(), todoID, revision)
+}
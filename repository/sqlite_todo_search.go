@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// SearchTodos returns a page of the user's todos matching every term, most relevant first.
+// bm25 scores better matches lower, and title matches count ten times as much as description ones.
+func (r *SQLiteToDoRepository) SearchTodos(ctx context.Context, userID int, terms []entity.SearchTerm, limit, offset int) ([]entity.TodoSearchResult, error) {
+	rows, err := r.conn().QueryContext(ctx,
+		"SELECT "+todoColumns+", -bm25(todos_fts, 10.0, 1.0) AS rank,"+
+			" COALESCE(highlight(todos_fts, 0, char(2), char(3)), ''),"+
+			" COALESCE(snippet(todos_fts, 1, char(2), char(3), '…', 24), '')"+
+			" FROM todos_fts JOIN todos t ON t.todo_id = todos_fts.rowid"+
+			" JOIN list_members m ON m.list_id = t.list_id AND m.user_id = $1 AND t.deleted_at IS NULL"+
+			" WHERE todos_fts MATCH $2 ORDER BY rank DESC, t.todo_id LIMIT $3 OFFSET $4",
+		userID, ftsQuery(terms), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []entity.TodoSearchResult{}
+	for rows.Next() {
+		var result entity.TodoSearchResult
+		todo, err := scanTodo(rows, &result.Rank, &result.Title, &result.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		result.Todo = todo
+		result.Title = highlight(result.Title)
+		result.Snippet = highlight(result.Snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// ftsQuery writes the terms as an FTS5 query that requires all of them.
+// Every term is quoted so that words like AND or NOT are searched for rather than parsed.
+func ftsQuery(terms []entity.SearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		part := `"` + strings.Join(term.Words, " ") + `"`
+		if term.Prefix {
+			part += "*"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " AND ")
+}
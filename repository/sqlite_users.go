@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// SQLiteUserRepository implements the UserRepository interface using SQLite
+type SQLiteUserRepository struct {
+	DB *sql.DB
+}
+
+// NewSQLiteUserRepository creates a new SQLiteUserRepository
+func NewSQLiteUserRepository(db *sql.DB) *SQLiteUserRepository {
+	return &SQLiteUserRepository{DB: db}
+}
+
+// CreateUser inserts a new user together with their personal list and sets its generated ID
+func (r *SQLiteUserRepository) CreateUser(ctx context.Context, user *entity.User) error {
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (username, email, password, email_verified, role) VALUES ($1, $2, $3, $4, $5) RETURNING user_id",
+		user.UserName, user.Email, user.Password, user.EmailVerified, user.Role,
+	).Scan(&user.UserID)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+	if _, err := sqliteEnsurePersonalList(ctx, tx, user.UserID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetUserByID retrieves a user by their ID
+func (r *SQLiteUserRepository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+	return r.getUser(ctx, "SELECT "+userColumns+" FROM users WHERE user_id = $1", userID)
+}
+
+// GetUserByUserName retrieves a user by their UserName
+func (r *SQLiteUserRepository) GetUserByUserName(ctx context.Context, UserName string) (*entity.User, error) {
+	return r.getUser(ctx, "SELECT "+userColumns+" FROM users WHERE username = $1", UserName)
+}
+
+// GetUserByEmail retrieves a user by their email address
+func (r *SQLiteUserRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return r.getUser(ctx, "SELECT "+userColumns+" FROM users WHERE email = $1", email)
+}
+
+// getUser runs a single-row user query and scans the result
+func (r *SQLiteUserRepository) getUser(ctx context.Context, query string, arg interface{}) (*entity.User, error) {
+	user, err := scanUser(r.DB.QueryRowContext(ctx, query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &entity.User{}, notFound("user")
+		}
+		return &entity.User{}, err
+	}
+	return user, nil
+}
+
+// ListUsers returns a page of users whose username or email contains search, ignoring case
+func (r *SQLiteUserRepository) ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE $1 = '' OR username LIKE '%' || $1 || '%' OR email LIKE '%' || $1 || '%' "+
+			"ORDER BY user_id LIMIT $2 OFFSET $3",
+		search, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// UpdateUser updates the user's information in the database
+func (r *SQLiteUserRepository) UpdateUser(ctx context.Context, user *entity.User) error {
+	_, err := r.DB.ExecContext(ctx,
+		"UPDATE users SET username = $1, email = $2, password = $3 WHERE user_id = $4",
+		user.UserName, user.Email, user.Password, user.UserID,
+	)
+	return uniqueViolation(err)
+}
+
+// DeleteUser deletes a user from the database
+func (r *SQLiteUserRepository) DeleteUser(ctx context.Context, userID int) error {
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM users WHERE user_id = $1", userID)
+	return err
+}
+
+// MarkEmailVerified flags the user's email address as verified
+func (r *SQLiteUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET email_verified = TRUE WHERE user_id = $1", userID)
+	return err
+}
+
+// UpdatePassword stores a new password hash and revokes every session issued before now
+func (r *SQLiteUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	_, err := r.DB.ExecContext(ctx,
+		"UPDATE users SET password = $1, sessions_revoked_at = "+sqliteNow+" WHERE user_id = $2",
+		passwordHash, userID,
+	)
+	return err
+}
+
+// SetDisabled enables or disables a user account
+func (r *SQLiteUserRepository) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	return r.execOne(ctx, "UPDATE users SET disabled = $1 WHERE user_id = $2", disabled, userID)
+}
+
+// SetRole changes the role of a user
+func (r *SQLiteUserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	return r.execOne(ctx, "UPDATE users SET role = $1 WHERE user_id = $2", role, userID)
+}
+
+// execOne runs a statement that is expected to touch exactly one user
+func (r *SQLiteUserRepository) execOne(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound("user")
+	}
+	return nil
+}
+
+// sqliteEnsurePersonalList returns the user's personal list ID, creating the list and its owner membership if missing.
+// SQLite serializes writers, so the statements cannot race with another caller.
+func sqliteEnsurePersonalList(ctx context.Context, q queryer, userID int) (int, error) {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO lists (name, owner_id, personal) VALUES ('Personal', $1, TRUE)
+		 ON CONFLICT (owner_id) WHERE personal DO NOTHING`, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	var listID int
+	if err := q.QueryRowContext(ctx, "SELECT list_id FROM lists WHERE owner_id = $1 AND personal", userID).Scan(&listID); err != nil {
+		return 0, err
+	}
+	_, err = q.ExecContext(ctx,
+		"INSERT INTO list_members (list_id, user_id, role) VALUES ($1, $2, 'owner') ON CONFLICT DO NOTHING", listID, userID)
+	return listID, err
+}
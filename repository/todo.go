@@ -3,23 +3,67 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 )
 
-// ToDoRepository defines the interface for ToDo operations
+var (
+	// ErrListNotWritable is returned when a todo is added to or changed in a list the user cannot edit or does not belong to
+	ErrListNotWritable = errors.New("todo list not found or not writable")
+
+	// ErrAssigneeNotMember is returned when assigning a todo to a user without access to its list
+	ErrAssigneeNotMember = errors.New("assignee is not a member of the todo's list")
+
+	// ErrVersionConflict is returned when a change was made against a version of a todo that is no longer current
+	ErrVersionConflict = fmt.Errorf("todo version %w", ErrConflict)
+)
+
+// ToDoRepository defines the interface for ToDo operations.
+// Access is granted through list membership: viewers can read, editors and owners can also write.
+// Deleted todos move to the trash and are hidden from every other query until restored or purged.
+// Every change increments a todo's version; writes given a non-zero expected version only apply to that version.
 type ToDoRepository interface {
 	AddToDo(ctx context.Context, todo *entity.ToDo) error
 	GetAllTodos(ctx context.Context, userID int) ([]entity.ToDo, error)
+	GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error)
 	GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error)
-	DeleteToDo(ctx context.Context, userID, todoID int) error
+	UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error)
+	DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error
 	DeleteAllTodos(ctx context.Context, userID int) error
+	GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error)
+	AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error)
+	GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error)
+	GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error)
+	RestoreToDo(ctx context.Context, userID, todoID int) error
+	UndoDeleteAll(ctx context.Context, userID int, since time.Time) (int, error)
+	PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error)
+	GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error)
+	GetRevision(ctx context.Context, userID, todoID, revision int) (entity.TodoRevision, error)
+	SearchTodos(ctx context.Context, userID int, terms []entity.SearchTerm, limit, offset int) ([]entity.TodoSearchResult, error)
+	// WithTx runs fn with a repository whose operations all belong to one transaction,
+	// committed when fn returns nil and rolled back otherwise.
+	WithTx(ctx context.Context, fn func(repo ToDoRepository) error) error
 }
 
+const todoColumns = "t.todo_id, t.title, t.datetime, t.description, t.user_id, t.list_id, t.assignee_id, t.deleted_at, t.version"
+
+const assignmentColumns = "a.assignment_id, a.todo_id, a.previous_assignee_id, a.assignee_id, COALESCE(a.assigned_by, 0), a.assigned_at"
+
+// todoMembership joins todos, including deleted ones, to the requesting user's memberships,
+// the user ID being the first argument
+const todoMembership = " FROM todos t JOIN list_members m ON m.list_id = t.list_id AND m.user_id = $1"
+
+// todoAccess is todoMembership restricted to todos that are not in the trash
+const todoAccess = todoMembership + " AND t.deleted_at IS NULL"
+
 // PostgresToDoRepository implements the ToDoRepository interface using PostgreSQL
 type PostgresToDoRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	Replicas *ReplicaSet // optional; GetAllTodos and GetTodo read from its replicas
+	tx       *sql.Tx     // set on the repositories handed out by WithTx
 }
 
 // NewPostgresToDoRepository creates a new PostgresToDoRepository
@@ -27,60 +71,449 @@ func NewPostgresToDoRepository(db *sql.DB) *PostgresToDoRepository {
 	return &PostgresToDoRepository{DB: db}
 }
 
-// AddToDo inserts a new todo into the database
+// AddToDo inserts a new todo and its first revision into the database and sets its generated ID and version.
+// Todos without a list go to the user's personal list.
 func (r *PostgresToDoRepository) AddToDo(ctx context.Context, todo *entity.ToDo) error {
-	_, err := r.DB.ExecContext(ctx,
-		"INSERT INTO todos (title, datetime, description, user_id) VALUES ($1, $2, $3, $4)",
-		todo.Title, todo.DateTime, todo.Description, todo.UserID,
-	)
+	if todo.ListID == 0 {
+		listID, err := ensurePersonalList(ctx, r.conn(), todo.UserID)
+		if err != nil {
+			return err
+		}
+		todo.ListID = listID
+	}
+
+	// The first revision is written by the same statement, so a todo never exists without its history
+	err := r.conn().QueryRowContext(ctx,
+		`WITH inserted AS (
+		   INSERT INTO todos (title, datetime, description, user_id, list_id)
+		   SELECT $1, $2, $3, $4, $5
+		   WHERE EXISTS (SELECT 1 FROM list_members WHERE list_id = $5 AND user_id = $4 AND role IN ('editor', 'owner'))
+		   RETURNING todo_id, title, description, datetime, user_id, version
+		 ), first_revision AS (
+		   INSERT INTO todo_revisions (todo_id, revision, title, description, datetime, changed_by)
+		   SELECT todo_id, 1, title, description, datetime, user_id FROM inserted
+		 )
+		 SELECT todo_id, version FROM inserted`,
+		todo.Title, todo.DateTime, todo.Description, todo.UserID, todo.ListID,
+	).Scan(&todo.ToDoID, &todo.Version)
+	if err == sql.ErrNoRows {
+		return ErrListNotWritable
+	}
 	return err
 }
 
-// GetAllTodos retrieves all todos for a specific user from the database
+// GetAllTodos retrieves the todos of every list the user is a member of
 func (r *PostgresToDoRepository) GetAllTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
-	rows, err := r.DB.QueryContext(ctx, "SELECT todo_id, title, datetime, description, user_id FROM todos WHERE user_id = $1", userID)
+	return queryTodos(ctx, r.reader(ctx), "SELECT "+todoColumns+todoAccess, userID)
+}
+
+// GetListTodos retrieves the todos of one list the user is a member of
+func (r *PostgresToDoRepository) GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error) {
+	return queryTodos(ctx, r.conn(), "SELECT "+todoColumns+todoAccess+" WHERE t.list_id = $2", userID, listID)
+}
+
+// GetTodo retrieves a specific todo from a list the user is a member of
+func (r *PostgresToDoRepository) GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	return getTodo(ctx, r.reader(ctx), userID, todoID)
+}
+
+// DeleteToDo moves a specific todo from a list the user can edit to the trash
+func (r *PostgresToDoRepository) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	err := execTodo(ctx, r.conn(),
+		`UPDATE todos t SET deleted_at = NOW(), version = t.version + 1 FROM list_members m
+		 WHERE t.todo_id = $1 AND t.deleted_at IS NULL AND ($3 = 0 OR t.version = $3)
+		 AND m.list_id = t.list_id AND m.user_id = $2 AND m.role IN ('editor', 'owner')`,
+		todoID, userID, expectedVersion)
+	if err != nil && expectedVersion != 0 {
+		// Tell a stale version apart from a todo that is missing or not writable. The version is read
+		// where the update ran, as a replica may still have the one the caller expected.
+		if todo, getErr := getTodo(ctx, r.conn(), userID, todoID); getErr == nil && todo.Version != expectedVersion {
+			return ErrVersionConflict
+		}
+	}
+	return err
+}
+
+// DeleteAllTodos moves every todo in the user's personal list to the trash as one deletion,
+// so that UndoDeleteAll can bring them back together.
+// Shared lists are left alone so one member cannot wipe a list for everybody.
+func (r *PostgresToDoRepository) DeleteAllTodos(ctx context.Context, userID int) error {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var deletionID int
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO todo_deletions (user_id, list_id)
+		 SELECT owner_id, list_id FROM lists WHERE owner_id = $1 AND personal RETURNING deletion_id`, userID,
+	).Scan(&deletionID)
+	if err == sql.ErrNoRows {
+		return nil // no personal list yet, so nothing to delete
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE todos SET deleted_at = NOW(), deletion_id = $1, version = version + 1
+		 WHERE list_id = (SELECT list_id FROM todo_deletions WHERE deletion_id = $1) AND deleted_at IS NULL`,
+		deletionID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// An empty deletion would hide an earlier one from undo. It is dropped here, as rolling back
+		// is left to the owner of a joined transaction.
+		if _, err := tx.ExecContext(ctx, "DELETE FROM todo_deletions WHERE deletion_id = $1", deletionID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetTrash retrieves the deleted todos of every list the user is a member of, most recently deleted first
+func (r *PostgresToDoRepository) GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return getTrash(ctx, r.conn(), userID)
+}
+
+// RestoreToDo takes a todo out of the trash of a list the user can edit
+func (r *PostgresToDoRepository) RestoreToDo(ctx context.Context, userID, todoID int) error {
+	return execTodo(ctx, r.conn(),
+		`UPDATE todos t SET deleted_at = NULL, deletion_id = NULL, version = t.version + 1 FROM list_members m
+		 WHERE t.todo_id = $1 AND t.deleted_at IS NOT NULL
+		 AND m.list_id = t.list_id AND m.user_id = $2 AND m.role IN ('editor', 'owner')`,
+		todoID, userID)
+}
+
+// UndoDeleteAll restores the todos removed by the user's latest DeleteAllTodos, if it happened after since.
+// It returns how many todos came back; zero means there was nothing left to undo.
+func (r *PostgresToDoRepository) UndoDeleteAll(ctx context.Context, userID int, since time.Time) (int, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var deletionID int
+	err = tx.QueryRowContext(ctx,
+		`SELECT deletion_id FROM todo_deletions WHERE user_id = $1
+		 ORDER BY deletion_id DESC LIMIT 1 FOR UPDATE`, userID,
+	).Scan(&deletionID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	// Only the latest deletion can be undone, and only within the window
+	result, err := tx.ExecContext(ctx,
+		`UPDATE todos SET deleted_at = NULL, deletion_id = NULL, version = version + 1
+		 WHERE deletion_id = $1 AND deleted_at IS NOT NULL
+		 AND EXISTS (SELECT 1 FROM todo_deletions WHERE deletion_id = $1 AND deleted_at > $2)`,
+		deletionID, since)
+	if err != nil {
+		return 0, err
+	}
+	restored, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if restored == 0 {
+		return 0, nil
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM todo_deletions WHERE deletion_id = $1", deletionID); err != nil {
+		return 0, err
+	}
+	return int(restored), tx.Commit()
+}
+
+// PurgeDeletedTodos permanently deletes todos that went to the trash before the given time,
+// along with their comments and history
+func (r *PostgresToDoRepository) PurgeDeletedTodos(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.conn().ExecContext(ctx, "DELETE FROM todos WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := r.conn().ExecContext(ctx, "DELETE FROM todo_deletions WHERE deleted_at < $1", before); err != nil {
+		return purged, err
+	}
+	return purged, nil
+}
+
+// GetAssignedTodos retrieves the todos assigned to the user in lists they still belong to
+func (r *PostgresToDoRepository) GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return queryTodos(ctx, r.conn(), "SELECT "+todoColumns+todoAccess+" WHERE t.assignee_id = $1", userID)
+}
+
+// AssignTodo sets or clears the assignee of a todo in a list the user can edit and records the change.
+// The assignee must be a member of the list. Assigning the current assignee again writes no history
+// and returns an assignment with a zero ID.
+func (r *PostgresToDoRepository) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	tx, err := r.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var todos []entity.ToDo
-	for rows.Next() {
-		var todo entity.ToDo
-		if err := rows.Scan(&todo.ToDoID, &todo.Title, &todo.DateTime, &todo.Description, &todo.UserID); err != nil {
+	var listID int
+	var role string
+	var current sql.NullInt64
+	err = tx.QueryRowContext(ctx,
+		"SELECT t.list_id, m.role, t.assignee_id"+todoAccess+" WHERE t.todo_id = $2 FOR UPDATE OF t",
+		userID, todoID,
+	).Scan(&listID, &role, &current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, notFound("todo")
+		}
+		return nil, err
+	}
+	if !entity.ListRoleAtLeast(role, entity.ListRoleEditor) {
+		return nil, ErrListNotWritable
+	}
+
+	assignment := &entity.TodoAssignment{TodoID: todoID, PreviousAssigneeID: nullIntPtr(current), AssigneeID: assigneeID, AssignedBy: userID}
+	if sameAssignee(assignment.PreviousAssigneeID, assigneeID) {
+		return assignment, nil
+	}
+
+	if assigneeID != nil {
+		var member bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM list_members WHERE list_id = $1 AND user_id = $2)", listID, *assigneeID,
+		).Scan(&member)
+		if err != nil {
 			return nil, err
 		}
-		todos = append(todos, todo)
+		if !member {
+			return nil, ErrAssigneeNotMember
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE todos SET assignee_id = $1, version = version + 1 WHERE todo_id = $2", assigneeID, todoID); err != nil {
+		return nil, err
+	}
+	err = tx.QueryRowContext(ctx,
+		`INSERT INTO todo_assignments (todo_id, previous_assignee_id, assignee_id, assigned_by)
+		 VALUES ($1, $2, $3, $4) RETURNING assignment_id, assigned_at`,
+		todoID, assignment.PreviousAssigneeID, assigneeID, userID,
+	).Scan(&assignment.AssignmentID, &assignment.AssignedAt)
+	if err != nil {
+		return nil, err
 	}
+	return assignment, tx.Commit()
+}
 
-	return todos, nil
+// GetAssignmentHistory retrieves every assignee change of a todo the user can see, oldest first
+func (r *PostgresToDoRepository) GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error) {
+	if _, err := r.GetTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return queryAssignments(ctx, r.conn(), todoID)
 }
 
-// GetTodo retrieves a specific todo for a user from the database
-func (r *PostgresToDoRepository) GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
-	var todo entity.ToDo
-	err := r.DB.QueryRowContext(ctx, "SELECT todo_id, title, datetime, description, user_id FROM todos WHERE todo_id = $1 AND user_id = $2", todoID, userID).
-		Scan(&todo.ToDoID, &todo.Title, &todo.DateTime, &todo.Description, &todo.UserID)
+// WithTx runs fn inside a transaction; called on a repository that is already in one, it joins it
+func (r *PostgresToDoRepository) WithTx(ctx context.Context, fn func(repo ToDoRepository) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&PostgresToDoRepository{DB: r.DB, tx: tx}); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// conn returns the transaction the repository is bound to, or the database
+func (r *PostgresToDoRepository) conn() txConn {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.DB
+}
+
+// reader returns the connection for a read that a replica may serve. Reads inside WithTx stay in
+// the transaction so they see its writes.
+func (r *PostgresToDoRepository) reader(ctx context.Context) txConn {
+	if r.tx != nil || r.Replicas == nil {
+		return r.conn()
+	}
+	return r.Replicas.Reader(ctx)
+}
+
+// begin starts a transaction for a single operation. Inside WithTx the operation joins the
+// outer transaction instead, and leaves committing or rolling back to it.
+func (r *PostgresToDoRepository) begin(ctx context.Context) (*scopedTx, error) {
+	if r.tx != nil {
+		return &scopedTx{Tx: r.tx, joined: true}, nil
+	}
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &scopedTx{Tx: tx}, nil
+}
+
+// getTodo retrieves a todo that is not in the trash from a list the user is a member of
+func getTodo(ctx context.Context, conn txConn, userID, todoID int) (entity.ToDo, error) {
+	todo, err := scanTodo(conn.QueryRowContext(ctx, "SELECT "+todoColumns+todoAccess+" WHERE t.todo_id = $2", userID, todoID))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return entity.ToDo{}, fmt.Errorf("todo not found")
+			return entity.ToDo{}, notFound("todo")
 		}
 		return entity.ToDo{}, err
 	}
 	return todo, nil
 }
 
-// DeleteToDo deletes a specific todo for a user from the database
-func (r *PostgresToDoRepository) DeleteToDo(ctx context.Context, userID, todoID int) error {
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM todos WHERE todo_id = $1 AND user_id = $2", todoID, userID)
+// getTrash retrieves the deleted todos of every list the user is a member of, most recently deleted first
+func getTrash(ctx context.Context, conn txConn, userID int) ([]entity.ToDo, error) {
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+todoColumns+todoMembership+" WHERE t.deleted_at IS NOT NULL ORDER BY t.deleted_at DESC, t.todo_id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	trash := []entity.ToDo{}
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		trash = append(trash, todo)
+	}
+	return trash, rows.Err()
+}
+
+// queryAssignments retrieves every assignee change of a todo, oldest first
+func queryAssignments(ctx context.Context, conn txConn, todoID int) ([]entity.TodoAssignment, error) {
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+assignmentColumns+" FROM todo_assignments a WHERE a.todo_id = $1 ORDER BY a.assignment_id", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []entity.TodoAssignment{}
+	for rows.Next() {
+		var assignment entity.TodoAssignment
+		var previous, assignee sql.NullInt64
+		if err := rows.Scan(&assignment.AssignmentID, &assignment.TodoID, &previous, &assignee,
+			&assignment.AssignedBy, &assignment.AssignedAt); err != nil {
+			return nil, err
+		}
+		assignment.PreviousAssigneeID = nullIntPtr(previous)
+		assignment.AssigneeID = nullIntPtr(assignee)
+		history = append(history, assignment)
+	}
+	return history, rows.Err()
+}
+
+// execTodo runs a statement that changes a single todo, reporting "todo not found" when no row matched
+func execTodo(ctx context.Context, conn txConn, query string, args ...interface{}) error {
+	result, err := conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected == 0 {
+		return notFound("todo")
+	}
 	return nil
 }
 
-// DeleteAllTodos deletes all todos for a specific user from the database
-func (r *PostgresToDoRepository) DeleteAllTodos(ctx context.Context, userID int) error {
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM todos WHERE user_id = $1", userID)
-	return err
+// queryTodos runs a multi-row todo query selected with todoColumns
+func queryTodos(ctx context.Context, conn txConn, query string, args ...interface{}) ([]entity.ToDo, error) {
+	rows, err := conn.QueryContext(ctx, query+" ORDER BY t.todo_id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []entity.ToDo
+	for rows.Next() {
+		todo, err := scanTodo(rows)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// scanTodo reads a row selected with todoColumns, followed by any extra columns into extra
+func scanTodo(row rowScanner, extra ...interface{}) (entity.ToDo, error) {
+	var todo entity.ToDo
+	var assignee sql.NullInt64
+	var deletedAt sql.NullTime
+	dest := []interface{}{&todo.ToDoID, &todo.Title, &todo.DateTime, &todo.Description, &todo.UserID, &todo.ListID,
+		&assignee, &deletedAt, &todo.Version}
+	err := row.Scan(append(dest, extra...)...)
+	todo.AssigneeID = nullIntPtr(assignee)
+	todo.DeletedAt = nullTimePtr(deletedAt)
+	return todo, err
+}
+
+// txConn is implemented by both *sql.DB and *sql.Tx
+type txConn interface {
+	queryer
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// scopedTx is a transaction owned by one operation, or joined from an outer one.
+// Committing or rolling back a joined transaction is left to its owner.
+type scopedTx struct {
+	*sql.Tx
+	joined bool
+}
+
+func (t *scopedTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t *scopedTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+// nullIntPtr converts a nullable ID column into an optional ID
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	id := int(n.Int64)
+	return &id
+}
+
+// sameAssignee reports whether two optional assignees are the same user, or both unassigned
+func sameAssignee(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
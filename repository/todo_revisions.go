@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+const revisionColumns = "v.revision, v.todo_id, COALESCE(v.title, ''), COALESCE(v.description, ''), v.datetime, COALESCE(v.changed_by, 0), v.created_at"
+
+// UpdateToDo changes a todo in a list the user can edit and records the new version as a revision
+// in the same transaction. An update that changes nothing writes no revision and keeps the version.
+func (r *PostgresToDoRepository) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	tx, err := r.begin(ctx)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	defer tx.Rollback()
+
+	var role string
+	err = tx.QueryRowContext(ctx,
+		"SELECT m.role"+todoAccess+" WHERE t.todo_id = $2 FOR UPDATE OF t", userID, todoID).Scan(&role)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.ToDo{}, notFound("todo")
+		}
+		return entity.ToDo{}, err
+	}
+	if !entity.ListRoleAtLeast(role, entity.ListRoleEditor) {
+		return entity.ToDo{}, ErrListNotWritable
+	}
+
+	todo, err := scanTodo(tx.QueryRowContext(ctx, "SELECT "+todoColumns+todoAccess+" WHERE t.todo_id = $2", userID, todoID))
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	if expectedVersion != 0 && todo.Version != expectedVersion {
+		return entity.ToDo{}, ErrVersionConflict
+	}
+
+	changed := false
+	if update.Title != nil && *update.Title != todo.Title {
+		todo.Title, changed = *update.Title, true
+	}
+	if update.Description != nil && *update.Description != todo.Description {
+		todo.Description, changed = *update.Description, true
+	}
+	if update.DateTime != nil && !update.DateTime.Equal(todo.DateTime) {
+		todo.DateTime, changed = *update.DateTime, true
+	}
+	if !changed {
+		return todo, nil
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"UPDATE todos SET title = $1, description = $2, datetime = $3, version = version + 1 WHERE todo_id = $4 RETURNING version",
+		todo.Title, todo.Description, todo.DateTime, todoID,
+	).Scan(&todo.Version)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	// The row lock taken above serializes updates, so the next number cannot be taken twice
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO todo_revisions (todo_id, revision, title, description, datetime, changed_by)
+		 SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5 FROM todo_revisions WHERE todo_id = $1`,
+		todoID, todo.Title, todo.Description, todo.DateTime, userID)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	return todo, tx.Commit()
+}
+
+// GetRevisions retrieves every version of a todo the user can see, oldest first
+func (r *PostgresToDoRepository) GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error) {
+	if _, err := r.GetTodo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	return queryRevisions(ctx, r.conn(), todoID)
+}
+
+// GetRevision retrieves one version of a todo the user can see
+func (r *PostgresToDoRepository) GetRevision(ctx context.Context, userID, todoID, revision int) (entity.TodoRevision, error) {
+	if _, err := r.GetTodo(ctx, userID, todoID); err != nil {
+		return entity.TodoRevision{}, err
+	}
+	return getRevision(ctx, r.conn(), todoID, revision)
+}
+
+// queryRevisions retrieves every version of a todo, oldest first
+func queryRevisions(ctx context.Context, conn txConn, todoID int) ([]entity.TodoRevision, error) {
+	rows, err := conn.QueryContext(ctx,
+		"SELECT "+revisionColumns+" FROM todo_revisions v WHERE v.todo_id = $1 ORDER BY v.revision", todoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []entity.TodoRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions, rows.Err()
+}
+
+// getRevision retrieves one version of a todo
+func getRevision(ctx context.Context, conn txConn, todoID, revision int) (entity.TodoRevision, error) {
+	found, err := scanRevision(conn.QueryRowContext(ctx,
+		"SELECT "+revisionColumns+" FROM todo_revisions v WHERE v.todo_id = $1 AND v.revision = $2", todoID, revision))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return entity.TodoRevision{}, notFound("revision")
+		}
+		return entity.TodoRevision{}, err
+	}
+	return found, nil
+}
+
+// scanRevision reads a row selected with revisionColumns
+func scanRevision(row rowScanner) (entity.TodoRevision, error) {
+	var revision entity.TodoRevision
+	err := row.Scan(&revision.Revision, &revision.TodoID, &revision.Title, &revision.Description,
+		&revision.DateTime, &revision.ChangedBy, &revision.CreatedAt)
+	return revision, err
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// Control characters mark the matches in ts_headline output, so the text around them can be
+// escaped before the markers are turned into <mark> elements
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+const headlineOptions = "'StartSel=' || chr(2) || ', StopSel=' || chr(3)"
+
+// SearchTodos returns a page of the user's todos matching every term, most relevant first
+func (r *PostgresToDoRepository) SearchTodos(ctx context.Context, userID int, terms []entity.SearchTerm, limit, offset int) ([]entity.TodoSearchResult, error) {
+	rows, err := r.conn().QueryContext(ctx,
+		"SELECT "+todoColumns+", ts_rank(t.search, q) AS rank,"+
+			" ts_headline('english', coalesce(t.title, ''), q, "+headlineOptions+" || ', HighlightAll=true'),"+
+			" ts_headline('english', coalesce(t.description, ''), q, "+headlineOptions+" || ', MaxWords=35, MinWords=15, MaxFragments=2')"+
+			todoAccess+" CROSS JOIN to_tsquery('english', $2) q"+
+			" WHERE t.search @@ q ORDER BY rank DESC, t.todo_id LIMIT $3 OFFSET $4",
+		userID, tsQuery(terms), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []entity.TodoSearchResult{}
+	for rows.Next() {
+		var result entity.TodoSearchResult
+		todo, err := scanTodo(rows, &result.Rank, &result.Title, &result.Snippet)
+		if err != nil {
+			return nil, err
+		}
+		result.Todo = todo
+		result.Title = highlight(result.Title)
+		result.Snippet = highlight(result.Snippet)
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// tsQuery writes the terms as a to_tsquery expression that requires all of them.
+// Search terms only hold letters and digits, so they need no quoting.
+func tsQuery(terms []entity.SearchTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, term := range terms {
+		part := strings.Join(term.Words, " <-> ")
+		if term.Prefix {
+			part += ":*"
+		}
+		if len(term.Words) > 1 {
+			part = "(" + part + ")"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " & ")
+}
+
+// highlight escapes text for HTML and wraps each marked match in a <mark> element
+func highlight(text string) string {
+	text = html.EscapeString(text)
+	text = strings.ReplaceAll(text, highlightStart, "<mark>")
+	return strings.ReplaceAll(text, highlightStop, "</mark>")
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// UserTokenRepository defines the interface for single-use user token operations
+type UserTokenRepository interface {
+	CreateToken(ctx context.Context, token *entity.UserToken) error
+	ConsumeToken(ctx context.Context, tokenID string, purpose entity.TokenPurpose) (*entity.UserToken, error)
+	InvalidateTokens(ctx context.Context, userID int, purpose entity.TokenPurpose) error
+}
+
+// PostgresUserTokenRepository implements the UserTokenRepository interface using PostgreSQL
+type PostgresUserTokenRepository struct {
+	DB *sql.DB
+}
+
+// NewPostgresUserTokenRepository creates a new PostgresUserTokenRepository
+func NewPostgresUserTokenRepository(db *sql.DB) *PostgresUserTokenRepository {
+	return &PostgresUserTokenRepository{DB: db}
+}
+
+// CreateToken stores a newly issued token
+func (r *PostgresUserTokenRepository) CreateToken(ctx context.Context, token *entity.UserToken) error {
+	_, err := r.DB.ExecContext(ctx,
+		"INSERT INTO user_tokens (token_id, user_id, purpose, expires_at) VALUES ($1, $2, $3, $4)",
+		token.TokenID, token.UserID, token.Purpose, token.ExpiresAt,
+	)
+	return err
+}
+
+// ConsumeToken marks an unused, unexpired token as used and returns it, or an error wrapping
+// ErrNotFound when there is no such token. The update is a single statement so a token can only
+// ever be redeemed once.
+func (r *PostgresUserTokenRepository) ConsumeToken(ctx context.Context, tokenID string, purpose entity.TokenPurpose) (*entity.UserToken, error) {
+	token := entity.UserToken{TokenID: tokenID, Purpose: purpose}
+	err := r.DB.QueryRowContext(ctx,
+		`UPDATE user_tokens SET used_at = NOW()
+		 WHERE token_id = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		 RETURNING user_id, expires_at, used_at`,
+		tokenID, purpose,
+	).Scan(&token.UserID, &token.ExpiresAt, &token.UsedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("token is invalid, expired or already used: %w", ErrNotFound)
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// InvalidateTokens marks every outstanding token of the given purpose for a user as used
+func (r *PostgresUserTokenRepository) InvalidateTokens(ctx context.Context, userID int, purpose entity.TokenPurpose) error {
+	_, err := r.DB.ExecContext(ctx,
+		"UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL",
+		userID, purpose,
+	)
+	return err
+}
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"fmt"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 )
@@ -14,13 +13,27 @@ type UserRepository interface {
 	CreateUser(ctx context.Context, user *entity.User) error
 	GetUserByID(ctx context.Context, userID int) (*entity.User, error)
 	GetUserByUserName(ctx context.Context, UserName string) (*entity.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*entity.User, error)
 	UpdateUser(ctx context.Context, user *entity.User) error
 	DeleteUser(ctx context.Context, userID int) error
+	MarkEmailVerified(ctx context.Context, userID int) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) error
+	ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error)
+	SetDisabled(ctx context.Context, userID int, disabled bool) error
+	SetRole(ctx context.Context, userID int, role string) error
+}
+
+const userColumns = "user_id, username, email, password, email_verified, role, disabled, sessions_revoked_at"
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
 }
 
 // PostgresUserRepository implements the UserRepository interface using PostgreSQL
 type PostgresUserRepository struct {
-	DB *sql.DB
+	DB       *sql.DB
+	Replicas *ReplicaSet // optional; GetUserByID reads from its replicas
 }
 
 // NewPostgresUserRepository creates a new PostgresUserRepository
@@ -28,55 +41,150 @@ func NewPostgresUserRepository(db *sql.DB) *PostgresUserRepository {
 	return &PostgresUserRepository{DB: db}
 }
 
-// CreateUser inserts a new user into the database
+// CreateUser inserts a new user together with their personal list and sets its generated ID
 func (r *PostgresUserRepository) CreateUser(ctx context.Context, user *entity.User) error {
-	_, err := r.DB.ExecContext(ctx,
-		"INSERT INTO users (username, email, password) VALUES ($1, $2, $3)",
-		user.UserName, user.Email, user.Password,
-	)
-	return err
+	tx, err := r.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO users (username, email, password, email_verified, role) VALUES ($1, $2, $3, $4, $5) RETURNING user_id",
+		user.UserName, user.Email, user.Password, user.EmailVerified, user.Role,
+	).Scan(&user.UserID)
+	if err != nil {
+		return uniqueViolation(err)
+	}
+	if _, err := ensurePersonalList(ctx, tx, user.UserID); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // GetUserByID retrieves a user by their ID
 func (r *PostgresUserRepository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
-	var user entity.User
-	err := r.DB.QueryRowContext(ctx, "SELECT user_id, username, email, password FROM users WHERE user_id = $1", userID).
-		Scan(&user.UserID, &user.UserName, &user.Email, &user.Password)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return &entity.User{}, fmt.Errorf("user not found")
-		}
-		return &entity.User{}, err
-	}
-	return &user, nil
+	return r.getUser(ctx, r.reader(ctx), "SELECT "+userColumns+" FROM users WHERE user_id = $1", userID)
 }
 
 // GetUserByUserName retrieves a user by their UserName
 func (r *PostgresUserRepository) GetUserByUserName(ctx context.Context, UserName string) (*entity.User, error) {
-	var user entity.User
-	err := r.DB.QueryRowContext(ctx, "SELECT user_id, username, email, password FROM users WHERE username = $1", UserName).
-		Scan(&user.UserID, &user.UserName, &user.Email, &user.Password)
+	return r.getUser(ctx, r.DB, "SELECT "+userColumns+" FROM users WHERE username = $1", UserName)
+}
+
+// GetUserByEmail retrieves a user by their email address
+func (r *PostgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*entity.User, error) {
+	return r.getUser(ctx, r.DB, "SELECT "+userColumns+" FROM users WHERE email = $1", email)
+}
+
+// getUser runs a single-row user query and scans the result
+func (r *PostgresUserRepository) getUser(ctx context.Context, conn queryer, query string, arg interface{}) (*entity.User, error) {
+	user, err := scanUser(conn.QueryRowContext(ctx, query, arg))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return &entity.User{}, fmt.Errorf("user not found")
+			return &entity.User{}, notFound("user")
 		}
 		return &entity.User{}, err
 	}
+	return user, nil
+}
 
+// reader returns the database for a read that a replica may serve
+func (r *PostgresUserRepository) reader(ctx context.Context) queryer {
+	if r.Replicas == nil {
+		return r.DB
+	}
+	return r.Replicas.Reader(ctx)
+}
+
+// scanUser reads a row selected with userColumns
+func scanUser(row rowScanner) (*entity.User, error) {
+	var user entity.User
+	var revokedAt sql.NullTime
+	err := row.Scan(&user.UserID, &user.UserName, &user.Email, &user.Password,
+		&user.EmailVerified, &user.Role, &user.Disabled, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	user.SessionsRevokedAt = revokedAt.Time
 	return &user, nil
 }
 
+// ListUsers returns a page of users whose username or email contains search
+func (r *PostgresUserRepository) ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error) {
+	rows, err := r.DB.QueryContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE $1 = '' OR username ILIKE '%' || $1 || '%' OR email ILIKE '%' || $1 || '%' "+
+			"ORDER BY user_id LIMIT $2 OFFSET $3",
+		search, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []entity.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
 // UpdateUser updates the user's information in the database
 func (r *PostgresUserRepository) UpdateUser(ctx context.Context, user *entity.User) error {
 	_, err := r.DB.ExecContext(ctx,
-		"UPDATE users SET username = $1, email = $2, password = $3 WHERE id = $4",
+		"UPDATE users SET username = $1, email = $2, password = $3 WHERE user_id = $4",
 		user.UserName, user.Email, user.Password, user.UserID,
 	)
-	return err
+	return uniqueViolation(err)
 }
 
 // DeleteUser deletes a user from the database
 func (r *PostgresUserRepository) DeleteUser(ctx context.Context, userID int) error {
-	_, err := r.DB.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID)
+	_, err := r.DB.ExecContext(ctx, "DELETE FROM users WHERE user_id = $1", userID)
 	return err
 }
+
+// MarkEmailVerified flags the user's email address as verified
+func (r *PostgresUserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	_, err := r.DB.ExecContext(ctx, "UPDATE users SET email_verified = TRUE WHERE user_id = $1", userID)
+	return err
+}
+
+// UpdatePassword stores a new password hash and revokes every session issued before now
+func (r *PostgresUserRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) error {
+	_, err := r.DB.ExecContext(ctx,
+		"UPDATE users SET password = $1, sessions_revoked_at = NOW() WHERE user_id = $2",
+		passwordHash, userID,
+	)
+	return err
+}
+
+// SetDisabled enables or disables a user account
+func (r *PostgresUserRepository) SetDisabled(ctx context.Context, userID int, disabled bool) error {
+	return r.execOne(ctx, "UPDATE users SET disabled = $1 WHERE user_id = $2", disabled, userID)
+}
+
+// SetRole changes the role of a user
+func (r *PostgresUserRepository) SetRole(ctx context.Context, userID int, role string) error {
+	return r.execOne(ctx, "UPDATE users SET role = $1 WHERE user_id = $2", role, userID)
+}
+
+// execOne runs a statement that is expected to touch exactly one user
+func (r *PostgresUserRepository) execOne(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound("user")
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/service"
+	"github.com/srikanthbhandary/todo-server/worker"
+)
+
+// requestAcceptedMessage is returned whether or not the address belongs to an account,
+// so these endpoints cannot be used to discover registered emails.
+const requestAcceptedMessage = "If the address belongs to an account, an email is on its way"
+
+func (rt *Router) RequestEmailVerification(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Email == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "email is required"})
+		return
+	}
+	if !rt.accountFlowsEnabled(w) {
+		return
+	}
+
+	rt.sendVerificationEmail(r, request.Email)
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": requestAcceptedMessage})
+}
+
+func (rt *Router) ConfirmEmailVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.accountFlowsEnabled(w) {
+		return
+	}
+
+	// Links in emails arrive as GET with a query parameter, API clients POST a body
+	token := r.URL.Query().Get("token")
+	if r.Method == http.MethodPost {
+		var request struct {
+			Token string `json:"token"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+			return
+		}
+		token = request.Token
+	}
+
+	if _, err := rt.accountSvc.VerifyEmail(r.Context(), token); err != nil {
+		writeAccountError(w, err, "failed to verify email")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Email verified successfully"})
+}
+
+func (rt *Router) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Email == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "email is required"})
+		return
+	}
+	if !rt.accountFlowsEnabled(w) {
+		return
+	}
+
+	user, token, err := rt.accountSvc.IssuePasswordReset(r.Context(), request.Email)
+	if err != nil {
+		slog.Info("password reset not issued", "error", err)
+	} else {
+		body := fmt.Sprintf("Use the token below to choose a new password. It expires in one hour.\n\n%s\n\n"+
+			"Submit it with your new password to %s.\nIf you did not ask for a reset, you can ignore this email.",
+			token, rt.publicURL(r, "/password-reset/confirm"))
+		rt.WorkerPool.EnqueueJob(worker.NewEmailJob(rt.EmailSender, []string{user.Email}, "Reset your password", body))
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"message": requestAcceptedMessage})
+}
+
+func (rt *Router) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+	if !rt.accountFlowsEnabled(w) {
+		return
+	}
+
+	if _, err := rt.accountSvc.ResetPassword(r.Context(), request.Token, request.Password); err != nil {
+		writeAccountError(w, err, "failed to reset password")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password updated successfully"})
+}
+
+// sendVerificationEmail issues a verification token for email and queues the message.
+// Failures are only logged: callers must not reveal whether the address is registered.
+func (rt *Router) sendVerificationEmail(r *http.Request, email string) {
+	if rt.accountSvc == nil {
+		return
+	}
+
+	user, token, err := rt.accountSvc.IssueEmailVerification(r.Context(), email)
+	if err != nil {
+		slog.Info("email verification not issued", "error", err)
+		return
+	}
+
+	link := rt.publicURL(r, "/verify-email/confirm?token="+url.QueryEscape(token))
+	body := fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in 24 hours.\n\n%s",
+		user.UserName, link)
+	rt.WorkerPool.EnqueueJob(worker.NewEmailJob(rt.EmailSender, []string{user.Email}, "Verify your email address", body))
+}
+
+// accountFlowsEnabled writes a 501 response when no AccountService has been configured
+func (rt *Router) accountFlowsEnabled(w http.ResponseWriter) bool {
+	if rt.accountSvc != nil {
+		return true
+	}
+	w.WriteHeader(http.StatusNotImplemented)
+	json.NewEncoder(w).Encode(map[string]string{"error": "account recovery is not enabled"})
+	return false
+}
+
+// publicURL builds an absolute link to path on this server
+func (rt *Router) publicURL(r *http.Request, path string) string {
+	if cfg := rt.currentConfig(); cfg != nil && cfg.BaseURL != "" {
+		return strings.TrimSuffix(cfg.BaseURL, "/") + path
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + path
+}
+
+// writeAccountError maps account flow errors onto HTTP status codes
+func writeAccountError(w http.ResponseWriter, err error, message string) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, service.ErrInvalidToken) || errors.Is(err, service.ErrEmptyPassword) {
+		status = http.StatusBadRequest
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "message": err.Error()})
+}
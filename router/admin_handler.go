@@ -0,0 +1,191 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/service"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+func (rt *Router) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	limit, offset, err := pagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid pagination", "message": err.Error()})
+		return
+	}
+
+	users, err := rt.userService.ListUsers(r.Context(), r.URL.Query().Get("q"), limit, offset)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to list users", "message": err.Error()})
+		return
+	}
+
+	profiles := make([]entity.UserProfile, 0, len(users))
+	for i := range users {
+		profiles = append(profiles, users[i].Profile())
+	}
+	json.NewEncoder(w).Encode(profiles)
+}
+
+func (rt *Router) AdminDisableUser(w http.ResponseWriter, r *http.Request) {
+	rt.setUserDisabled(w, r, true)
+}
+
+func (rt *Router) AdminEnableUser(w http.ResponseWriter, r *http.Request) {
+	rt.setUserDisabled(w, r, false)
+}
+
+func (rt *Router) AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := rt.targetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := rt.userService.DeleteUser(r.Context(), userID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to delete user", "message": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rt *Router) AdminSetUserRole(w http.ResponseWriter, r *http.Request) {
+	userID, ok := rt.targetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	if err := rt.userService.SetUserRole(r.Context(), userID, request.Role); err != nil {
+		writeAdminError(w, err, "failed to update role")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role updated successfully"})
+}
+
+func (rt *Router) AdminResetPassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := rt.adminTargetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	if err := rt.userService.SetPassword(r.Context(), userID, request.Password); err != nil {
+		writeAdminError(w, err, "failed to reset password")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password updated successfully"})
+}
+
+func (rt *Router) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	userID, ok := rt.targetUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := rt.userService.SetUserDisabled(r.Context(), userID, disabled); err != nil {
+		writeAdminError(w, err, "failed to update user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// targetUserID parses the user ID from the path and refuses changes to the caller's own account,
+// so an administrator cannot lock themselves out or drop their own privileges by accident.
+func (rt *Router) targetUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := rt.adminTargetUserID(w, r)
+	if !ok {
+		return 0, false
+	}
+	if userID == r.Context().Value("userID").(int) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "cannot change your own account through the admin API"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// adminTargetUserID parses the user ID from the path
+func (rt *Router) adminTargetUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	w.Header().Set("Content-Type", "application/json")
+	userID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid user ID"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// writeAdminError maps admin operation errors onto HTTP status codes
+func writeAdminError(w http.ResponseWriter, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrEmptyPassword):
+		status = http.StatusBadRequest
+	case errors.Is(err, repository.ErrNotFound):
+		status = http.StatusNotFound
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "message": err.Error()})
+}
+
+// pagination reads the limit and offset query parameters
+func pagination(r *http.Request) (int, int, error) {
+	limit, offset := defaultPageSize, 0
+	query := r.URL.Query()
+
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("limit must be a positive integer")
+		}
+		limit = parsed
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, errors.New("offset must be a non-negative integer")
+		}
+		offset = parsed
+	}
+	return limit, offset, nil
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminEndpoints(t *testing.T) {
+	jwtSvc := new(mocks.MockJWTValidator)
+	emailSender := &mocks.MockEmailSender{}
+
+	newRouter := func(t *testing.T, userSvc *mocks.MockUserService) *Router {
+		jobChannel := make(chan worker.Job, 10)
+		pool := worker.NewWorkerPool(1, jobChannel)
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		pool.Init(ctx)
+
+		r := NewRouter(new(mocks.MockToDoService), userSvc, jwtSvc, nil, pool, emailSender)
+		r.InitRoutes()
+		return r
+	}
+
+	t.Run("TestAdminListUsers_Forbidden", func(t *testing.T) {
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+		r := newRouter(t, userSvc)
+
+		req := httptest.NewRequest("GET", "/admin/users", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		userSvc.AssertNotCalled(t, "ListUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestAdminListUsers_SUCCESS", func(t *testing.T) {
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleAdmin}, nil)
+		userSvc.On("ListUsers", mock.Anything, "bob", 10, 20).Return([]entity.User{
+			{UserID: 2, UserName: "bob", Password: "$2a$10$hash"},
+		}, nil)
+		r := newRouter(t, userSvc)
+
+		req := httptest.NewRequest("GET", "/admin/users?q=bob&limit=10&offset=20", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "password")
+		var result []entity.UserProfile
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&result))
+		assert.Len(t, result, 1)
+		userSvc.AssertExpectations(t)
+	})
+
+	t.Run("TestAdminDisableUser_Self", func(t *testing.T) {
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleAdmin}, nil)
+		r := newRouter(t, userSvc)
+
+		req := httptest.NewRequest("POST", "/admin/users/1/disable", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		userSvc.AssertNotCalled(t, "SetUserDisabled", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestGetUserByID_OtherUserForbidden", func(t *testing.T) {
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+		r := newRouter(t, userSvc)
+
+		req := httptest.NewRequest("GET", "/users/2", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("TestGetMe_NoPasswordHash", func(t *testing.T) {
+		userSvc := new(mocks.MockUserService)
+		user := &entity.User{UserID: 1, UserName: "testuser", Password: "$2a$10$hash", Role: entity.RoleUser}
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(user, nil)
+		userSvc.On("GetUserByID", mock.Anything, 1).Return(user, nil)
+		r := newRouter(t, userSvc)
+
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.NotContains(t, rr.Body.String(), "$2a$")
+	})
+}
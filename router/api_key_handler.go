@@ -0,0 +1,106 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/srikanthbhandary/todo-server/service"
+)
+
+func (rt *Router) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.apiKeysEnabled(w) {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+	if request.Name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "name is required"})
+		return
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "expires_at must be in the future"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	key, secret, err := rt.apiKeySvc.CreateAPIKey(r.Context(), userID, request.Name, request.Scopes, request.ExpiresAt)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidScope) {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to create API key", "message": err.Error()})
+		return
+	}
+
+	// The secret is only ever returned here
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"key": secret, "api_key": key})
+}
+
+func (rt *Router) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.apiKeysEnabled(w) {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	keys, err := rt.apiKeySvc.ListAPIKeys(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to list API keys", "message": err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (rt *Router) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.apiKeysEnabled(w) {
+		return
+	}
+
+	keyID, err := strconv.Atoi(mux.Vars(r)["keyID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid API key ID"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	if err := rt.apiKeySvc.RevokeAPIKey(r.Context(), userID, keyID); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to revoke API key", "message": err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiKeysEnabled writes a 501 response when no APIKeyService has been configured
+func (rt *Router) apiKeysEnabled(w http.ResponseWriter) bool {
+	if rt.apiKeySvc != nil {
+		return true
+	}
+	w.WriteHeader(http.StatusNotImplemented)
+	json.NewEncoder(w).Encode(map[string]string{"error": "API keys are not enabled"})
+	return false
+}
@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	mockToDoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	apiKeySvc := new(mocks.MockAPIKeyService)
+	jwtSvc := new(mocks.MockJWTValidator)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:7").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:7", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(mockToDoSvc, mockUserSvc, jwtSvc, ratelimiter, pool, &mocks.MockEmailSender{}, WithAPIKeyService(apiKeySvc))
+	r.InitRoutes()
+
+	readOnlyKey := &entity.APIKey{KeyID: 3, UserID: 7, Scopes: []string{entity.ScopeTodosRead}}
+	apiKeySvc.On("Authenticate", mock.Anything, "tds_abcd1234_secret").Return(readOnlyKey, nil)
+	mockUserSvc.On("ValidateSession", mock.Anything, 7, readOnlyKey.CreatedAt).Return(&entity.User{UserID: 7, Role: entity.RoleUser}, nil)
+	mockToDoSvc.On("GetAllTodos", mock.Anything, 7).Return([]entity.ToDo{}, nil)
+
+	t.Run("TestAPIKey_ReadAllowed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/todos", nil)
+		req.Header.Set("Authorization", "Bearer tds_abcd1234_secret")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockToDoSvc.AssertExpectations(t)
+	})
+
+	t.Run("TestAPIKey_WriteForbidden", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/todos", strings.NewReader(`{"title": "from ci"}`))
+		req.Header.Set("Authorization", "Bearer tds_abcd1234_secret")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		mockToDoSvc.AssertNotCalled(t, "AddToDo", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestAPIKey_CannotManageKeys", func(t *testing.T) {
+		// Key management only accepts JWTs; the mock JWT validator would accept anything,
+		// so check that the API key path is never consulted there.
+		mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1}, nil)
+		apiKeySvc.On("ListAPIKeys", mock.Anything, 1).Return([]entity.APIKey{}, nil)
+
+		req := httptest.NewRequest("GET", "/api-keys", nil)
+		req.Header.Set("Authorization", "Bearer tds_abcd1234_secret")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		apiKeySvc.AssertNumberOfCalls(t, "Authenticate", 2)
+	})
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/worker"
+)
+
+func (rt *Router) AssignToDo(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		AssigneeID *int `json:"assignee_id"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	todoID, err := strconv.Atoi(mux.Vars(r)["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	assignment, err := rt.todoService.AssignTodo(r.Context(), userID, todoID, request.AssigneeID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, repository.ErrAssigneeNotMember):
+			status = http.StatusUnprocessableEntity
+		case errors.Is(err, repository.ErrListNotWritable):
+			status = http.StatusForbidden
+		case errors.Is(err, repository.ErrNotFound):
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to assign todo", "message": err.Error()})
+		return
+	}
+
+	// A zero ID means the assignee did not change, so nobody needs to hear about it
+	if assignment.AssignmentID != 0 {
+		rt.notifyAssignment(r, assignment)
+	}
+	json.NewEncoder(w).Encode(assignment)
+}
+
+func (rt *Router) GetAssignmentHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, err := strconv.Atoi(mux.Vars(r)["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	history, err := rt.todoService.GetAssignmentHistory(r.Context(), userID, todoID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "todo not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(history)
+}
+
+// notifyAssignment tells the new assignee, and the previous one, about a change through
+// their open WebSocket connections and by email. The person making the change is not notified.
+func (rt *Router) notifyAssignment(r *http.Request, assignment *entity.TodoAssignment) {
+	title := fmt.Sprintf("#%d", assignment.TodoID)
+	if todo, err := rt.todoService.GetTodo(r.Context(), assignment.AssignedBy, assignment.TodoID); err == nil {
+		title = todo.Title
+	}
+
+	path := fmt.Sprintf("/todos/%d", assignment.TodoID)
+	if assignment.AssigneeID != nil && *assignment.AssigneeID != assignment.AssignedBy {
+		rt.notifyUser(r, *assignment.AssigneeID, "todo_assigned", assignment,
+			"A todo was assigned to you", fmt.Sprintf("You have been assigned the todo %q.", title), path)
+	}
+	if assignment.PreviousAssigneeID != nil && *assignment.PreviousAssigneeID != assignment.AssignedBy {
+		rt.notifyUser(r, *assignment.PreviousAssigneeID, "todo_unassigned", assignment,
+			"A todo is no longer assigned to you", fmt.Sprintf("The todo %q is no longer assigned to you.", title), path)
+	}
+}
+
+// notifyUser queues a WebSocket push and an email for one user.
+// The push carries the event name and its payload; the email links to path on this server.
+func (rt *Router) notifyUser(r *http.Request, userID int, event string, payload interface{}, subject, body, path string) {
+	message, err := json.Marshal(map[string]interface{}{"type": event, "data": payload})
+	if err == nil {
+		rt.WorkerPool.EnqueueJob(&worker.UserNotificationJob{Connections: rt.WorkerPool.Connections, UserID: userID, Message: message})
+	}
+
+	user, err := rt.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		slog.Warn("email not sent", "event", event, "user_id", userID, "error", err)
+		return
+	}
+	rt.WorkerPool.EnqueueJob(worker.NewEmailJob(rt.EmailSender, []string{user.Email}, subject,
+		body+"\n\nOpen it at "+rt.publicURL(r, path)))
+}
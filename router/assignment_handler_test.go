@@ -0,0 +1,114 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// channelEmailSender hands the emails it sends to the test over a channel, since the worker pool
+// sends them on its own goroutines
+type channelEmailSender struct {
+	sent chan string
+}
+
+func newChannelEmailSender() *channelEmailSender {
+	return &channelEmailSender{sent: make(chan string, 10)}
+}
+
+func (s *channelEmailSender) SendEmail(to []string, subject, body string) error {
+	s.sent <- fmt.Sprintf("To: %s, Subject: %s, Body: %s", to[0], subject, body)
+	return nil
+}
+
+// next waits for the next email sent and fails the test when none comes
+func (s *channelEmailSender) next(t *testing.T) string {
+	t.Helper()
+	select {
+	case email := <-s.sent:
+		return email
+	case <-time.After(time.Second):
+		t.Fatal("expected an email to be sent")
+		return ""
+	}
+}
+
+func TestAssignmentHandlers(t *testing.T) {
+	mockToDoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	emailSender := newChannelEmailSender()
+	r := NewRouter(mockToDoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, emailSender)
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestAssignToDo_NotifiesAssignee", func(t *testing.T) {
+		assignee := 2
+		assignment := &entity.TodoAssignment{AssignmentID: 4, TodoID: 10, AssigneeID: &assignee, AssignedBy: 1}
+		mockToDoSvc.On("AssignTodo", mock.Anything, 1, 10, &assignee).Return(assignment, nil).Once()
+		mockToDoSvc.On("GetTodo", mock.Anything, 1, 10).Return(entity.ToDo{ToDoID: 10, Title: "Tag the release"}, nil).Once()
+		mockUserSvc.On("GetUserByID", mock.Anything, 2).Return(&entity.User{UserID: 2, Email: "teammate@example.com"}, nil).Once()
+
+		rr := serve("PUT", "/todos/10/assignee", `{"assignee_id": 2}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		email := emailSender.next(t)
+		assert.Contains(t, email, "To: teammate@example.com")
+		assert.Contains(t, email, "Tag the release")
+	})
+
+	t.Run("TestAssignToDo_NotMember", func(t *testing.T) {
+		assignee := 3
+		mockToDoSvc.On("AssignTodo", mock.Anything, 1, 11, &assignee).Return(nil, repository.ErrAssigneeNotMember).Once()
+
+		rr := serve("PUT", "/todos/11/assignee", `{"assignee_id": 3}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("TestGetTodos_AssignedToMe", func(t *testing.T) {
+		mockToDoSvc.On("GetAssignedTodos", mock.Anything, 1).Return([]entity.ToDo{{ToDoID: 10}}, nil).Once()
+
+		rr := serve("GET", "/todos?assigned_to=me", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		mockToDoSvc.AssertNotCalled(t, "GetAllTodos", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestGetTodos_AssignedToOther", func(t *testing.T) {
+		rr := serve("GET", "/todos?assigned_to=2", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	mockToDoSvc.AssertExpectations(t)
+}
@@ -0,0 +1,150 @@
+package router
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// maxExportRows bounds a single CSV export; larger exports page with offset
+const maxExportRows = 10000
+
+// GetAuditEvents lists the events the caller performed or that changed their data
+func (rt *Router) GetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(int)
+	rt.serveAuditEvents(w, r, userID)
+}
+
+// AdminGetAuditEvents lists every event, optionally narrowed to one user with user_id
+func (rt *Router) AdminGetAuditEvents(w http.ResponseWriter, r *http.Request) {
+	userID := 0
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid user_id"})
+			return
+		}
+		userID = parsed
+	}
+	rt.serveAuditEvents(w, r, userID)
+}
+
+// serveAuditEvents answers an audit query as JSON, or as CSV when format=csv
+func (rt *Router) serveAuditEvents(w http.ResponseWriter, r *http.Request, userID int) {
+	asCSV := r.URL.Query().Get("format") == "csv"
+	if !asCSV {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	if rt.auditSvc == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(map[string]string{"error": "the audit log is not enabled"})
+		return
+	}
+
+	filter, err := auditFilter(r, asCSV)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid query", "message": err.Error()})
+		return
+	}
+	filter.UserID = userID
+
+	events, err := rt.auditSvc.ListEvents(r.Context(), filter)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to retrieve audit events", "message": err.Error()})
+		return
+	}
+
+	if asCSV {
+		writeAuditCSV(w, events)
+		return
+	}
+	json.NewEncoder(w).Encode(events)
+}
+
+// auditFilter reads the action, since, until, limit and offset query parameters.
+// CSV exports default to and allow larger pages than JSON listings.
+func auditFilter(r *http.Request, export bool) (entity.AuditFilter, error) {
+	var filter entity.AuditFilter
+	query := r.URL.Query()
+
+	limit, offset, err := pagination(r)
+	if err != nil {
+		return filter, err
+	}
+	if export {
+		limit = maxExportRows
+		if value := query.Get("limit"); value != "" {
+			limit, _ = strconv.Atoi(value) // already validated by pagination
+		}
+		if limit > maxExportRows {
+			limit = maxExportRows
+		}
+	}
+	filter.Limit, filter.Offset = limit, offset
+	filter.Action = query.Get("action")
+
+	for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if value := query.Get(name); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*target = parsed
+		}
+	}
+	return filter, nil
+}
+
+// writeAuditCSV streams events as a CSV attachment
+func writeAuditCSV(w http.ResponseWriter, events []entity.AuditEvent) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"id", "created_at", "actor_id", "subject_id", "action", "target_type", "target_id",
+		"ip", "request_id", "before", "after"})
+	for _, event := range events {
+		writer.Write([]string{
+			strconv.FormatInt(event.EventID, 10),
+			event.CreatedAt.UTC().Format(time.RFC3339),
+			optionalID(event.ActorID),
+			optionalID(event.SubjectID),
+			csvSafe(event.Action),
+			csvSafe(event.TargetType),
+			csvSafe(event.TargetID),
+			csvSafe(event.IP),
+			csvSafe(event.RequestID),
+			csvSafe(string(event.Before)),
+			csvSafe(string(event.After)),
+		})
+	}
+	writer.Flush()
+}
+
+// optionalID formats a nullable user ID for CSV
+func optionalID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
+
+// csvSafe keeps spreadsheet programs from evaluating user-controlled values such as usernames as formulas
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditHandlers(t *testing.T) {
+	mockRedis := &mocks.MockRedisClient{}
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	newRouter := func(role string) (*Router, *mocks.MockAuditService) {
+		userSvc := new(mocks.MockUserService)
+		auditSvc := new(mocks.MockAuditService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: role}, nil)
+		r := NewRouter(new(mocks.MockToDoService), userSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{},
+			WithAuditService(auditSvc))
+		r.InitRoutes()
+		return r, auditSvc
+	}
+
+	serve := func(r *Router, path string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	actor := 1
+	events := []entity.AuditEvent{{EventID: 9, ActorID: &actor, Action: "user.update", TargetType: "user", TargetID: "=HYPERLINK(1)"}}
+
+	t.Run("TestGetAuditEvents_OwnEventsOnly", func(t *testing.T) {
+		r, auditSvc := newRouter(entity.RoleUser)
+		since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		auditSvc.On("ListEvents", mock.Anything, entity.AuditFilter{UserID: 1, Action: "user.update", Since: since, Limit: 50}).Return(events, nil)
+
+		rr := serve(r, "/audit?action=user.update&since=2024-01-01T00:00:00Z", map[string]string{"X-Request-ID": "abc-123"})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "abc-123", rr.Header().Get("X-Request-ID"))
+		assert.Contains(t, rr.Body.String(), `"action":"user.update"`)
+	})
+
+	t.Run("TestGetAuditEvents_InvalidSince", func(t *testing.T) {
+		r, _ := newRouter(entity.RoleUser)
+
+		rr := serve(r, "/audit?since=yesterday", nil)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("TestGetAuditEvents_CSVExport", func(t *testing.T) {
+		r, auditSvc := newRouter(entity.RoleUser)
+		auditSvc.On("ListEvents", mock.Anything, entity.AuditFilter{UserID: 1, Limit: maxExportRows}).Return(events, nil)
+
+		rr := serve(r, "/audit?format=csv", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), "id,created_at,actor_id")
+		assert.Contains(t, rr.Body.String(), "'=HYPERLINK(1)")
+	})
+
+	t.Run("TestAdminGetAuditEvents_RequiresAdmin", func(t *testing.T) {
+		r, _ := newRouter(entity.RoleUser)
+
+		rr := serve(r, "/admin/audit", nil)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("TestAdminGetAuditEvents_FilterByUser", func(t *testing.T) {
+		r, auditSvc := newRouter(entity.RoleAdmin)
+		auditSvc.On("ListEvents", mock.Anything, entity.AuditFilter{UserID: 4, Limit: 50}).Return(events, nil)
+
+		rr := serve(r, "/admin/audit?user_id=4", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
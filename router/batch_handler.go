@@ -0,0 +1,99 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/service"
+)
+
+// Batch modes
+const (
+	batchTransactional = "transactional"
+	batchBestEffort    = "best_effort"
+)
+
+// maxBatchBody bounds the size of a batch request
+const maxBatchBody = 1 << 20
+
+// batchResult reports what happened to one operation of a batch
+type batchResult struct {
+	Index  int          `json:"index"`
+	Op     string       `json:"op"`
+	ID     int          `json:"id,omitempty"`
+	Status int          `json:"status"`
+	Todo   *entity.ToDo `json:"todo,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// BatchToDos applies a list of create, update and delete operations in one request.
+// In transactional mode (the default) either all operations are applied or none are, and a failure
+// answers with the failing operation's status; in best_effort mode each succeeds or fails on its own.
+// The batch is rate limited once, weighted by the number of operations.
+func (rt *Router) BatchToDos(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Mode       string                  `json:"mode"`
+		Operations []entity.BatchOperation `json:"operations"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody)).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+	if request.Mode == "" {
+		request.Mode = batchTransactional
+	}
+	if request.Mode != batchTransactional && request.Mode != batchBestEffort {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "mode must be transactional or best_effort"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	weight := len(request.Operations)
+	if weight < 1 {
+		weight = 1
+	}
+	if !rt.allowRequests(w, r, RateLimitGroupBatch, userID, weight) {
+		return
+	}
+
+	outcomes, err := rt.todoService.RunBatch(r.Context(), userID, request.Operations, request.Mode == batchTransactional)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrInvalidBatch) {
+			status = http.StatusBadRequest
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to run batch", "message": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	results := make([]batchResult, len(outcomes))
+	for i, outcome := range outcomes {
+		op := request.Operations[i]
+		result := batchResult{Index: i, Op: op.Op, ID: op.ID, Status: http.StatusOK, Todo: outcome.Todo}
+		switch {
+		case outcome.Err != nil:
+			result.Status = todoErrorStatus(outcome.Err)
+			result.Error = outcome.Err.Error()
+			if request.Mode == batchTransactional && !errors.Is(outcome.Err, service.ErrBatchAborted) {
+				status = result.Status
+			}
+		case op.Op == entity.BatchCreate:
+			result.ID = outcome.Todo.ToDoID
+			result.Status = http.StatusCreated
+		case op.Op == entity.BatchDelete:
+			result.Status = http.StatusNoContent
+		}
+		results[i] = result
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{"mode": request.Mode, "results": results})
+}
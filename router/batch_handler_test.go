@@ -0,0 +1,103 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/service"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchToDos(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("IncrBy", "rate_limit:1", int64(2)).Return(redis.NewIntResult(2, nil))
+	mockRedis.On("IncrBy", "rate_limit:1", int64(60)).Return(redis.NewIntResult(62, nil))
+	mockRedis.On("PTTL", "rate_limit:1").Return(redis.NewDurationResult(4*time.Second, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 50, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(todoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{})
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/todos/batch", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	type response struct {
+		Results []batchResult `json:"results"`
+	}
+
+	t.Run("TestBatchToDos_BestEffort", func(t *testing.T) {
+		todoSvc.On("RunBatch", mock.Anything, 1, mock.Anything, false).Return([]entity.BatchOutcome{
+			{Todo: &entity.ToDo{ToDoID: 9, Title: "Milk"}},
+			{Err: fmt.Errorf("todo %w", repository.ErrNotFound)},
+		}, nil).Once()
+
+		rr := serve(`{"mode": "best_effort", "operations": [{"op": "create", "title": "Milk"}, {"op": "delete", "id": 4}]}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var body response
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusCreated, body.Results[0].Status)
+		assert.Equal(t, 9, body.Results[0].ID)
+		assert.Equal(t, http.StatusNotFound, body.Results[1].Status)
+		mockRedis.AssertCalled(t, "IncrBy", "rate_limit:1", int64(2))
+		mockRedis.AssertNotCalled(t, "Incr", "rate_limit:1")
+	})
+
+	t.Run("TestBatchToDos_TransactionalFailure", func(t *testing.T) {
+		todoSvc.On("RunBatch", mock.Anything, 1, mock.Anything, true).Return([]entity.BatchOutcome{
+			{Err: service.ErrBatchAborted},
+			{Err: &service.ConflictError{TodoID: 4}},
+		}, nil).Once()
+
+		rr := serve(`{"operations": [{"op": "delete", "id": 3}, {"op": "delete", "id": 4, "version": 2}]}`)
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+		var body response
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, http.StatusFailedDependency, body.Results[0].Status)
+	})
+
+	t.Run("TestBatchToDos_WeightedRateLimit", func(t *testing.T) {
+		ops := strings.TrimSuffix(strings.Repeat(`{"op": "delete", "id": 1},`, 60), ",")
+
+		rr := serve(`{"operations": [` + ops + `]}`)
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "50", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "4", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("TestBatchToDos_InvalidMode", func(t *testing.T) {
+		rr := serve(`{"mode": "sometimes", "operations": []}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
@@ -0,0 +1,104 @@
+package router
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states, as reported in its metrics
+const (
+	BreakerClosed = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+var breakerStateNames = [...]string{BreakerClosed: "closed", BreakerOpen: "open", BreakerHalfOpen: "half-open"}
+
+// CircuitBreaker stops calls to a dependency that keeps failing. It opens after a number of
+// failures in a row and turns calls away for the cooldown, then lets a single call through to
+// test the dependency: closing again if it succeeds and reopening if it fails.
+// It is safe for concurrent use.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	name     string
+	failures int
+	cooldown time.Duration
+	state    int
+	// failed counts the failures in a row while closed
+	failed   int
+	openedAt time.Time
+	opened   int64
+	rejected int64
+	now      func() time.Time
+}
+
+// NewCircuitBreaker creates a closed breaker for the named dependency, which opens after the given
+// number of failures in a row
+func NewCircuitBreaker(name string, failures int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{name: name, failures: failures, cooldown: cooldown, now: time.Now}
+}
+
+// Allow reports whether a call may go ahead. Every allowed call must be followed by
+// Success or Failure, so that a half-open breaker learns the outcome of its test call.
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) >= b.cooldown {
+			b.transition(BreakerHalfOpen)
+			return true
+		}
+	}
+	// Either open, or half-open with the test call still running
+	b.rejected++
+	return false
+}
+
+// Success records a call that succeeded
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failed = 0
+	if b.state == BreakerHalfOpen {
+		b.transition(BreakerClosed)
+	}
+}
+
+// Failure records a call that failed with err
+func (b *CircuitBreaker) Failure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failed++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failed >= b.failures) {
+		slog.Warn(b.name+" failed", "error", err)
+		b.openedAt = b.now()
+		b.opened++
+		b.transition(BreakerOpen)
+	}
+}
+
+// transition moves to the state and logs it; b.mu must be held
+func (b *CircuitBreaker) transition(state int) {
+	slog.Warn(b.name+" circuit breaker changed state", "from", breakerStateNames[b.state], "to", breakerStateNames[state])
+	b.state = state
+}
+
+// Metrics reports the state, how often the breaker opened and how many calls it turned away
+func (b *CircuitBreaker) Metrics() map[string]float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return map[string]float64{
+		"state":                float64(b.state),
+		"consecutive_failures": float64(b.failed),
+		"opened":               float64(b.opened),
+		"rejected":             float64(b.rejected),
+	}
+}
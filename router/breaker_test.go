@@ -0,0 +1,42 @@
+package router
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	b := NewCircuitBreaker("test", 2, 10*time.Second)
+	b.now = func() time.Time { return now }
+	down := errors.New("connection refused")
+
+	assert.True(t, b.Allow())
+	b.Failure(down)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.True(t, b.Allow())
+	b.Failure(down)
+	assert.Equal(t, float64(BreakerClosed), b.Metrics()["state"], "a success resets the failures in a row")
+
+	assert.True(t, b.Allow())
+	b.Failure(down)
+	assert.Equal(t, float64(BreakerOpen), b.Metrics()["state"])
+	assert.False(t, b.Allow(), "an open breaker turns calls away")
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.Allow(), "one test call goes through after the cooldown")
+	assert.False(t, b.Allow(), "only one test call at a time")
+	b.Failure(down)
+	assert.Equal(t, float64(BreakerOpen), b.Metrics()["state"], "a failed test call reopens the breaker")
+	assert.False(t, b.Allow())
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.True(t, b.Allow())
+	assert.Equal(t, map[string]float64{"state": BreakerClosed, "consecutive_failures": 0, "opened": 2, "rejected": 3}, b.Metrics())
+}
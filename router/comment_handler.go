@@ -0,0 +1,175 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/service"
+)
+
+func (rt *Router) CreateComment(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Body string `json:"body"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	todoID, ok := rt.commentPathID(w, r, "todoID")
+	if !ok {
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	comment, mentioned, err := rt.commentSvc.CreateComment(r.Context(), userID, todoID, request.Body)
+	if err != nil {
+		writeCommentError(w, err, "failed to create comment")
+		return
+	}
+	rt.notifyMentions(r, comment, mentioned)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (rt *Router) ListComments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, ok := rt.commentPathID(w, r, "todoID")
+	if !ok {
+		return
+	}
+	limit, offset, err := pagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid pagination", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	comments, err := rt.commentSvc.ListComments(r.Context(), userID, todoID, limit, offset)
+	if err != nil {
+		writeCommentError(w, err, "failed to list comments")
+		return
+	}
+
+	json.NewEncoder(w).Encode(comments)
+}
+
+func (rt *Router) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Body string `json:"body"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	todoID, ok := rt.commentPathID(w, r, "todoID")
+	if !ok {
+		return
+	}
+	commentID, ok := rt.commentPathID(w, r, "commentID")
+	if !ok {
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	comment, mentioned, err := rt.commentSvc.UpdateComment(r.Context(), userID, todoID, commentID, request.Body)
+	if err != nil {
+		writeCommentError(w, err, "failed to update comment")
+		return
+	}
+	rt.notifyMentions(r, comment, mentioned)
+
+	json.NewEncoder(w).Encode(comment)
+}
+
+func (rt *Router) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, ok := rt.commentPathID(w, r, "todoID")
+	if !ok {
+		return
+	}
+	commentID, ok := rt.commentPathID(w, r, "commentID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	if err := rt.commentSvc.DeleteComment(r.Context(), userID, todoID, commentID); err != nil {
+		writeCommentError(w, err, "failed to delete comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rt *Router) GetCommentRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, ok := rt.commentPathID(w, r, "todoID")
+	if !ok {
+		return
+	}
+	commentID, ok := rt.commentPathID(w, r, "commentID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	revisions, err := rt.commentSvc.GetCommentRevisions(r.Context(), userID, todoID, commentID)
+	if err != nil {
+		writeCommentError(w, err, "failed to retrieve comment history")
+		return
+	}
+
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// notifyMentions tells every newly mentioned user about the comment
+func (rt *Router) notifyMentions(r *http.Request, comment *entity.Comment, mentioned []entity.User) {
+	for _, user := range mentioned {
+		rt.notifyUser(r, user.UserID, "comment_mention", comment,
+			"You were mentioned in a comment",
+			fmt.Sprintf("%s mentioned you in a comment:\n\n%s", comment.UserName, comment.Body),
+			fmt.Sprintf("/todos/%d/comments", comment.TodoID))
+	}
+}
+
+// commentPathID parses an ID from the path, writing a 400 response when it is malformed
+func (rt *Router) commentPathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	if rt.commentSvc == nil {
+		w.WriteHeader(http.StatusNotImplemented)
+		json.NewEncoder(w).Encode(map[string]string{"error": "comments are not enabled"})
+		return 0, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeCommentError maps comment errors onto HTTP status codes
+func writeCommentError(w http.ResponseWriter, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidComment):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrCommentPermission):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrTodoNotFound), errors.Is(err, service.ErrCommentNotFound):
+		status = http.StatusNotFound
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "message": err.Error()})
+}
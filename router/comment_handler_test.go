@@ -0,0 +1,84 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/service"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCommentHandlers(t *testing.T) {
+	mockUserSvc := new(mocks.MockUserService)
+	commentSvc := new(mocks.MockCommentService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	emailSender := newChannelEmailSender()
+	r := NewRouter(new(mocks.MockToDoService), mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, emailSender,
+		WithCommentService(commentSvc))
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestCreateComment_NotifiesMentions", func(t *testing.T) {
+		comment := &entity.Comment{CommentID: 3, TodoID: 10, UserID: 1, UserName: "author", Body: "@alice please review"}
+		alice := entity.User{UserID: 8, UserName: "alice", Email: "alice@example.com"}
+		commentSvc.On("CreateComment", mock.Anything, 1, 10, "@alice please review").Return(comment, []entity.User{alice}, nil).Once()
+		mockUserSvc.On("GetUserByID", mock.Anything, 8).Return(&alice, nil).Once()
+
+		rr := serve("POST", "/todos/10/comments", `{"body": "@alice please review"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		assert.Contains(t, emailSender.next(t), "To: alice@example.com")
+	})
+
+	t.Run("TestListComments_Paginated", func(t *testing.T) {
+		commentSvc.On("ListComments", mock.Anything, 1, 10, 20, 40).Return([]entity.Comment{}, nil).Once()
+
+		rr := serve("GET", "/todos/10/comments?limit=20&offset=40", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("TestUpdateComment_NotAuthor", func(t *testing.T) {
+		commentSvc.On("UpdateComment", mock.Anything, 1, 10, 3, "edited").Return(nil, nil, service.ErrCommentPermission).Once()
+
+		rr := serve("PATCH", "/todos/10/comments/3", `{"body": "edited"}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("TestDeleteComment_NotFound", func(t *testing.T) {
+		commentSvc.On("DeleteComment", mock.Anything, 1, 10, 4).Return(service.ErrCommentNotFound).Once()
+
+		rr := serve("DELETE", "/todos/10/comments/4", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	commentSvc.AssertExpectations(t)
+}
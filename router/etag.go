@@ -0,0 +1,71 @@
+package router
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// todoETag is a strong validator for one todo; it changes whenever the todo's version does
+func todoETag(todo entity.ToDo) string {
+	return fmt.Sprintf(`"%d-%d"`, todo.ToDoID, todo.Version)
+}
+
+// listETag is a weak validator for a list of todos, covering which todos it holds and their versions
+func listETag(todos []entity.ToDo) string {
+	hash := sha256.New()
+	for _, todo := range todos {
+		fmt.Fprintf(hash, "%d-%d;", todo.ToDoID, todo.Version)
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// notModified sets the ETag header and answers 304 when it matches If-None-Match.
+// It reports whether the response has been written.
+func notModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	w.Header().Set("ETag", etag)
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		// If-None-Match uses the weak comparison, so W/ prefixes are ignored
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatchVersion reads the todo version a write is conditioned on from If-Match.
+// Zero means the write is unconditional. When If-Match names no current ETag of this todo,
+// a 412 is written and ok is false.
+func ifMatchVersion(w http.ResponseWriter, r *http.Request, todoID int) (version int, ok bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return 0, true
+		}
+		// If-Match uses the strong comparison, so weak tags never match
+		var id, v int
+		if _, err := fmt.Sscanf(tag, `"%d-%d"`, &id, &v); err == nil && id == todoID && v > 0 {
+			return v, true
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusPreconditionFailed)
+	json.NewEncoder(w).Encode(map[string]string{"error": "precondition failed", "message": "If-Match does not name a version of this todo"})
+	return 0, false
+}
@@ -0,0 +1,43 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// HealthChecker reports whether a dependency can serve requests: nil when it can, the reason otherwise.
+// It should answer from state kept up to date elsewhere rather than probe the dependency itself.
+type HealthChecker interface {
+	Healthy() error
+}
+
+// Liveness answers 200 for as long as the process serves HTTP, whatever state its dependencies are in
+func (rt *Router) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// Readiness answers 200 when every health check passes and 503 otherwise,
+// listing each check as "ok" or the reason it failed
+func (rt *Router) Readiness(w http.ResponseWriter, r *http.Request) {
+	names := make([]string, 0, len(rt.healthChecks))
+	for name := range rt.healthChecks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	status, code := "ready", http.StatusOK
+	checks := map[string]string{}
+	for _, name := range names {
+		checks[name] = "ok"
+		if err := rt.healthChecks[name].Healthy(); err != nil {
+			checks[name] = err.Error()
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{"status": status, "checks": checks})
+}
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubHealthCheck struct {
+	err error
+}
+
+func (c *stubHealthCheck) Healthy() error {
+	return c.err
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	pool := worker.NewWorkerPool(1, make(chan worker.Job, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	database := &stubHealthCheck{}
+	r := NewRouter(new(mocks.MockToDoService), new(mocks.MockUserService), new(mocks.MockJWTValidator),
+		NewMemoryRateLimiter(100, time.Minute), pool, &mocks.MockEmailSender{}, WithHealthCheck("database", database))
+	r.InitRoutes()
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+
+	t.Run("TestReadiness_Ready", func(t *testing.T) {
+		rr := serve("/readyz")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"status":"ready","checks":{"database":"ok"}}`, rr.Body.String())
+	})
+
+	t.Run("TestReadiness_DatabaseDown", func(t *testing.T) {
+		database.err = errors.New("connection refused")
+		defer func() { database.err = nil }()
+
+		rr := serve("/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+		assert.JSONEq(t, `{"status":"unavailable","checks":{"database":"connection refused"}}`, rr.Body.String())
+
+		assert.Equal(t, http.StatusOK, serve("/healthz").Code, "liveness does not depend on the database")
+	})
+}
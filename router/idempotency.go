@@ -0,0 +1,231 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// IdempotencyKeyTTL is how long the first response to an Idempotency-Key is kept for replay
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// IdempotencyLockTTL is how long a key stays reserved for a request still being processed. It is kept
+// short so that a key whose request never finished, as when the server restarted, frees up quickly.
+const IdempotencyLockTTL = time.Minute
+
+// maxIdempotentBody bounds the request bodies read to fingerprint a request
+const maxIdempotentBody = 1 << 20
+
+// idempotencyKeyPattern limits keys to something safe to embed in a storage key; UUIDs fit
+var idempotencyKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,255}$`)
+
+// IdempotentResponse is the stored outcome of the first request made with an Idempotency-Key.
+// Status is zero while that request is still being processed.
+type IdempotentResponse struct {
+	Fingerprint string            `json:"fingerprint"`
+	Status      int               `json:"status,omitempty"`
+	Header      map[string]string `json:"header,omitempty"`
+	Body        []byte            `json:"body,omitempty"`
+}
+
+// IdempotencyStore remembers responses to requests made with an Idempotency-Key.
+type IdempotencyStore interface {
+	// Reserve claims the key for a request with the given fingerprint and returns nil.
+	// When the key is already claimed, it returns the stored response instead.
+	Reserve(key, fingerprint string) (*IdempotentResponse, error)
+	// Save stores the response to a reserved key.
+	Save(key string, response *IdempotentResponse) error
+	// Release forgets a reserved key so that the request can be retried.
+	Release(key string) error
+}
+
+// RedisIdempotencyStore implements IdempotencyStore with one expiring Redis key per Idempotency-Key.
+// Keys are reserved for IdempotencyLockTTL and saved responses kept for ttl.
+type RedisIdempotencyStore struct {
+	client  RedisClient
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func NewRedisIdempotencyStore(rdb RedisClient, ttl time.Duration) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: rdb, ttl: ttl, lockTTL: IdempotencyLockTTL}
+}
+
+func (s *RedisIdempotencyStore) Reserve(key, fingerprint string) (*IdempotentResponse, error) {
+	pending, err := json.Marshal(IdempotentResponse{Fingerprint: fingerprint})
+	if err != nil {
+		return nil, err
+	}
+	// The key can expire between the two calls, so try again once before giving up
+	for attempt := 0; attempt < 2; attempt++ {
+		claimed, err := s.client.SetNX(s.redisKey(key), pending, s.lockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if claimed {
+			return nil, nil
+		}
+
+		stored, err := s.client.Get(s.redisKey(key)).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var response IdempotentResponse
+		if err := json.Unmarshal(stored, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}
+	return nil, fmt.Errorf("idempotency key %s could not be reserved", key)
+}
+
+func (s *RedisIdempotencyStore) Save(key string, response *IdempotentResponse) error {
+	stored, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(s.redisKey(key), stored, s.ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(key string) error {
+	return s.client.Del(s.redisKey(key)).Err()
+}
+
+func (s *RedisIdempotencyStore) redisKey(key string) string {
+	return "idempotency:" + key
+}
+
+// idempotent makes a create endpoint safe to retry. The first response to a request carrying an
+// Idempotency-Key is stored and replayed for later requests with the same key and body; reusing the
+// key for a different request is rejected. Server errors are not stored, so such requests can be retried.
+// Without a configured store, or when the store fails, requests are processed as usual.
+func (rt *Router) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" || rt.idempotencyStore == nil {
+			next(w, r)
+			return
+		}
+		if !idempotencyKeyPattern.MatchString(key) {
+			writeIdempotencyError(w, http.StatusBadRequest, "invalid Idempotency-Key")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			writeIdempotencyError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the caller so users cannot see each other's responses
+		userID, _ := r.Context().Value("userID").(int)
+		key = fmt.Sprintf("%d:%s", userID, key)
+		fingerprint := requestFingerprint(r, body)
+
+		stored, err := rt.idempotencyStore.Reserve(key, fingerprint)
+		if err != nil {
+			slog.Warn("idempotency store unavailable, processing request without it", "error", err)
+			next(w, r)
+			return
+		}
+		if stored != nil {
+			switch {
+			case stored.Fingerprint != fingerprint:
+				writeIdempotencyError(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case stored.Status == 0:
+				writeIdempotencyError(w, http.StatusConflict, "a request with this Idempotency-Key is still being processed")
+			default:
+				replay(w, stored)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		finished := false
+		defer func() {
+			// next panicked; free the key rather than leave the request looking in progress
+			if !finished {
+				if err := rt.idempotencyStore.Release(key); err != nil {
+					slog.Error("failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+		next(recorder, r)
+		finished = true
+
+		if recorder.status >= http.StatusInternalServerError {
+			if err := rt.idempotencyStore.Release(key); err != nil {
+				slog.Error("failed to release idempotency key", "error", err)
+			}
+			return
+		}
+		response := &IdempotentResponse{
+			Fingerprint: fingerprint,
+			Status:      recorder.status,
+			Header:      map[string]string{},
+			Body:        recorder.body.Bytes(),
+		}
+		for _, name := range []string{"Content-Type", "Location", "ETag"} {
+			if value := w.Header().Get(name); value != "" {
+				response.Header[name] = value
+			}
+		}
+		if err := rt.idempotencyStore.Save(key, response); err != nil {
+			slog.Error("failed to store idempotent response", "error", err)
+		}
+	}
+}
+
+// requestFingerprint identifies a request by its method, path and body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.Path)
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay writes a stored response again, marked so clients can tell it was not processed twice
+func replay(w http.ResponseWriter, stored *IdempotentResponse) {
+	for name, value := range stored.Header {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(stored.Status)
+	w.Write(stored.Body)
+}
+
+func writeIdempotencyError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,185 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// memoryIdempotencyStore keeps idempotent responses in a map for tests
+type memoryIdempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*IdempotentResponse
+}
+
+func (s *memoryIdempotencyStore) Reserve(key, fingerprint string) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if stored, ok := s.responses[key]; ok {
+		return stored, nil
+	}
+	s.responses[key] = &IdempotentResponse{Fingerprint: fingerprint}
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Save(key string, response *IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[key] = response
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.responses, key)
+	return nil
+}
+
+func TestIdempotentCreate(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	store := &memoryIdempotencyStore{responses: map[string]*IdempotentResponse{}}
+	r := NewRouter(todoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{},
+		WithIdempotencyStore(store))
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	create := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/todos", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestCreateToDo_EchoesTodo", func(t *testing.T) {
+		todoSvc.On("AddToDo", mock.Anything, mock.AnythingOfType("*entity.ToDo")).
+			Run(func(args mock.Arguments) {
+				todo := args.Get(1).(*entity.ToDo)
+				todo.ToDoID, todo.Version = 7, 1
+			}).Return(nil).Once()
+
+		rr := create("", `{"title": "Milk"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, "/todos/7", rr.Header().Get("Location"))
+
+		var todo entity.ToDo
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &todo))
+		assert.Equal(t, 7, todo.ToDoID)
+	})
+
+	t.Run("TestCreateToDo_RetryReplaysFirstResponse", func(t *testing.T) {
+		todoSvc.On("AddToDo", mock.Anything, mock.AnythingOfType("*entity.ToDo")).
+			Run(func(args mock.Arguments) { args.Get(1).(*entity.ToDo).ToDoID = 8 }).Return(nil).Once()
+
+		first := create("retry-1", `{"title": "Bread"}`)
+		second := create("retry-1", `{"title": "Bread"}`)
+
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "/todos/8", second.Header().Get("Location"))
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+		todoSvc.AssertNumberOfCalls(t, "AddToDo", 2) // once per subtest so far
+	})
+
+	t.Run("TestCreateToDo_KeyReusedWithDifferentBody", func(t *testing.T) {
+		rr := create("retry-1", `{"title": "Butter"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	})
+
+	t.Run("TestCreateToDo_KeyStillProcessing", func(t *testing.T) {
+		store.Reserve("1:busy", requestFingerprint(httptest.NewRequest("POST", "/todos", nil), []byte(`{"title": "Jam"}`)))
+
+		rr := create("busy", `{"title": "Jam"}`)
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("TestCreateToDo_ServerErrorNotStored", func(t *testing.T) {
+		todoSvc.On("AddToDo", mock.Anything, mock.AnythingOfType("*entity.ToDo")).Return(assert.AnError).Once()
+
+		rr := create("flaky", `{"title": "Eggs"}`)
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		_, kept := store.responses["1:flaky"]
+		assert.False(t, kept)
+	})
+
+	t.Run("TestCreateToDo_PanicReleasesKey", func(t *testing.T) {
+		todoSvc.On("AddToDo", mock.Anything, mock.AnythingOfType("*entity.ToDo")).
+			Run(func(mock.Arguments) { panic("boom") }).Return(nil).Once()
+
+		assert.Panics(t, func() { create("crash", `{"title": "Tea"}`) })
+		_, kept := store.responses["1:crash"]
+		assert.False(t, kept)
+	})
+
+	t.Run("TestCreateToDo_InvalidKey", func(t *testing.T) {
+		rr := create("not a key", `{"title": "Eggs"}`)
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestRedisIdempotencyStore(t *testing.T) {
+	t.Run("TestReserve_ReturnsStoredResponse", func(t *testing.T) {
+		mockRedis := &mocks.MockRedisClient{}
+		store := NewRedisIdempotencyStore(mockRedis, IdempotencyKeyTTL)
+		stored, _ := json.Marshal(IdempotentResponse{Fingerprint: "abc", Status: http.StatusCreated, Body: []byte(`{"id":1}`)})
+
+		mockRedis.On("SetNX", "idempotency:1:key", mock.Anything, IdempotencyLockTTL).Return(redis.NewBoolResult(false, nil))
+		mockRedis.On("Get", "idempotency:1:key").Return(redis.NewStringResult(string(stored), nil))
+
+		response, err := store.Reserve("1:key", "abc")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, response.Status)
+		assert.Equal(t, `{"id":1}`, string(response.Body))
+	})
+
+	t.Run("TestReserve_ClaimsNewKey", func(t *testing.T) {
+		mockRedis := &mocks.MockRedisClient{}
+		store := NewRedisIdempotencyStore(mockRedis, IdempotencyKeyTTL)
+
+		mockRedis.On("SetNX", "idempotency:1:new", mock.Anything, IdempotencyLockTTL).Return(redis.NewBoolResult(true, nil))
+
+		response, err := store.Reserve("1:new", "abc")
+		assert.NoError(t, err)
+		assert.Nil(t, response)
+	})
+
+	t.Run("TestSave_KeepsResponseForTTL", func(t *testing.T) {
+		mockRedis := &mocks.MockRedisClient{}
+		store := NewRedisIdempotencyStore(mockRedis, IdempotencyKeyTTL)
+
+		mockRedis.On("Set", "idempotency:1:key", mock.Anything, IdempotencyKeyTTL).Return(redis.NewStatusResult("OK", nil))
+
+		assert.NoError(t, store.Save("1:key", &IdempotentResponse{Fingerprint: "abc", Status: http.StatusCreated}))
+		mockRedis.AssertExpectations(t)
+	})
+}
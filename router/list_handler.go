@@ -0,0 +1,289 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/service"
+	"github.com/srikanthbhandary/todo-server/worker"
+)
+
+func (rt *Router) CreateList(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name string `json:"name"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.listsEnabled(w) {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	list, err := rt.listSvc.CreateList(r.Context(), userID, request.Name)
+	if err != nil {
+		writeListError(w, err, "failed to create list")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(list)
+}
+
+func (rt *Router) GetLists(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.listsEnabled(w) {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	lists, err := rt.listSvc.GetLists(r.Context(), userID)
+	if err != nil {
+		writeListError(w, err, "failed to retrieve lists")
+		return
+	}
+
+	json.NewEncoder(w).Encode(lists)
+}
+
+func (rt *Router) GetList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	listID, ok := rt.listPathID(w, r, "listID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	list, members, err := rt.listSvc.GetList(r.Context(), userID, listID)
+	if err != nil {
+		writeListError(w, err, "failed to retrieve list")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{"list": list, "members": members})
+}
+
+func (rt *Router) DeleteList(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	listID, ok := rt.listPathID(w, r, "listID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	if err := rt.listSvc.DeleteList(r.Context(), userID, listID); err != nil {
+		writeListError(w, err, "failed to delete list")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rt *Router) GetListTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	listID, ok := rt.listPathID(w, r, "listID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	todos, err := rt.listSvc.GetListTodos(r.Context(), userID, listID)
+	if err != nil {
+		writeListError(w, err, "failed to retrieve todos")
+		return
+	}
+
+	json.NewEncoder(w).Encode(todos)
+}
+
+func (rt *Router) InviteListMember(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	listID, ok := rt.listPathID(w, r, "listID")
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.Email == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "email is required"})
+		return
+	}
+	if request.Role == "" {
+		request.Role = entity.ListRoleEditor
+	}
+
+	userID := r.Context().Value("userID").(int)
+	invitation, err := rt.listSvc.InviteMember(r.Context(), userID, listID, request.Email, request.Role)
+	if err != nil {
+		writeListError(w, err, "failed to invite member")
+		return
+	}
+	rt.sendInvitationEmail(r, userID, invitation)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(invitation)
+}
+
+func (rt *Router) SetListMemberRole(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Role string `json:"role"`
+	}
+	w.Header().Set("Content-Type", "application/json")
+	listID, ok := rt.listPathID(w, r, "listID")
+	if !ok {
+		return
+	}
+	memberID, ok := rt.listPathID(w, r, "userID")
+	if !ok {
+		return
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	if err := rt.listSvc.SetMemberRole(r.Context(), userID, listID, memberID, request.Role); err != nil {
+		writeListError(w, err, "failed to change member role")
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "Member role updated"})
+}
+
+func (rt *Router) RemoveListMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	listID, ok := rt.listPathID(w, r, "listID")
+	if !ok {
+		return
+	}
+	memberID, ok := rt.listPathID(w, r, "userID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	if err := rt.listSvc.RemoveMember(r.Context(), userID, listID, memberID); err != nil {
+		writeListError(w, err, "failed to remove member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rt *Router) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !rt.listsEnabled(w) {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	invitations, err := rt.listSvc.GetInvitations(r.Context(), userID)
+	if err != nil {
+		writeListError(w, err, "failed to retrieve invitations")
+		return
+	}
+
+	json.NewEncoder(w).Encode(invitations)
+}
+
+func (rt *Router) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	rt.respondToInvitation(w, r, true)
+}
+
+func (rt *Router) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	rt.respondToInvitation(w, r, false)
+}
+
+// respondToInvitation accepts or declines the invitation named in the path
+func (rt *Router) respondToInvitation(w http.ResponseWriter, r *http.Request, accept bool) {
+	w.Header().Set("Content-Type", "application/json")
+	invitationID, ok := rt.listPathID(w, r, "invitationID")
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	invitation, err := rt.listSvc.RespondToInvitation(r.Context(), userID, invitationID, accept)
+	if err != nil {
+		writeListError(w, err, "failed to respond to invitation")
+		return
+	}
+
+	json.NewEncoder(w).Encode(invitation)
+}
+
+// sendInvitationEmail queues the invitation email; failures to look up the inviter only change the wording
+func (rt *Router) sendInvitationEmail(r *http.Request, inviterID int, invitation *entity.ListInvitation) {
+	inviter := "Someone"
+	if user, err := rt.userService.GetUserByID(r.Context(), inviterID); err == nil {
+		inviter = user.UserName
+	} else {
+		slog.Warn("inviter not found", "user_id", inviterID, "error", err)
+	}
+
+	body := fmt.Sprintf("%s invited you to the todo list %q as %s.\n\n"+
+		"Sign in with this email address and accept or decline the invitation at %s.",
+		inviter, invitation.ListName, invitation.Role, rt.publicURL(r, "/invitations"))
+	rt.WorkerPool.EnqueueJob(worker.NewEmailJob(rt.EmailSender, []string{invitation.Email},
+		"You have been invited to a todo list", body))
+}
+
+// listPathID parses an ID from the path, writing a 400 response when it is malformed
+func (rt *Router) listPathID(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	if !rt.listsEnabled(w) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid " + name})
+		return 0, false
+	}
+	return id, true
+}
+
+// listsEnabled writes a 501 response when no ListService has been configured
+func (rt *Router) listsEnabled(w http.ResponseWriter) bool {
+	if rt.listSvc != nil {
+		return true
+	}
+	w.WriteHeader(http.StatusNotImplemented)
+	json.NewEncoder(w).Encode(map[string]string{"error": "shared lists are not enabled"})
+	return false
+}
+
+// writeListError maps list errors onto HTTP status codes
+func writeListError(w http.ResponseWriter, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrInvalidListRole), errors.Is(err, service.ErrInvalidListName):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrListPermission), errors.Is(err, service.ErrPersonalList):
+		status = http.StatusForbidden
+	case errors.Is(err, service.ErrLastOwner):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrListNotFound), errors.Is(err, service.ErrInvitationNotFound),
+		errors.Is(err, repository.ErrNotFound):
+		status = http.StatusNotFound
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "message": err.Error()})
+}
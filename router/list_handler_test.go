@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/service"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListHandlers(t *testing.T) {
+	mockUserSvc := new(mocks.MockUserService)
+	listSvc := new(mocks.MockListService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	emailSender := newChannelEmailSender()
+	r := NewRouter(new(mocks.MockToDoService), mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, emailSender,
+		WithListService(listSvc))
+	r.InitRoutes()
+
+	user := &entity.User{UserID: 1, UserName: "owner", Role: entity.RoleUser}
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(user, nil)
+	mockUserSvc.On("GetUserByID", mock.Anything, 1).Return(user, nil)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestCreateList_SUCCESS", func(t *testing.T) {
+		list := &entity.TodoList{ListID: 5, Name: "Release checklist", OwnerID: 1, Role: entity.ListRoleOwner}
+		listSvc.On("CreateList", mock.Anything, 1, "Release checklist").Return(list, nil).Once()
+
+		rr := serve("POST", "/lists", `{"name": "Release checklist"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"role":"owner"`)
+	})
+
+	t.Run("TestGetList_NotMember", func(t *testing.T) {
+		listSvc.On("GetList", mock.Anything, 1, 9).Return(nil, nil, service.ErrListNotFound).Once()
+
+		rr := serve("GET", "/lists/9", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("TestInviteMember_SendsEmail", func(t *testing.T) {
+		invitation := &entity.ListInvitation{InvitationID: 3, ListID: 5, ListName: "Release checklist",
+			Email: "friend@example.com", Role: entity.ListRoleViewer, Status: entity.InvitationPending}
+		listSvc.On("InviteMember", mock.Anything, 1, 5, "friend@example.com", entity.ListRoleViewer).Return(invitation, nil).Once()
+
+		rr := serve("POST", "/lists/5/invitations", `{"email": "friend@example.com", "role": "viewer"}`)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+
+		email := emailSender.next(t)
+		assert.Contains(t, email, "To: friend@example.com")
+		assert.Contains(t, email, "Release checklist")
+	})
+
+	t.Run("TestInviteMember_NotOwner", func(t *testing.T) {
+		listSvc.On("InviteMember", mock.Anything, 1, 6, "friend@example.com", entity.ListRoleEditor).
+			Return(nil, service.ErrListPermission).Once()
+
+		rr := serve("POST", "/lists/6/invitations", `{"email": "friend@example.com"}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("TestRemoveMember_LastOwner", func(t *testing.T) {
+		listSvc.On("RemoveMember", mock.Anything, 1, 5, 1).Return(service.ErrLastOwner).Once()
+
+		rr := serve("DELETE", "/lists/5/members/1", "")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
+	t.Run("TestAcceptInvitation_SUCCESS", func(t *testing.T) {
+		invitation := &entity.ListInvitation{InvitationID: 3, ListID: 5, Status: entity.InvitationAccepted}
+		listSvc.On("RespondToInvitation", mock.Anything, 1, 3, true).Return(invitation, nil).Once()
+
+		rr := serve("POST", "/invitations/3/accept", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"status":"accepted"`)
+	})
+
+	listSvc.AssertExpectations(t)
+}
@@ -0,0 +1,143 @@
+package router
+
+import (
+	"fmt"
+	"time"
+)
+
+// LoginThrottler tracks failed logins and decides when further attempts must wait.
+type LoginThrottler interface {
+	// Blocked returns how long the caller must wait before another attempt is allowed.
+	Blocked(username, ip string) (time.Duration, error)
+	// RecordFailure counts a failed attempt and reports whether the username was just locked out.
+	RecordFailure(username, ip string) (bool, error)
+	// RecordSuccess clears the failure history of the username.
+	RecordSuccess(username string) error
+}
+
+// LoginPolicy describes how failed logins against a single key are throttled.
+type LoginPolicy struct {
+	FreeAttempts    int           // failures allowed before any delay is imposed
+	MaxAttempts     int           // failures that trigger a lockout
+	BaseDelay       time.Duration // delay after the first throttled failure, doubled for each one after
+	MaxDelay        time.Duration // upper bound for the progressive delay
+	LockoutDuration time.Duration // how long a lockout lasts
+	Window          time.Duration // how long failures are remembered
+}
+
+// DefaultUserLoginPolicy throttles guessing against a single account.
+var DefaultUserLoginPolicy = LoginPolicy{
+	FreeAttempts:    3,
+	MaxAttempts:     10,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutDuration: 15 * time.Minute,
+	Window:          15 * time.Minute,
+}
+
+// DefaultIPLoginPolicy throttles a single client spraying many accounts.
+var DefaultIPLoginPolicy = LoginPolicy{
+	FreeAttempts:    10,
+	MaxAttempts:     50,
+	BaseDelay:       time.Second,
+	MaxDelay:        time.Minute,
+	LockoutDuration: time.Hour,
+	Window:          time.Hour,
+}
+
+// delayAfter returns the wait imposed after the given number of failures
+func (p LoginPolicy) delayAfter(failures int) time.Duration {
+	if failures >= p.MaxAttempts {
+		return p.LockoutDuration
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// RedisLoginThrottler implements LoginThrottler with counters and block keys in Redis
+type RedisLoginThrottler struct {
+	client     RedisClient
+	userPolicy LoginPolicy
+	ipPolicy   LoginPolicy
+}
+
+func NewRedisLoginThrottler(rdb RedisClient, userPolicy, ipPolicy LoginPolicy) *RedisLoginThrottler {
+	return &RedisLoginThrottler{
+		client:     rdb,
+		userPolicy: userPolicy,
+		ipPolicy:   ipPolicy,
+	}
+}
+
+func (lt *RedisLoginThrottler) Blocked(username, ip string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range []string{blockKey("user", username), blockKey("ip", ip)} {
+		ttl, err := lt.client.PTTL(key).Result()
+		if err != nil {
+			return 0, err
+		}
+		// PTTL reports negative values for keys that do not exist
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait, nil
+}
+
+func (lt *RedisLoginThrottler) RecordFailure(username, ip string) (bool, error) {
+	lockedOut, err := lt.recordFailure("user", username, lt.userPolicy)
+	if err != nil {
+		return false, err
+	}
+	if _, err := lt.recordFailure("ip", ip, lt.ipPolicy); err != nil {
+		return false, err
+	}
+	return lockedOut, nil
+}
+
+func (lt *RedisLoginThrottler) RecordSuccess(username string) error {
+	return lt.client.Del(failureKey("user", username), blockKey("user", username)).Err()
+}
+
+// recordFailure counts a failure for one key and applies the resulting delay or lockout
+func (lt *RedisLoginThrottler) recordFailure(kind, id string, policy LoginPolicy) (bool, error) {
+	key := failureKey(kind, id)
+	count, err := lt.client.Incr(key).Result()
+	if err != nil {
+		return false, err
+	}
+	if count == 1 {
+		lt.client.Expire(key, policy.Window)
+	}
+
+	delay := policy.delayAfter(int(count))
+	if delay == 0 {
+		return false, nil
+	}
+	if err := lt.client.Set(blockKey(kind, id), 1, delay).Err(); err != nil {
+		return false, err
+	}
+
+	if int(count) >= policy.MaxAttempts {
+		// Start counting afresh once the lockout has been served
+		return true, lt.client.Del(key).Err()
+	}
+	return false, nil
+}
+
+func failureKey(kind, id string) string {
+	return fmt.Sprintf("login_failures:%s:%s", kind, id)
+}
+
+func blockKey(kind, id string) string {
+	return fmt.Sprintf("login_blocked:%s:%s", kind, id)
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginPolicyDelay(t *testing.T) {
+	policy := DefaultUserLoginPolicy
+
+	tests := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{6, 4 * time.Second},
+		{9, 32 * time.Second},
+		{10, policy.LockoutDuration},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, policy.delayAfter(tt.failures), "failures: %d", tt.failures)
+	}
+
+	policy.MaxAttempts = 20
+	assert.Equal(t, policy.MaxDelay, policy.delayAfter(15))
+}
+
+func TestRedisLoginThrottler(t *testing.T) {
+	t.Run("TestRecordFailure_Lockout", func(t *testing.T) {
+		mockRedis := &mocks.MockRedisClient{}
+		policy := LoginPolicy{FreeAttempts: 1, MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: time.Minute,
+			LockoutDuration: time.Hour, Window: time.Hour}
+		throttler := NewRedisLoginThrottler(mockRedis, policy, DefaultIPLoginPolicy)
+
+		mockRedis.On("Incr", "login_failures:user:alice").Return(redis.NewIntResult(3, nil))
+		mockRedis.On("Incr", "login_failures:ip:10.0.0.1").Return(redis.NewIntResult(2, nil))
+		mockRedis.On("Set", "login_blocked:user:alice", 1, time.Hour).Return(redis.NewStatusResult("OK", nil))
+		mockRedis.On("Del", []string{"login_failures:user:alice"}).Return(redis.NewIntResult(1, nil))
+
+		lockedOut, err := throttler.RecordFailure("alice", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.True(t, lockedOut)
+		mockRedis.AssertExpectations(t)
+	})
+
+	t.Run("TestBlocked_LongestWait", func(t *testing.T) {
+		mockRedis := &mocks.MockRedisClient{}
+		throttler := NewRedisLoginThrottler(mockRedis, DefaultUserLoginPolicy, DefaultIPLoginPolicy)
+
+		// PTTL reports -2ms for a key that does not exist
+		mockRedis.On("PTTL", "login_blocked:user:alice").Return(redis.NewDurationResult(-2*time.Millisecond, nil))
+		mockRedis.On("PTTL", "login_blocked:ip:10.0.0.1").Return(redis.NewDurationResult(5*time.Second, nil))
+
+		wait, err := throttler.Blocked("alice", "10.0.0.1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5*time.Second, wait)
+	})
+}
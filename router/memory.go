@@ -0,0 +1,138 @@
+package router
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryRateLimiter is a RateLimiter that counts requests in memory, for tests and dev mode.
+// Like RedisRateLimiter it allows limit requests per user in a fixed window that starts
+// with the user's first request. It is safe for concurrent use.
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	resetTime time.Duration
+	windows   map[string]*rateWindow
+	now       func() time.Time
+}
+
+type rateWindow struct {
+	count   int
+	expires time.Time
+}
+
+func NewMemoryRateLimiter(limit int, resetTime time.Duration) *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		limit:     limit,
+		resetTime: resetTime,
+		windows:   map[string]*rateWindow{},
+		now:       time.Now,
+	}
+}
+
+func (rl *MemoryRateLimiter) AllowRequest(userID string) (bool, error) {
+	return rl.AllowRequests(userID, 1)
+}
+
+func (rl *MemoryRateLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := rl.Consume(userID, n)
+	return status.Allowed, err
+}
+
+// Consume counts n requests in the user's current window
+func (rl *MemoryRateLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	window, ok := rl.windows[userID]
+	if !ok || !now.Before(window.expires) {
+		// Drop expired windows as new ones open, so idle users do not pile up
+		for id, w := range rl.windows {
+			if !now.Before(w.expires) {
+				delete(rl.windows, id)
+			}
+		}
+		window = &rateWindow{expires: now.Add(rl.resetTime)}
+		rl.windows[userID] = window
+	}
+
+	window.count += n
+	return fixedWindowStatus(rl.limit, window.count, window.expires.Sub(now)), nil
+}
+
+// MemoryTokenBucketLimiter gives each user a bucket of limit tokens in memory that refills evenly
+// over the period, like RedisTokenBucketLimiter. It is safe for concurrent use.
+type MemoryTokenBucketLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	period  time.Duration
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemoryTokenBucketLimiter(limit int, period time.Duration) *MemoryTokenBucketLimiter {
+	return &MemoryTokenBucketLimiter{
+		limit:   limit,
+		period:  period,
+		buckets: map[string]*tokenBucket{},
+		now:     time.Now,
+	}
+}
+
+func (rl *MemoryTokenBucketLimiter) AllowRequest(userID string) (bool, error) {
+	return rl.AllowRequests(userID, 1)
+}
+
+func (rl *MemoryTokenBucketLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := rl.Consume(userID, n)
+	return status.Allowed, err
+}
+
+// Consume takes n tokens from the user's bucket if it holds that many
+func (rl *MemoryTokenBucketLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	now := rl.now()
+	bucket, ok := rl.buckets[userID]
+	if !ok {
+		// A full bucket is the same as none, so drop those as new ones are made
+		for id, b := range rl.buckets {
+			if rl.refill(b, now) >= float64(rl.limit) {
+				delete(rl.buckets, id)
+			}
+		}
+		bucket = &tokenBucket{tokens: float64(rl.limit), updated: now}
+		rl.buckets[userID] = bucket
+	}
+	bucket.tokens, bucket.updated = rl.refill(bucket, now), now
+
+	status := RateLimitStatus{Limit: rl.limit}
+	if bucket.tokens >= float64(n) {
+		bucket.tokens -= float64(n)
+		status.Allowed = true
+	} else if n > rl.limit {
+		status.RetryAfter = rl.period
+	} else {
+		status.RetryAfter = rl.timeFor(float64(n) - bucket.tokens)
+	}
+	status.Remaining = int(bucket.tokens)
+	status.Reset = rl.timeFor(float64(rl.limit) - bucket.tokens)
+	return status, nil
+}
+
+// refill returns the tokens in the bucket at now
+func (rl *MemoryTokenBucketLimiter) refill(b *tokenBucket, now time.Time) float64 {
+	return min(float64(rl.limit), b.tokens+float64(now.Sub(b.updated))*float64(rl.limit)/float64(rl.period))
+}
+
+// timeFor returns how long the bucket takes to refill the given number of tokens
+func (rl *MemoryTokenBucketLimiter) timeFor(tokens float64) time.Duration {
+	return time.Duration(tokens * float64(rl.period) / float64(rl.limit))
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryRateLimiter(t *testing.T) {
+	now := time.Now()
+	rl := NewMemoryRateLimiter(3, 10*time.Second)
+	rl.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, err := rl.AllowRequest("1")
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+	}
+	allowed, _ := rl.AllowRequest("1")
+	assert.False(t, allowed, "the fourth request in the window is denied")
+
+	allowed, _ = rl.AllowRequest("2")
+	assert.True(t, allowed, "every user has their own window")
+
+	now = now.Add(10 * time.Second)
+	allowed, _ = rl.AllowRequests("1", 3)
+	assert.True(t, allowed, "a new window opens once the old one expires")
+	allowed, _ = rl.AllowRequests("1", 1)
+	assert.False(t, allowed, "weighted requests count n times")
+
+	now = now.Add(4 * time.Second)
+	status, err := rl.Consume("1", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimitStatus{Limit: 3, Reset: 6 * time.Second, RetryAfter: 6 * time.Second}, status)
+}
+
+func TestMemoryTokenBucketLimiter(t *testing.T) {
+	now := time.Now()
+	rl := NewMemoryTokenBucketLimiter(4, 4*time.Second)
+	rl.now = func() time.Time { return now }
+
+	status, err := rl.Consume("1", 4)
+	assert.NoError(t, err)
+	assert.True(t, status.Allowed, "a full bucket allows a burst of the whole limit")
+	assert.Equal(t, 4*time.Second, status.Reset)
+
+	status, _ = rl.Consume("1", 1)
+	assert.False(t, status.Allowed)
+	assert.Equal(t, time.Second, status.RetryAfter, "one token refills per second")
+
+	allowed, _ := rl.AllowRequest("2")
+	assert.True(t, allowed, "every user has their own bucket")
+
+	now = now.Add(2500 * time.Millisecond)
+	status, _ = rl.Consume("1", 2)
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 0, status.Remaining, "half a token is left")
+
+	now = now.Add(time.Minute)
+	status, _ = rl.Consume("1", 1)
+	assert.True(t, status.Allowed)
+	assert.Equal(t, 3, status.Remaining, "the bucket never holds more than its limit")
+
+	status, _ = rl.Consume("1", 5)
+	assert.False(t, status.Allowed)
+	assert.Equal(t, 4*time.Second, status.RetryAfter, "requests heavier than the limit never fit")
+}
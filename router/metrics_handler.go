@@ -0,0 +1,22 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// MetricsSource reports the current values of a component's counters and gauges
+type MetricsSource interface {
+	Metrics() map[string]float64
+}
+
+// AdminGetMetrics returns the metrics of every registered source, keyed by source name
+func (rt *Router) AdminGetMetrics(w http.ResponseWriter, r *http.Request) {
+	metrics := make(map[string]map[string]float64, len(rt.metrics))
+	for name, source := range rt.metrics {
+		metrics[name] = source.Metrics()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
@@ -0,0 +1,51 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type stubMetrics map[string]float64
+
+func (m stubMetrics) Metrics() map[string]float64 {
+	return m
+}
+
+func TestAdminGetMetrics(t *testing.T) {
+	pool := worker.NewWorkerPool(1, make(chan worker.Job, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	serve := func(role string) *httptest.ResponseRecorder {
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: role}, nil)
+		r := NewRouter(new(mocks.MockToDoService), userSvc, new(mocks.MockJWTValidator), nil, pool, &mocks.MockEmailSender{},
+			WithMetrics("todo_cache", stubMetrics{"hits": 3, "misses": 1, "hit_ratio": 0.75}))
+		r.InitRoutes()
+
+		req := httptest.NewRequest("GET", "/admin/metrics", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestAdminGetMetrics_Forbidden", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, serve(entity.RoleUser).Code)
+	})
+
+	t.Run("TestAdminGetMetrics_SUCCESS", func(t *testing.T) {
+		rr := serve(entity.RoleAdmin)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"todo_cache":{"hits":3,"misses":1,"hit_ratio":0.75}}`, rr.Body.String())
+	})
+}
@@ -1,8 +1,12 @@
 package router
 
 import (
-	"log"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
 	"net/http"
+	"regexp"
 	"time"
 )
 
@@ -10,6 +14,28 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 		next.ServeHTTP(w, r) // Call the next handler in the chain
-		log.Printf("Host: %s URL: %s Method %s Time: %v", r.RemoteAddr, r.URL, r.Method, time.Since(start))
+		slog.Info("request", "host", r.RemoteAddr, "url", r.URL.String(), "method", r.Method, "time", time.Since(start))
+	})
+}
+
+// requestIDPattern limits the request IDs accepted from clients to something safe to log and store
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestContextMiddleware stores the client IP and a request ID in the request context.
+// The request ID is taken from the X-Request-ID header when it looks sane, generated otherwise,
+// and echoed back so clients can quote it.
+func RequestContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !requestIDPattern.MatchString(requestID) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			requestID = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		ctx := context.WithValue(r.Context(), "requestID", requestID)
+		ctx = context.WithValue(ctx, "clientIP", clientIP(r))
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
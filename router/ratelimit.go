@@ -0,0 +1,205 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/srikanthbhandary/todo-server/config"
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// Route groups that can be given their own rate limits
+const (
+	RateLimitGroupTodos = "todos"
+	RateLimitGroupLists = "lists"
+	RateLimitGroupBatch = "batch"
+)
+
+// Rate limiting algorithms
+const (
+	FixedWindow   = "fixed_window"
+	SlidingWindow = "sliding_window"
+	TokenBucket   = "token_bucket"
+)
+
+// RateLimitStatus is what a limiter decided about a request and what is left of the key's quota
+type RateLimitStatus struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is how long until the whole quota is available again
+	Reset time.Duration
+	// RetryAfter is how long until a denied request would be allowed
+	RetryAfter time.Duration
+}
+
+// QuotaLimiter is a RateLimiter that also reports the rest of the quota, for the RateLimit headers
+type QuotaLimiter interface {
+	RateLimiter
+	Consume(userID string, n int) (RateLimitStatus, error)
+}
+
+// fixedWindowStatus describes a window of the given limit after count requests, reset from now
+func fixedWindowStatus(limit, count int, reset time.Duration) RateLimitStatus {
+	status := RateLimitStatus{Allowed: count <= limit, Limit: limit, Remaining: max(limit-count, 0), Reset: reset}
+	if !status.Allowed {
+		status.RetryAfter = reset
+	}
+	return status
+}
+
+// RateLimits holds a limiter for every configured rule and picks the most specific one for a request.
+// It is safe for concurrent use, and its rules can be replaced while requests are counted.
+type RateLimits struct {
+	mu         sync.RWMutex
+	limiters   map[rateLimitScope]RateLimiter
+	newLimiter func(name string, limit int, period time.Duration) (RateLimiter, error)
+}
+
+// rateLimitScope is the route group and user tier a rule applies to; empty matches all
+type rateLimitScope struct {
+	group, tier string
+}
+
+func (s rateLimitScope) name() string {
+	orAll := func(v string) string {
+		if v == "" {
+			return "all"
+		}
+		return v
+	}
+	return orAll(s.group) + ":" + orAll(s.tier)
+}
+
+// NewRateLimits creates a limiter for every rule with newLimiter. Each rule counts requests
+// separately, under a name newLimiter is given to keep its keys apart from the other rules'.
+func NewRateLimits(rules []config.RateLimitRule, newLimiter func(name string, limit int, period time.Duration) (RateLimiter, error)) (*RateLimits, error) {
+	limits := &RateLimits{newLimiter: newLimiter}
+	if err := limits.Replace(rules); err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// Replace swaps in limiters for the rules, made with the limits' newLimiter. The limiters in use
+// are kept when the rules are invalid. In-memory limiters start counting afresh, while Redis ones
+// carry on with the counts kept under their names.
+func (l *RateLimits) Replace(rules []config.RateLimitRule) error {
+	limiters := map[rateLimitScope]RateLimiter{}
+	for _, rule := range rules {
+		scope := rateLimitScope{group: rule.Group, tier: rule.Tier}
+		switch {
+		case scope.group != "" && scope.group != RateLimitGroupTodos && scope.group != RateLimitGroupLists && scope.group != RateLimitGroupBatch:
+			return fmt.Errorf("rate limit %s: unknown route group %q", scope.name(), scope.group)
+		case scope.tier != "" && !entity.ValidRole(scope.tier):
+			return fmt.Errorf("rate limit %s: unknown tier %q", scope.name(), scope.tier)
+		case rule.Requests <= 0 || rule.PeriodSeconds <= 0:
+			return fmt.Errorf("rate limit %s: requests and period_seconds must be positive", scope.name())
+		}
+		if _, ok := limiters[scope]; ok {
+			return fmt.Errorf("rate limit %s is defined twice", scope.name())
+		}
+
+		limiter, err := l.newLimiter(scope.name(), rule.Requests, time.Duration(rule.PeriodSeconds)*time.Second)
+		if err != nil {
+			return err
+		}
+		limiters[scope] = limiter
+	}
+
+	l.mu.Lock()
+	l.limiters = limiters
+	l.mu.Unlock()
+	return nil
+}
+
+// Limiter returns the limiter of the most specific rule for the group and tier,
+// preferring a rule for the group to one for the tier, or nil when no rule matches
+func (l *RateLimits) Limiter(group, tier string) RateLimiter {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, scope := range []rateLimitScope{{group, tier}, {group, ""}, {"", tier}, {"", ""}} {
+		if limiter, ok := l.limiters[scope]; ok {
+			return limiter
+		}
+	}
+	return nil
+}
+
+// RateLimitMiddleware counts each request against the user's limit for the route group
+func (rt *Router) RateLimitMiddleware(group string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Retrieve user ID from context, set by the authentication middleware
+			userID, ok := r.Context().Value("userID").(int)
+			if !ok {
+				http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+				return
+			}
+			if !rt.allowRequests(w, r, group, userID, 1) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allowRequests counts a request weighing n against the user's limit for the group and sets the
+// RateLimit headers. When the request is denied or cannot be counted it answers it and returns false.
+func (rt *Router) allowRequests(w http.ResponseWriter, r *http.Request, group string, userID, n int) bool {
+	limiter := rt.rateLimiter
+	if rt.rateLimits != nil {
+		tier, _ := r.Context().Value("userRole").(string)
+		if matched := rt.rateLimits.Limiter(group, tier); matched != nil {
+			limiter = matched
+		}
+	}
+
+	var allowed bool
+	var err error
+	if quota, ok := limiter.(QuotaLimiter); ok {
+		var status RateLimitStatus
+		if status, err = quota.Consume(strconv.Itoa(userID), n); err == nil {
+			if status.Limit > 0 {
+				setRateLimitHeaders(w.Header(), status)
+			}
+			allowed = status.Allowed
+		}
+	} else {
+		allowed, err = limiter.AllowRequests(strconv.Itoa(userID), n)
+	}
+
+	if errors.Is(err, ErrRateLimitUnavailable) {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return false
+	}
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return false
+	}
+	if !allowed {
+		http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// setRateLimitHeaders reports the quota in the RateLimit headers, with durations in whole seconds
+func setRateLimitHeaders(h http.Header, status RateLimitStatus) {
+	h.Set("RateLimit-Limit", strconv.Itoa(status.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(status.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(status.Reset)))
+	if !status.Allowed {
+		h.Set("Retry-After", strconv.Itoa(max(ceilSeconds(status.RetryAfter), 1)))
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
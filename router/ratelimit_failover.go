@@ -0,0 +1,94 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// What to do with requests while the rate limiters cannot reach Redis
+const (
+	FailClosed = "fail_closed"
+	FailOpen   = "fail_open"
+	FailLocal  = "local"
+)
+
+// ErrRateLimitUnavailable is returned for requests that cannot be counted under the fail_closed policy
+var ErrRateLimitUnavailable = errors.New("rate limiting is unavailable")
+
+// LimiterFailover keeps requests flowing by its policy when the limiters it wraps fail. The limiters
+// share one circuit breaker, since they share the Redis client, so once it opens none of them call
+// Redis until the cooldown is over.
+type LimiterFailover struct {
+	policy  string
+	breaker *CircuitBreaker
+	// degraded counts the requests decided by the policy rather than by Redis
+	degraded atomic.Int64
+}
+
+// NewLimiterFailover creates a failover for the policy, which is fail_closed, fail_open or local
+func NewLimiterFailover(policy string, breaker *CircuitBreaker) (*LimiterFailover, error) {
+	switch policy {
+	case FailClosed, FailOpen, FailLocal:
+		return &LimiterFailover{policy: policy, breaker: breaker}, nil
+	}
+	return nil, fmt.Errorf("unknown rate limit failure policy %q", policy)
+}
+
+// Wrap returns a limiter that counts with limiter while it works. Under the local policy requests
+// are otherwise counted by an in-memory token bucket of limit tokens per period; being per server,
+// it allows each user limit requests on every server.
+func (f *LimiterFailover) Wrap(limiter QuotaLimiter, limit int, period time.Duration) QuotaLimiter {
+	wrapped := &failoverLimiter{failover: f, limiter: limiter}
+	if f.policy == FailLocal {
+		wrapped.fallback = NewMemoryTokenBucketLimiter(limit, period)
+	}
+	return wrapped
+}
+
+// Metrics reports the circuit breaker's metrics and how many requests the policy decided
+func (f *LimiterFailover) Metrics() map[string]float64 {
+	metrics := f.breaker.Metrics()
+	metrics["degraded_requests"] = float64(f.degraded.Load())
+	return metrics
+}
+
+type failoverLimiter struct {
+	failover *LimiterFailover
+	limiter  QuotaLimiter
+	fallback QuotaLimiter
+}
+
+func (l *failoverLimiter) AllowRequest(userID string) (bool, error) {
+	return l.AllowRequests(userID, 1)
+}
+
+func (l *failoverLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := l.Consume(userID, n)
+	return status.Allowed, err
+}
+
+// Consume counts n requests with the wrapped limiter, or by the policy when the breaker is open or
+// the limiter fails
+func (l *failoverLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	breaker := l.failover.breaker
+	if breaker.Allow() {
+		status, err := l.limiter.Consume(userID, n)
+		if err == nil {
+			breaker.Success()
+			return status, nil
+		}
+		breaker.Failure(err)
+	}
+
+	l.failover.degraded.Add(1)
+	switch l.failover.policy {
+	case FailOpen:
+		// There is no quota to report, so the RateLimit headers are left out
+		return RateLimitStatus{Allowed: true}, nil
+	case FailLocal:
+		return l.fallback.Consume(userID, n)
+	}
+	return RateLimitStatus{}, ErrRateLimitUnavailable
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// flakyLimiter fails while down and otherwise allows every request
+type flakyLimiter struct {
+	down  bool
+	calls int
+}
+
+func (l *flakyLimiter) AllowRequest(userID string) (bool, error) {
+	return l.AllowRequests(userID, 1)
+}
+
+func (l *flakyLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := l.Consume(userID, n)
+	return status.Allowed, err
+}
+
+func (l *flakyLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	l.calls++
+	if l.down {
+		return RateLimitStatus{}, errors.New("connection refused")
+	}
+	return RateLimitStatus{Allowed: true, Limit: 100, Remaining: 99, Reset: time.Second}, nil
+}
+
+func TestLimiterFailover(t *testing.T) {
+	newLimiter := func(t *testing.T, policy string) (QuotaLimiter, *flakyLimiter, *LimiterFailover) {
+		failover, err := NewLimiterFailover(policy, NewCircuitBreaker("test", 2, time.Minute))
+		assert.NoError(t, err)
+		redis := &flakyLimiter{down: true}
+		return failover.Wrap(redis, 2, time.Minute), redis, failover
+	}
+
+	t.Run("TestFailClosed", func(t *testing.T) {
+		limiter, _, _ := newLimiter(t, FailClosed)
+		_, err := limiter.Consume("1", 1)
+		assert.ErrorIs(t, err, ErrRateLimitUnavailable)
+	})
+
+	t.Run("TestFailOpen", func(t *testing.T) {
+		limiter, _, _ := newLimiter(t, FailOpen)
+		for i := 0; i < 5; i++ {
+			status, err := limiter.Consume("1", 1)
+			assert.NoError(t, err)
+			assert.Equal(t, RateLimitStatus{Allowed: true}, status)
+		}
+	})
+
+	t.Run("TestFailLocal", func(t *testing.T) {
+		limiter, _, _ := newLimiter(t, FailLocal)
+		for i := 0; i < 2; i++ {
+			status, err := limiter.Consume("1", 1)
+			assert.NoError(t, err)
+			assert.True(t, status.Allowed)
+		}
+		status, err := limiter.Consume("1", 1)
+		assert.NoError(t, err)
+		assert.False(t, status.Allowed, "the local bucket holds the rule's limit")
+	})
+
+	t.Run("TestBreakerStopsCalls", func(t *testing.T) {
+		limiter, redis, failover := newLimiter(t, FailOpen)
+		for i := 0; i < 5; i++ {
+			limiter.Consume("1", 1)
+		}
+		assert.Equal(t, 2, redis.calls, "Redis is left alone once the breaker opens")
+
+		failover.breaker.now = func() time.Time { return time.Now().Add(time.Minute) }
+		redis.down = false
+		status, _ := limiter.Consume("1", 1)
+		assert.Equal(t, 100, status.Limit, "Redis counts again once it recovers")
+		assert.Equal(t, map[string]float64{
+			"state": BreakerClosed, "consecutive_failures": 0, "opened": 1, "rejected": 3, "degraded_requests": 5,
+		}, failover.Metrics())
+	})
+
+	t.Run("TestNewLimiterFailover_UnknownPolicy", func(t *testing.T) {
+		_, err := NewLimiterFailover("retry", NewCircuitBreaker("test", 2, time.Minute))
+		assert.Error(t, err)
+	})
+}
+
+func TestRateLimitMiddleware_Unavailable(t *testing.T) {
+	pool := worker.NewWorkerPool(1, make(chan worker.Job, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	serve := func(policy string) *httptest.ResponseRecorder {
+		todoSvc := new(mocks.MockToDoService)
+		todoSvc.On("GetAllTodos", mock.Anything, 1).Return([]entity.ToDo{}, nil)
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+		failover, _ := NewLimiterFailover(policy, NewCircuitBreaker("test", 5, time.Minute))
+		limiter := failover.Wrap(&flakyLimiter{down: true}, 100, time.Minute)
+		r := NewRouter(todoSvc, userSvc, new(mocks.MockJWTValidator), limiter, pool, &mocks.MockEmailSender{})
+		r.InitRoutes()
+
+		req := httptest.NewRequest("GET", "/todos", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestFailClosed", func(t *testing.T) {
+		assert.Equal(t, http.StatusServiceUnavailable, serve(FailClosed).Code)
+	})
+
+	t.Run("TestFailOpen", func(t *testing.T) {
+		rr := serve(FailOpen)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"), "there is no quota to report")
+	})
+
+	t.Run("TestFailLocal", func(t *testing.T) {
+		rr := serve(FailLocal)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "99", rr.Header().Get("RateLimit-Remaining"))
+	})
+}
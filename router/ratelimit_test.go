@@ -0,0 +1,150 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/config"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newMemoryRateLimits(t *testing.T, rules []config.RateLimitRule) *RateLimits {
+	limits, err := NewRateLimits(rules, func(name string, limit int, period time.Duration) (RateLimiter, error) {
+		return NewMemoryRateLimiter(limit, period), nil
+	})
+	assert.NoError(t, err)
+	return limits
+}
+
+func TestNewRateLimits(t *testing.T) {
+	t.Run("TestLimiter_MostSpecificRule", func(t *testing.T) {
+		names := map[RateLimiter]string{}
+		limits, err := NewRateLimits([]config.RateLimitRule{
+			{Requests: 100, PeriodSeconds: 10},
+			{Group: RateLimitGroupBatch, Requests: 500, PeriodSeconds: 60},
+			{Tier: entity.RoleAdmin, Requests: 1000, PeriodSeconds: 10},
+			{Group: RateLimitGroupBatch, Tier: entity.RoleAdmin, Requests: 5000, PeriodSeconds: 60},
+		}, func(name string, limit int, period time.Duration) (RateLimiter, error) {
+			limiter := NewMemoryRateLimiter(limit, period)
+			names[limiter] = name
+			return limiter, nil
+		})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "all:all", names[limits.Limiter(RateLimitGroupTodos, entity.RoleUser)])
+		assert.Equal(t, "batch:all", names[limits.Limiter(RateLimitGroupBatch, entity.RoleUser)])
+		assert.Equal(t, "all:admin", names[limits.Limiter(RateLimitGroupLists, entity.RoleAdmin)])
+		assert.Equal(t, "batch:admin", names[limits.Limiter(RateLimitGroupBatch, entity.RoleAdmin)])
+	})
+
+	t.Run("TestLimiter_NoCatchAll", func(t *testing.T) {
+		limits := newMemoryRateLimits(t, []config.RateLimitRule{{Group: RateLimitGroupLists, Requests: 5, PeriodSeconds: 1}})
+		assert.Nil(t, limits.Limiter(RateLimitGroupTodos, entity.RoleUser))
+	})
+
+	t.Run("TestReplace", func(t *testing.T) {
+		limits := newMemoryRateLimits(t, []config.RateLimitRule{{Requests: 1, PeriodSeconds: 10}})
+		before := limits.Limiter(RateLimitGroupBatch, entity.RoleUser)
+
+		err := limits.Replace([]config.RateLimitRule{{Group: "todo", Requests: 1, PeriodSeconds: 10}})
+		assert.Error(t, err)
+		assert.Same(t, before, limits.Limiter(RateLimitGroupBatch, entity.RoleUser), "invalid rules leave the limiters alone")
+
+		err = limits.Replace([]config.RateLimitRule{{Requests: 1, PeriodSeconds: 10}, {Group: RateLimitGroupBatch, Requests: 3, PeriodSeconds: 10}})
+		assert.NoError(t, err)
+		allowed, _ := limits.Limiter(RateLimitGroupBatch, entity.RoleUser).AllowRequests("1", 3)
+		assert.True(t, allowed, "the new rule for batches is in force")
+	})
+
+	t.Run("TestNewRateLimits_Invalid", func(t *testing.T) {
+		newLimiter := func(name string, limit int, period time.Duration) (RateLimiter, error) {
+			return NewMemoryRateLimiter(limit, period), nil
+		}
+		for name, rules := range map[string][]config.RateLimitRule{
+			"unknown group":   {{Group: "todo", Requests: 1, PeriodSeconds: 1}},
+			"unknown tier":    {{Tier: "premium", Requests: 1, PeriodSeconds: 1}},
+			"zero requests":   {{Requests: 0, PeriodSeconds: 1}},
+			"negative period": {{Requests: 1, PeriodSeconds: -1}},
+			"duplicate":       {{Requests: 1, PeriodSeconds: 1}, {Requests: 2, PeriodSeconds: 1}},
+		} {
+			_, err := NewRateLimits(rules, newLimiter)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	pool := worker.NewWorkerPool(1, make(chan worker.Job, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	newRouter := func(role string) *Router {
+		todoSvc := new(mocks.MockToDoService)
+		todoSvc.On("GetAllTodos", mock.Anything, 1).Return([]entity.ToDo{}, nil)
+		listSvc := new(mocks.MockListService)
+		listSvc.On("GetLists", mock.Anything, 1).Return([]entity.TodoList{}, nil)
+		userSvc := new(mocks.MockUserService)
+		userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: role}, nil)
+
+		limits := newMemoryRateLimits(t, []config.RateLimitRule{
+			{Requests: 2, PeriodSeconds: 10},
+			{Group: RateLimitGroupLists, Requests: 1, PeriodSeconds: 10},
+			{Tier: entity.RoleAdmin, Requests: 5, PeriodSeconds: 10},
+		})
+		r := NewRouter(todoSvc, userSvc, new(mocks.MockJWTValidator), limits.Limiter("", ""), pool, &mocks.MockEmailSender{},
+			WithListService(listSvc), WithRateLimits(limits))
+		r.InitRoutes()
+		return r
+	}
+	get := func(r *Router, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestRateLimit_Headers", func(t *testing.T) {
+		r := newRouter(entity.RoleUser)
+
+		rr := get(r, "/todos")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, "1", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "10", rr.Header().Get("RateLimit-Reset"))
+		assert.Empty(t, rr.Header().Get("Retry-After"))
+
+		get(r, "/todos")
+		rr = get(r, "/todos")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "10", rr.Header().Get("Retry-After"))
+	})
+
+	t.Run("TestRateLimit_PerGroup", func(t *testing.T) {
+		r := newRouter(entity.RoleUser)
+
+		assert.Equal(t, http.StatusOK, get(r, "/lists").Code)
+		assert.Equal(t, http.StatusTooManyRequests, get(r, "/lists").Code)
+		assert.Equal(t, http.StatusOK, get(r, "/todos").Code, "lists are counted apart from todos")
+	})
+
+	t.Run("TestRateLimit_PerTier", func(t *testing.T) {
+		r := newRouter(entity.RoleAdmin)
+
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, http.StatusOK, get(r, "/todos").Code)
+		}
+		assert.Equal(t, http.StatusTooManyRequests, get(r, "/todos").Code)
+		assert.Equal(t, http.StatusOK, get(r, "/lists").Code, "the group rule wins over the tier rule")
+		assert.Equal(t, http.StatusTooManyRequests, get(r, "/lists").Code)
+	})
+}
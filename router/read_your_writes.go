@@ -0,0 +1,77 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+// writeTracker remembers when each user last changed something, so that their reads can go to the
+// primary database until the read replicas have caught up. It only knows about requests served by
+// this instance; behind a load balancer without sticky sessions the window is best effort.
+type writeTracker struct {
+	mu        sync.Mutex
+	window    time.Duration
+	lastWrite map[int]time.Time
+	swept     time.Time
+	now       func() time.Time
+}
+
+func newWriteTracker(window time.Duration) *writeTracker {
+	return &writeTracker{window: window, lastWrite: map[int]time.Time{}, now: time.Now}
+}
+
+// recent reports whether the user changed something within the window
+func (t *writeTracker) recent(userID int) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	last, ok := t.lastWrite[userID]
+	return ok && t.now().Sub(last) < t.window
+}
+
+// record starts the user's window, dropping the windows that have closed once per window length
+func (t *writeTracker) record(userID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	t.lastWrite[userID] = now
+
+	if now.Sub(t.swept) >= t.window {
+		for id, last := range t.lastWrite {
+			if now.Sub(last) >= t.window {
+				delete(t.lastWrite, id)
+			}
+		}
+		t.swept = now
+	}
+}
+
+// isWrite reports whether the request may change data
+func isWrite(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// readYourWrites returns the context for an authenticated request. Requests that change data, and
+// every request of a user who changed something within the window, read from the primary database.
+func (rt *Router) readYourWrites(r *http.Request, userID int) context.Context {
+	if isWrite(r) || (rt.writes != nil && rt.writes.recent(userID)) {
+		return repository.WithPrimary(r.Context())
+	}
+	return r.Context()
+}
+
+// serveAuthenticated serves an authenticated request and, if it may have changed data,
+// opens the user's read-your-writes window
+func (rt *Router) serveAuthenticated(w http.ResponseWriter, r *http.Request, userID int, next http.Handler) {
+	next.ServeHTTP(w, r)
+	if rt.writes != nil && isWrite(r) {
+		rt.writes.record(userID)
+	}
+}
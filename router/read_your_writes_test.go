@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWriteTracker(t *testing.T) {
+	now := time.Now()
+	tracker := newWriteTracker(5 * time.Second)
+	tracker.now = func() time.Time { return now }
+
+	assert.False(t, tracker.recent(1))
+	tracker.record(1)
+	assert.True(t, tracker.recent(1))
+	assert.False(t, tracker.recent(2), "windows are per user")
+
+	now = now.Add(5 * time.Second)
+	assert.False(t, tracker.recent(1), "the window closes")
+
+	tracker.record(2)
+	assert.NotContains(t, tracker.lastWrite, 1, "closed windows are swept")
+}
+
+func TestReadYourWrites(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	userSvc := new(mocks.MockUserService)
+	userSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	pool := worker.NewWorkerPool(1, make(chan worker.Job, 1))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(todoSvc, userSvc, new(mocks.MockJWTValidator), NewMemoryRateLimiter(100, time.Minute), pool,
+		&mocks.MockEmailSender{}, WithReadYourWrites(time.Minute))
+	r.InitRoutes()
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+	primary := mock.MatchedBy(func(ctx context.Context) bool { return repository.UsesPrimary(ctx) })
+	replica := mock.MatchedBy(func(ctx context.Context) bool { return !repository.UsesPrimary(ctx) })
+
+	todoSvc.On("GetAllTodos", replica, 1).Return([]entity.ToDo{}, nil).Once()
+	assert.Equal(t, http.StatusOK, serve("GET", "/todos", "").Code)
+
+	todoSvc.On("AddToDo", primary, mock.Anything).Return(nil).Once()
+	assert.Equal(t, http.StatusCreated, serve("POST", "/todos", `{"title":"Pay invoice"}`).Code)
+
+	todoSvc.On("GetAllTodos", primary, 1).Return([]entity.ToDo{}, nil).Once()
+	assert.Equal(t, http.StatusOK, serve("GET", "/todos", "").Code, "reads after a write go to the primary")
+
+	todoSvc.AssertExpectations(t)
+}
@@ -3,7 +3,6 @@ package router
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/go-redis/redis"
@@ -12,16 +11,27 @@ import (
 // RedisClient defines the methods we will use from Redis
 type RedisClient interface {
 	Incr(key string) *redis.IntCmd
+	IncrBy(key string, value int64) *redis.IntCmd
 	Expire(key string, expiration time.Duration) *redis.BoolCmd
+	Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	Del(keys ...string) *redis.IntCmd
+	PTTL(key string) *redis.DurationCmd
+	SetNX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Get(key string) *redis.StringCmd
 }
 
 type RateLimiter interface {
 	AllowRequest(userID string) (bool, error)
+	// AllowRequests counts one request that weighs as much as n ordinary ones
+	AllowRequests(userID string, n int) (bool, error)
 }
 
-// Developing Rate Limiter
+// RedisRateLimiter allows limit requests per user in a fixed window that starts with the user's
+// first request. Bursts of up to twice the limit get through across the edge of two windows;
+// the sliding window and token bucket limiters do not have that problem.
 type RedisRateLimiter struct {
 	client    RedisClient
+	prefix    string
 	limit     int
 	resetTime time.Duration
 	ctx       context.Context
@@ -31,6 +41,7 @@ func NewRedisRateLimiter(ctx context.Context, rdb RedisClient, limit int, resetT
 
 	return &RedisRateLimiter{
 		client:    rdb,
+		prefix:    "rate_limit",
 		limit:     limit,
 		resetTime: resetTime,
 		ctx:       ctx,
@@ -38,22 +49,40 @@ func NewRedisRateLimiter(ctx context.Context, rdb RedisClient, limit int, resetT
 }
 
 func (rl *RedisRateLimiter) AllowRequest(userID string) (bool, error) {
-	key := fmt.Sprintf("rate_limit:%s", userID)
+	return rl.AllowRequests(userID, 1)
+}
+
+func (rl *RedisRateLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := rl.Consume(userID, n)
+	return status.Allowed, err
+}
+
+// Consume counts n requests in the user's current window
+func (rl *RedisRateLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	key := fmt.Sprintf("%s:%s", rl.prefix, userID)
 
-	// Increment the count and set expiration
-	count, err := rl.client.Incr(key).Result()
+	var count int64
+	var err error
+	if n == 1 {
+		count, err = rl.client.Incr(key).Result()
+	} else {
+		count, err = rl.client.IncrBy(key, int64(n)).Result()
+	}
 	if err != nil {
-		return false, err
+		return RateLimitStatus{}, err
 	}
-	log.Println("Count", count)
 
-	if count == 1 {
-		rl.client.Expire(key, rl.resetTime)
+	reset := rl.resetTime
+	if count != int64(n) {
+		if reset, err = rl.client.PTTL(key).Result(); err != nil {
+			return RateLimitStatus{}, err
+		}
 	}
-
-	if count > int64(rl.limit) {
-		return false, nil // Request denied
+	// This request opened the window, or the request that did failed to start its expiry
+	if count == int64(n) || reset < 0 {
+		rl.client.Expire(key, rl.resetTime)
+		reset = rl.resetTime
 	}
 
-	return true, nil // Request allowed
+	return fixedWindowStatus(rl.limit, int(count), reset), nil
 }
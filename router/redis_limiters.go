@@ -0,0 +1,215 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// RedisScripter is the part of the Redis client that runs Lua scripts
+type RedisScripter interface {
+	Eval(script string, keys []string, args ...interface{}) *redis.Cmd
+	EvalSha(sha1 string, keys []string, args ...interface{}) *redis.Cmd
+	ScriptExists(hashes ...string) *redis.BoolSliceCmd
+	ScriptLoad(script string) *redis.StringCmd
+}
+
+// RedisLimiterClient is what the Redis limiters need from the Redis client, whatever the algorithm
+type RedisLimiterClient interface {
+	RedisClient
+	RedisScripter
+}
+
+// NewRedisLimiter creates a limiter that counts with the algorithm in Redis, allowing limit
+// requests per period. Its keys start with rate_limit:<algorithm>:<name>.
+func NewRedisLimiter(ctx context.Context, rdb RedisLimiterClient, algorithm, name string, limit int, period time.Duration) (QuotaLimiter, error) {
+	prefix := fmt.Sprintf("rate_limit:%s:%s", algorithm, name)
+	switch algorithm {
+	case FixedWindow:
+		limiter := NewRedisRateLimiter(ctx, rdb, limit, period)
+		limiter.prefix = prefix
+		return limiter, nil
+	case SlidingWindow:
+		return NewSlidingWindowLimiter(rdb, prefix, limit, period), nil
+	case TokenBucket:
+		return NewTokenBucketLimiter(rdb, prefix, limit, period), nil
+	}
+	return nil, fmt.Errorf("unknown rate limit algorithm %q", algorithm)
+}
+
+// The limiter scripts take the current time in milliseconds from the caller, so that they only
+// write what their arguments determine, and return {allowed, remaining, reset ms, retry after ms}.
+
+// slidingWindowScript keeps a log of the requests in the last window, one sorted set member each.
+// KEYS[1] is the log; ARGV is now, window, limit, n, and a prefix that makes this call's members unique.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+
+local allowed = 0
+if count + n <= limit then
+	for i = 1, n do
+		redis.call('ZADD', KEYS[1], now, ARGV[5] .. ':' .. i)
+	end
+	redis.call('PEXPIRE', KEYS[1], window)
+	count = count + n
+	allowed = 1
+end
+
+-- The whole quota is back once the newest request has left the window
+local reset = 0
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if #newest > 0 then
+	reset = tonumber(newest[2]) + window - now
+end
+
+local retry = 0
+if allowed == 0 then
+	if n > limit then
+		retry = window
+	else
+		-- The request fits once the count + n - limit oldest requests have left the window
+		local i = count + n - limit - 1
+		local entry = redis.call('ZRANGE', KEYS[1], i, i, 'WITHSCORES')
+		retry = tonumber(entry[2]) + window - now
+	end
+end
+
+return {allowed, limit - count, reset, retry}
+`)
+
+// tokenBucketScript keeps the tokens left and when they were counted; the bucket refills evenly,
+// from empty to full over one period. KEYS[1] is the bucket; ARGV is now, capacity, period and n.
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local n = tonumber(ARGV[4])
+
+local tokens = capacity
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+if state[1] then
+	tokens = math.min(capacity, tonumber(state[1]) + math.max(0, now - tonumber(state[2])) * capacity / period)
+end
+
+local allowed = 0
+if tokens >= n then
+	tokens = tokens - n
+	allowed = 1
+end
+
+-- A full bucket is the same as none, so the key expires once the bucket has refilled
+local reset = math.ceil((capacity - tokens) * period / capacity)
+if reset > 0 then
+	redis.call('HMSET', KEYS[1], 'tokens', tokens, 'updated', now)
+	redis.call('PEXPIRE', KEYS[1], reset)
+end
+
+local retry = 0
+if allowed == 0 then
+	if n > capacity then
+		retry = period
+	else
+		retry = math.ceil((n - tokens) * period / capacity)
+	end
+end
+
+return {allowed, math.floor(tokens), reset, retry}
+`)
+
+// RedisSlidingWindowLimiter allows limit requests per user in any window of the given length.
+// Every request is logged until it leaves the window, so memory grows with the limit.
+type RedisSlidingWindowLimiter struct {
+	client RedisScripter
+	prefix string
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+func NewSlidingWindowLimiter(rdb RedisScripter, prefix string, limit int, window time.Duration) *RedisSlidingWindowLimiter {
+	return &RedisSlidingWindowLimiter{client: rdb, prefix: prefix, limit: limit, window: window, now: time.Now}
+}
+
+func (rl *RedisSlidingWindowLimiter) AllowRequest(userID string) (bool, error) {
+	return rl.AllowRequests(userID, 1)
+}
+
+func (rl *RedisSlidingWindowLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := rl.Consume(userID, n)
+	return status.Allowed, err
+}
+
+// Consume logs n requests if they fit in the user's window
+func (rl *RedisSlidingWindowLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	reply, err := slidingWindowScript.Run(rl.client, []string{rl.prefix + ":" + userID},
+		rl.now().UnixMilli(), rl.window.Milliseconds(), rl.limit, n, strconv.FormatUint(rand.Uint64(), 36)).Result()
+	if err != nil {
+		return RateLimitStatus{}, err
+	}
+	return limiterStatus(reply, rl.limit)
+}
+
+// RedisTokenBucketLimiter gives each user a bucket of limit tokens that refills over the period.
+// Unlike the windows it lets an idle user burst up to the whole limit at once, and keeps two
+// numbers per user whatever the limit.
+type RedisTokenBucketLimiter struct {
+	client RedisScripter
+	prefix string
+	limit  int
+	period time.Duration
+	now    func() time.Time
+}
+
+func NewTokenBucketLimiter(rdb RedisScripter, prefix string, limit int, period time.Duration) *RedisTokenBucketLimiter {
+	return &RedisTokenBucketLimiter{client: rdb, prefix: prefix, limit: limit, period: period, now: time.Now}
+}
+
+func (rl *RedisTokenBucketLimiter) AllowRequest(userID string) (bool, error) {
+	return rl.AllowRequests(userID, 1)
+}
+
+func (rl *RedisTokenBucketLimiter) AllowRequests(userID string, n int) (bool, error) {
+	status, err := rl.Consume(userID, n)
+	return status.Allowed, err
+}
+
+// Consume takes n tokens from the user's bucket if it holds that many
+func (rl *RedisTokenBucketLimiter) Consume(userID string, n int) (RateLimitStatus, error) {
+	reply, err := tokenBucketScript.Run(rl.client, []string{rl.prefix + ":" + userID},
+		rl.now().UnixMilli(), rl.limit, rl.period.Milliseconds(), n).Result()
+	if err != nil {
+		return RateLimitStatus{}, err
+	}
+	return limiterStatus(reply, rl.limit)
+}
+
+// limiterStatus reads the {allowed, remaining, reset ms, retry after ms} reply of a limiter script
+func limiterStatus(reply interface{}, limit int) (RateLimitStatus, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return RateLimitStatus{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		if numbers[i], ok = value.(int64); !ok {
+			return RateLimitStatus{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+		}
+	}
+	return RateLimitStatus{
+		Allowed:    numbers[0] == 1,
+		Limit:      limit,
+		Remaining:  max(int(numbers[1]), 0),
+		Reset:      time.Duration(numbers[2]) * time.Millisecond,
+		RetryAfter: time.Duration(numbers[3]) * time.Millisecond,
+	}, nil
+}
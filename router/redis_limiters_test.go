@@ -0,0 +1,157 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingScripter answers every script with reply and remembers the last call
+type recordingScripter struct {
+	keys  []string
+	args  []interface{}
+	reply interface{}
+	err   error
+}
+
+func (s *recordingScripter) Eval(script string, keys []string, args ...interface{}) *redis.Cmd {
+	return s.EvalSha("", keys, args...)
+}
+
+func (s *recordingScripter) EvalSha(sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	s.keys, s.args = keys, args
+	return redis.NewCmdResult(s.reply, s.err)
+}
+
+func (s *recordingScripter) ScriptExists(hashes ...string) *redis.BoolSliceCmd {
+	return redis.NewBoolSliceResult([]bool{true}, nil)
+}
+
+func (s *recordingScripter) ScriptLoad(script string) *redis.StringCmd {
+	return redis.NewStringResult("", nil)
+}
+
+func TestRedisScriptLimiters(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	t.Run("TestSlidingWindow_Denied", func(t *testing.T) {
+		scripter := &recordingScripter{reply: []interface{}{int64(0), int64(0), int64(9500), int64(1200)}}
+		limiter := NewSlidingWindowLimiter(scripter, "rate_limit:sliding_window:all:all", 100, 10*time.Second)
+		limiter.now = func() time.Time { return now }
+
+		status, err := limiter.Consume("7", 3)
+		assert.NoError(t, err)
+		assert.Equal(t, RateLimitStatus{Limit: 100, Reset: 9500 * time.Millisecond, RetryAfter: 1200 * time.Millisecond}, status)
+		assert.Equal(t, []string{"rate_limit:sliding_window:all:all:7"}, scripter.keys)
+		assert.Equal(t, []interface{}{now.UnixMilli(), int64(10000), 100, 3}, scripter.args[:4])
+	})
+
+	t.Run("TestTokenBucket_Allowed", func(t *testing.T) {
+		scripter := &recordingScripter{reply: []interface{}{int64(1), int64(4), int64(600), int64(0)}}
+		limiter := NewTokenBucketLimiter(scripter, "rate_limit:token_bucket:batch:user", 5, 3*time.Second)
+		limiter.now = func() time.Time { return now }
+
+		allowed, err := limiter.AllowRequest("7")
+		assert.NoError(t, err)
+		assert.True(t, allowed)
+		assert.Equal(t, []string{"rate_limit:token_bucket:batch:user:7"}, scripter.keys)
+		assert.Equal(t, []interface{}{now.UnixMilli(), 5, int64(3000), 1}, scripter.args)
+	})
+
+	t.Run("TestScriptErrors", func(t *testing.T) {
+		scripter := &recordingScripter{err: errors.New("connection refused")}
+		_, err := NewTokenBucketLimiter(scripter, "p", 5, time.Second).Consume("7", 1)
+		assert.Error(t, err)
+
+		scripter = &recordingScripter{reply: []interface{}{int64(1), "4"}}
+		_, err = NewTokenBucketLimiter(scripter, "p", 5, time.Second).Consume("7", 1)
+		assert.Error(t, err, "malformed replies are errors")
+	})
+
+	t.Run("TestNewRedisLimiter_UnknownAlgorithm", func(t *testing.T) {
+		_, err := NewRedisLimiter(context.TODO(), nil, "leaky_bucket", "all:all", 5, time.Second)
+		assert.Error(t, err)
+	})
+}
+
+// TestRedisScriptLimitersLive runs the limiter scripts in Redis. It needs a disposable Redis, whose
+// address is taken from TODO_TEST_REDIS_ADDR; the test is skipped when that is not set.
+func TestRedisScriptLimitersLive(t *testing.T) {
+	addr := os.Getenv("TODO_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TODO_TEST_REDIS_ADDR is not set")
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+	prefix := fmt.Sprintf("rate_limit_test:%d", time.Now().UnixNano())
+	defer func() {
+		keys, _ := rdb.Keys(prefix + ":*").Result()
+		if len(keys) > 0 {
+			rdb.Del(keys...)
+		}
+	}()
+
+	now := time.UnixMilli(1700000000000)
+	clock := func() time.Time { return now }
+
+	t.Run("TestSlidingWindow", func(t *testing.T) {
+		limiter := NewSlidingWindowLimiter(rdb, prefix+":sliding", 3, 10*time.Second)
+		limiter.now = clock
+
+		for i := 0; i < 2; i++ {
+			status, err := limiter.Consume("1", 1)
+			assert.NoError(t, err)
+			assert.True(t, status.Allowed)
+			now = now.Add(4 * time.Second)
+		}
+		// Requests at 0s and 4s; now is 8s
+		status, _ := limiter.Consume("1", 2)
+		assert.False(t, status.Allowed, "only one request fits")
+		assert.Equal(t, 1, status.Remaining)
+		assert.Equal(t, 2*time.Second, status.RetryAfter, "the first request leaves the window at 10s")
+
+		status, _ = limiter.Consume("1", 1)
+		assert.True(t, status.Allowed)
+		assert.Equal(t, 0, status.Remaining)
+		assert.Equal(t, 10*time.Second, status.Reset, "the quota is whole once the newest request has left")
+
+		// At 12s the first request has left, unlike with a fixed window that opened at 0s and
+		// would allow a burst of three more at 10s
+		now = now.Add(4 * time.Second)
+		status, _ = limiter.Consume("1", 1)
+		assert.True(t, status.Allowed)
+		status, _ = limiter.Consume("1", 1)
+		assert.False(t, status.Allowed)
+		assert.Equal(t, 2*time.Second, status.RetryAfter)
+	})
+
+	t.Run("TestTokenBucket", func(t *testing.T) {
+		limiter := NewTokenBucketLimiter(rdb, prefix+":bucket", 4, 4*time.Second)
+		limiter.now = clock
+
+		status, err := limiter.Consume("1", 4)
+		assert.NoError(t, err)
+		assert.True(t, status.Allowed, "a full bucket allows a burst of the whole limit")
+		assert.Equal(t, 4*time.Second, status.Reset)
+
+		status, _ = limiter.Consume("1", 1)
+		assert.False(t, status.Allowed)
+		assert.Equal(t, time.Second, status.RetryAfter, "one token refills per second")
+
+		now = now.Add(2500 * time.Millisecond)
+		status, _ = limiter.Consume("1", 2)
+		assert.True(t, status.Allowed)
+		assert.Equal(t, 0, status.Remaining, "half a token is left")
+
+		now = now.Add(time.Minute)
+		status, _ = limiter.Consume("1", 1)
+		assert.True(t, status.Allowed)
+		assert.Equal(t, 3, status.Remaining, "the bucket never holds more than its limit")
+	})
+}
@@ -20,6 +20,7 @@ func TestYourFunction(t *testing.T) {
 
 	// Case 1: Test normal behavior
 	mockRedis.On("Incr", "rate_limit:test-key").Return(intCmd)
+	mockRedis.On("PTTL", "rate_limit:test-key").Return(redis.NewDurationResult(10*time.Second, nil))
 	mockRedis.On("Expire", "rate_limit:test-key", 10*time.Second).Return(boolCmd)
 
 	// Call the function under test and pass the mock
@@ -42,3 +43,38 @@ func TestYourFunction(t *testing.T) {
 	assert.Equal(t, result, true)
 	assert.Nil(t, err, "There should be no error, even if Expire fails")
 }
+
+func TestAllowRequests(t *testing.T) {
+	mockRedis := &mocks.MockRedisClient{}
+	mockRedis.On("IncrBy", "rate_limit:test-key", int64(3)).Return(redis.NewIntResult(3, nil)).Once()
+	mockRedis.On("IncrBy", "rate_limit:test-key", int64(3)).Return(redis.NewIntResult(6, nil)).Once()
+	mockRedis.On("Expire", "rate_limit:test-key", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	mockRedis.On("PTTL", "rate_limit:test-key").Return(redis.NewDurationResult(8*time.Second, nil))
+
+	limiter := NewRedisRateLimiter(context.TODO(), mockRedis, 5, 10*time.Second)
+
+	allowed, err := limiter.AllowRequests("test-key", 3)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	mockRedis.AssertNumberOfCalls(t, "Expire", 1)
+
+	allowed, err = limiter.AllowRequests("test-key", 3)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+	mockRedis.AssertNumberOfCalls(t, "Expire", 1)
+}
+
+func TestRedisRateLimiterConsume(t *testing.T) {
+	mockRedis := &mocks.MockRedisClient{}
+	mockRedis.On("IncrBy", "rate_limit:test-key", int64(2)).Return(redis.NewIntResult(4, nil))
+	// The request that opened the window failed to set its expiry
+	mockRedis.On("PTTL", "rate_limit:test-key").Return(redis.NewDurationResult(-time.Millisecond, nil))
+	mockRedis.On("Expire", "rate_limit:test-key", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+
+	limiter := NewRedisRateLimiter(context.TODO(), mockRedis, 5, 10*time.Second)
+
+	status, err := limiter.Consume("test-key", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimitStatus{Allowed: true, Limit: 5, Remaining: 1, Reset: 10 * time.Second}, status)
+	mockRedis.AssertCalled(t, "Expire", "rate_limit:test-key", 10*time.Second)
+}
@@ -0,0 +1,86 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// GetTodoRevisions lists every version of a todo, oldest first
+func (rt *Router) GetTodoRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, err := strconv.Atoi(mux.Vars(r)["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	revisions, err := rt.todoService.GetRevisions(r.Context(), userID, todoID)
+	if err != nil {
+		writeTodoError(w, "failed to retrieve revisions", err)
+		return
+	}
+	json.NewEncoder(w).Encode(revisions)
+}
+
+// DiffTodoRevisions lists the fields that changed between the revisions given by from and to
+func (rt *Router) DiffTodoRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, err := strconv.Atoi(mux.Vars(r)["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+	from, fromErr := strconv.Atoi(r.URL.Query().Get("from"))
+	to, toErr := strconv.Atoi(r.URL.Query().Get("to"))
+	if fromErr != nil || toErr != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "from and to must be revision numbers"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	changes, err := rt.todoService.DiffRevisions(r.Context(), userID, todoID, from, to)
+	if err != nil {
+		writeTodoError(w, "failed to compare revisions", err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"from": from, "to": to, "changes": changes})
+}
+
+// RevertToDo restores a todo to an earlier revision, recording the result as a new revision
+func (rt *Router) RevertToDo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	vars := mux.Vars(r)
+	todoID, err := strconv.Atoi(vars["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+	revision, err := strconv.Atoi(vars["revision"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid revision"})
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(w, r, todoID)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	todo, err := rt.todoService.RevertToDo(r.Context(), userID, todoID, revision, expectedVersion)
+	if err != nil {
+		writeTodoError(w, "failed to revert todo", err)
+		return
+	}
+	w.Header().Set("ETag", todoETag(todo))
+	json.NewEncoder(w).Encode(todo)
+}
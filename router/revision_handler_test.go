@@ -0,0 +1,86 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRevisionHandlers(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(todoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{})
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestUpdateToDo_PartialBody", func(t *testing.T) {
+		todoSvc.On("UpdateToDo", mock.Anything, 1, 3, mock.MatchedBy(func(update entity.TodoUpdate) bool {
+			return *update.Title == "Renamed" && update.Description == nil && update.DateTime == nil
+		}), 0).Return(entity.ToDo{ToDoID: 3, Title: "Renamed"}, nil).Once()
+
+		rr := serve("PATCH", "/todos/3", `{"title": "Renamed"}`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"title":"Renamed"`)
+	})
+
+	t.Run("TestUpdateToDo_Viewer", func(t *testing.T) {
+		todoSvc.On("UpdateToDo", mock.Anything, 1, 4, mock.Anything, 0).Return(entity.ToDo{}, repository.ErrListNotWritable).Once()
+
+		rr := serve("PATCH", "/todos/4", `{"title": "Nope"}`)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+
+	t.Run("TestDiffTodoRevisions", func(t *testing.T) {
+		changes := []entity.FieldChange{{Field: "title", From: "Draft", To: "Final"}}
+		todoSvc.On("DiffRevisions", mock.Anything, 1, 3, 1, 2).Return(changes, nil).Once()
+
+		rr := serve("GET", "/todos/3/revisions/diff?from=1&to=2", "")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"from": 1, "to": 2, "changes": [{"field": "title", "from": "Draft", "to": "Final"}]}`, rr.Body.String())
+	})
+
+	t.Run("TestDiffTodoRevisions_MissingRange", func(t *testing.T) {
+		rr := serve("GET", "/todos/3/revisions/diff?from=1", "")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("TestRevertToDo_UnknownRevision", func(t *testing.T) {
+		todoSvc.On("RevertToDo", mock.Anything, 1, 3, 9, 0).Return(entity.ToDo{}, fmt.Errorf("revision %w", repository.ErrNotFound)).Once()
+
+		rr := serve("POST", "/todos/3/revisions/9/revert", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+}
@@ -2,12 +2,13 @@ package router
 
 import (
 	"context"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -18,18 +19,115 @@ import (
 )
 
 type Router struct {
-	todoService service.ToDoService
-	userService service.UserService
-	jwtService  service.JWTValidator
-	rateLimiter RateLimiter
-	Router      *mux.Router
-	WorkerPool  *worker.WorkerPool
-	EmailSender worker.EmailSender
-	Config      *config.Config
+	todoService      service.ToDoService
+	userService      service.UserService
+	jwtService       service.JWTValidator
+	accountSvc       service.AccountService
+	apiKeySvc        service.APIKeyService
+	listSvc          service.ListService
+	commentSvc       service.CommentService
+	auditSvc         service.AuditService
+	rateLimiter      RateLimiter
+	rateLimits       *RateLimits
+	loginThrottler   LoginThrottler
+	idempotencyStore IdempotencyStore
+	healthChecks     map[string]HealthChecker
+	metrics          map[string]MetricsSource
+	writes           *writeTracker
+	Router           *mux.Router
+	WorkerPool       *worker.WorkerPool
+	EmailSender      worker.EmailSender
+	Config           *config.Config
+	configs          *config.Manager
 }
 
 type Option func(*Router)
 
+// WithLoginThrottler returns an Option that throttles failed logins
+func WithLoginThrottler(lt LoginThrottler) Option {
+	return func(rt *Router) {
+		rt.loginThrottler = lt
+	}
+}
+
+// WithIdempotencyStore returns an Option that lets create endpoints be retried with an Idempotency-Key
+func WithIdempotencyStore(store IdempotencyStore) Option {
+	return func(rt *Router) {
+		rt.idempotencyStore = store
+	}
+}
+
+// WithAPIKeyService returns an Option that enables personal API keys
+func WithAPIKeyService(svc service.APIKeyService) Option {
+	return func(rt *Router) {
+		rt.apiKeySvc = svc
+	}
+}
+
+// WithListService returns an Option that enables shared lists and invitations
+func WithListService(svc service.ListService) Option {
+	return func(rt *Router) {
+		rt.listSvc = svc
+	}
+}
+
+// WithCommentService returns an Option that enables comments on todos
+func WithCommentService(svc service.CommentService) Option {
+	return func(rt *Router) {
+		rt.commentSvc = svc
+	}
+}
+
+// WithAuditService returns an Option that records logins and serves the audit log
+func WithAuditService(svc service.AuditService) Option {
+	return func(rt *Router) {
+		rt.auditSvc = svc
+	}
+}
+
+// WithAccountService returns an Option that enables the email verification and password reset flows
+func WithAccountService(svc service.AccountService) Option {
+	return func(rt *Router) {
+		rt.accountSvc = svc
+	}
+}
+
+// WithHealthCheck returns an Option that makes the readiness endpoint depend on a named check
+func WithHealthCheck(name string, checker HealthChecker) Option {
+	return func(rt *Router) {
+		if rt.healthChecks == nil {
+			rt.healthChecks = map[string]HealthChecker{}
+		}
+		rt.healthChecks[name] = checker
+	}
+}
+
+// WithRateLimits returns an Option that limits requests with the most specific of the limits for their
+// route group and the user's tier, falling back to the router's rate limiter when none matches
+func WithRateLimits(limits *RateLimits) Option {
+	return func(rt *Router) {
+		rt.rateLimits = limits
+	}
+}
+
+// WithMetrics returns an Option that reports the source's metrics under name on GET /admin/metrics
+func WithMetrics(name string, source MetricsSource) Option {
+	return func(rt *Router) {
+		if rt.metrics == nil {
+			rt.metrics = map[string]MetricsSource{}
+		}
+		rt.metrics[name] = source
+	}
+}
+
+// WithReadYourWrites returns an Option that sends a user's reads to the primary database for the
+// window after they change something, so they do not see replicas that have not caught up yet
+func WithReadYourWrites(window time.Duration) Option {
+	return func(rt *Router) {
+		rt.writes = newWriteTracker(window)
+	}
+}
+
 // WithConfig returns an Option that sets the Config for the Router
 func WithConfig(cfg *config.Config) Option {
 	return func(rt *Router) {
@@ -37,6 +135,23 @@ func WithConfig(cfg *config.Config) Option {
 	}
 }
 
+// WithConfigManager returns an Option that reads the Config from the manager on every request,
+// so that settings reloaded while the server runs take effect at once
+func WithConfigManager(configs *config.Manager) Option {
+	return func(rt *Router) {
+		rt.configs = configs
+		rt.Config = configs.Current()
+	}
+}
+
+// currentConfig returns the Config in force, which is nil when the Router was given none
+func (rt *Router) currentConfig() *config.Config {
+	if rt.configs != nil {
+		return rt.configs.Current()
+	}
+	return rt.Config
+}
+
 func NewRouter(todoSvc service.ToDoService, userSvc service.UserService,
 	jwtService service.JWTValidator, rateLimiter RateLimiter,
 	wp *worker.WorkerPool, emailSender worker.EmailSender,
@@ -62,26 +177,111 @@ func (rt *Router) InitRoutes() {
 	rt.Router.HandleFunc("/ws", rt.WebSocketHandler).Methods("GET")
 	rt.Router.HandleFunc("/", rt.ServeHTML).Methods("GET")
 
+	// Probe endpoints for orchestrators and load balancers
+	rt.Router.HandleFunc("/healthz", rt.Liveness).Methods("GET")
+	rt.Router.HandleFunc("/readyz", rt.Readiness).Methods("GET")
+
 	// User endpoints
 	rt.Router.HandleFunc("/users", rt.CreateUser).Methods("POST")
-	rt.Router.HandleFunc("/users/{id}", rt.GetUserByID).Methods("GET")
+	rt.Router.Handle("/users/{id}", rt.JWTMiddleware(http.HandlerFunc(rt.GetUserByID))).Methods("GET")
+	rt.Router.Handle("/me", rt.JWTMiddleware(http.HandlerFunc(rt.GetMe))).Methods("GET")
 	rt.Router.HandleFunc("/login", rt.LoginUser).Methods("POST")
 
+	// Account recovery endpoints
+	rt.Router.HandleFunc("/verify-email/request", rt.RequestEmailVerification).Methods("POST")
+	rt.Router.HandleFunc("/verify-email/confirm", rt.ConfirmEmailVerification).Methods("GET", "POST")
+	rt.Router.HandleFunc("/password-reset/request", rt.RequestPasswordReset).Methods("POST")
+	rt.Router.HandleFunc("/password-reset/confirm", rt.ConfirmPasswordReset).Methods("POST")
+
 	rt.Router.Use(LoggingMiddleware) // Apply any other middleware as needed
+	rt.Router.Use(RequestContextMiddleware)
+
+	// Audit log of the caller's own events
+	auditRouter := rt.Router.PathPrefix("/audit").Subrouter()
+	auditRouter.Use(rt.JWTMiddleware)
+	auditRouter.Use(rt.RequireScope(entity.ScopeAccount))
+	auditRouter.HandleFunc("", rt.GetAuditEvents).Methods("GET")
+
+	// API key management only accepts session tokens, so a leaked key cannot mint new keys
+	apiKeyRouter := rt.Router.PathPrefix("/api-keys").Subrouter()
+	apiKeyRouter.Use(rt.JWTMiddleware)
+	apiKeyRouter.Use(rt.RequireScope(entity.ScopeAccount))
+
+	apiKeyRouter.HandleFunc("", rt.CreateAPIKey).Methods("POST")
+	apiKeyRouter.HandleFunc("", rt.ListAPIKeys).Methods("GET")
+	apiKeyRouter.HandleFunc("/{keyID}", rt.RevokeAPIKey).Methods("DELETE")
+
+	// Admin endpoints
+	adminRouter := rt.Router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(rt.JWTMiddleware)
+	adminRouter.Use(rt.RequireScope(entity.ScopeAdmin))
+	adminRouter.Use(rt.RequirePermission(entity.PermissionManageUsers))
+
+	adminRouter.HandleFunc("/users", rt.AdminListUsers).Methods("GET")
+	adminRouter.HandleFunc("/users/{id}", rt.AdminDeleteUser).Methods("DELETE")
+	adminRouter.HandleFunc("/users/{id}/disable", rt.AdminDisableUser).Methods("POST")
+	adminRouter.HandleFunc("/users/{id}/enable", rt.AdminEnableUser).Methods("POST")
+	adminRouter.HandleFunc("/users/{id}/role", rt.AdminSetUserRole).Methods("PUT")
+	adminRouter.HandleFunc("/users/{id}/password", rt.AdminResetPassword).Methods("POST")
+	adminRouter.HandleFunc("/audit", rt.AdminGetAuditEvents).Methods("GET")
+	adminRouter.HandleFunc("/metrics", rt.AdminGetMetrics).Methods("GET")
+
+	// Shared list endpoints
+	listRouter := rt.Router.PathPrefix("/lists").Subrouter()
+	listRouter.Use(rt.AuthMiddleware)
+	listRouter.Use(rt.RateLimitMiddleware(RateLimitGroupLists))
+
+	listRouter.Handle("", rt.scoped(entity.ScopeTodosRead, rt.GetLists)).Methods("GET")
+	listRouter.Handle("", rt.scoped(entity.ScopeTodosWrite, rt.idempotent(rt.CreateList))).Methods("POST")
+	listRouter.Handle("/{listID}", rt.scoped(entity.ScopeTodosRead, rt.GetList)).Methods("GET")
+	listRouter.Handle("/{listID}", rt.scoped(entity.ScopeTodosWrite, rt.DeleteList)).Methods("DELETE")
+	listRouter.Handle("/{listID}/todos", rt.scoped(entity.ScopeTodosRead, rt.GetListTodos)).Methods("GET")
+	listRouter.Handle("/{listID}/invitations", rt.scoped(entity.ScopeTodosWrite, rt.idempotent(rt.InviteListMember))).Methods("POST")
+	listRouter.Handle("/{listID}/members/{userID}", rt.scoped(entity.ScopeTodosWrite, rt.SetListMemberRole)).Methods("PUT")
+	listRouter.Handle("/{listID}/members/{userID}", rt.scoped(entity.ScopeTodosWrite, rt.RemoveListMember)).Methods("DELETE")
+
+	// Invitations are answered with a session token, since accepting one grants access to new data
+	invitationRouter := rt.Router.PathPrefix("/invitations").Subrouter()
+	invitationRouter.Use(rt.JWTMiddleware)
+	invitationRouter.Use(rt.RequireScope(entity.ScopeAccount))
+
+	invitationRouter.HandleFunc("", rt.GetInvitations).Methods("GET")
+	invitationRouter.HandleFunc("/{invitationID}/accept", rt.AcceptInvitation).Methods("POST")
+	invitationRouter.HandleFunc("/{invitationID}/decline", rt.DeclineInvitation).Methods("POST")
+
+	// Batches are rate limited by their size inside the handler, so they bypass the per-request limiter
+	rt.Router.Handle("/todos/batch", rt.AuthMiddleware(rt.scoped(entity.ScopeTodosWrite, rt.BatchToDos))).Methods("POST")
 
 	protectedRouter := rt.Router.PathPrefix("/todos").Subrouter()
-	protectedRouter.Use(rt.JWTMiddleware)          // Apply JWT middleware to this subrouter
-	protectedRouter.Use(rt.JRateLimiterMiddleware) // Apply JWT middleware to this subrouter
+	protectedRouter.Use(rt.AuthMiddleware) // Accepts both JWTs and API keys
+	protectedRouter.Use(rt.RateLimitMiddleware(RateLimitGroupTodos))
 
-	// ToDo endpoints (protected)
-	protectedRouter.HandleFunc("/download", rt.DownloadToDos).Methods("GET")
-	protectedRouter.HandleFunc("/download/output/{filename}", rt.DownloadFileHandler).Methods("GET")
+	// ToDo endpoints (protected), each declaring the scope it needs
+	protectedRouter.Handle("/download", rt.scoped(entity.ScopeTodosRead, rt.DownloadToDos)).Methods("GET")
+	protectedRouter.Handle("/download/output/{filename}", rt.scoped(entity.ScopeTodosRead, rt.DownloadFileHandler)).Methods("GET")
+	protectedRouter.Handle("/trash", rt.scoped(entity.ScopeTodosRead, rt.GetTrash)).Methods("GET")
+	protectedRouter.Handle("/search", rt.scoped(entity.ScopeTodosRead, rt.SearchTodos)).Methods("GET")
+	protectedRouter.Handle("/undo-delete-all", rt.scoped(entity.ScopeTodosDeleteAll, rt.UndoDeleteAll)).Methods("POST")
 
-	protectedRouter.HandleFunc("", rt.GetAllToDos).Methods("GET")            // /todos
-	protectedRouter.HandleFunc("", rt.CreateToDo).Methods("POST")            // /todos for creating a todo
-	protectedRouter.HandleFunc("/{todoID}", rt.GetTodo).Methods("GET")       // /todos/{todoID}
-	protectedRouter.HandleFunc("/{todoID}", rt.DeleteToDo).Methods("DELETE") // /todos/{todoID}
-	protectedRouter.HandleFunc("", rt.DeleteAllTodos).Methods("DELETE")      // /todos for deleting all todos
+	protectedRouter.Handle("", rt.scoped(entity.ScopeTodosRead, rt.GetAllToDos)).Methods("GET")                 // /todos
+	protectedRouter.Handle("", rt.scoped(entity.ScopeTodosWrite, rt.idempotent(rt.CreateToDo))).Methods("POST") // /todos for creating a todo
+	protectedRouter.Handle("/{todoID}", rt.scoped(entity.ScopeTodosRead, rt.GetTodo)).Methods("GET")            // /todos/{todoID}
+	protectedRouter.Handle("/{todoID}", rt.scoped(entity.ScopeTodosWrite, rt.DeleteToDo)).Methods("DELETE")     // /todos/{todoID}
+	protectedRouter.Handle("", rt.scoped(entity.ScopeTodosDeleteAll, rt.DeleteAllTodos)).Methods("DELETE")      // /todos for deleting all todos
+
+	protectedRouter.Handle("/{todoID}", rt.scoped(entity.ScopeTodosWrite, rt.UpdateToDo)).Methods("PATCH")
+	protectedRouter.Handle("/{todoID}/revisions", rt.scoped(entity.ScopeTodosRead, rt.GetTodoRevisions)).Methods("GET")
+	protectedRouter.Handle("/{todoID}/revisions/diff", rt.scoped(entity.ScopeTodosRead, rt.DiffTodoRevisions)).Methods("GET")
+	protectedRouter.Handle("/{todoID}/revisions/{revision}/revert", rt.scoped(entity.ScopeTodosWrite, rt.RevertToDo)).Methods("POST")
+	protectedRouter.Handle("/{todoID}/restore", rt.scoped(entity.ScopeTodosWrite, rt.RestoreToDo)).Methods("POST")
+	protectedRouter.Handle("/{todoID}/assignee", rt.scoped(entity.ScopeTodosWrite, rt.AssignToDo)).Methods("PUT")
+	protectedRouter.Handle("/{todoID}/assignments", rt.scoped(entity.ScopeTodosRead, rt.GetAssignmentHistory)).Methods("GET")
+
+	protectedRouter.Handle("/{todoID}/comments", rt.scoped(entity.ScopeTodosRead, rt.ListComments)).Methods("GET")
+	protectedRouter.Handle("/{todoID}/comments", rt.scoped(entity.ScopeTodosWrite, rt.idempotent(rt.CreateComment))).Methods("POST")
+	protectedRouter.Handle("/{todoID}/comments/{commentID}", rt.scoped(entity.ScopeTodosWrite, rt.UpdateComment)).Methods("PATCH")
+	protectedRouter.Handle("/{todoID}/comments/{commentID}", rt.scoped(entity.ScopeTodosWrite, rt.DeleteComment)).Methods("DELETE")
+	protectedRouter.Handle("/{todoID}/comments/{commentID}/revisions", rt.scoped(entity.ScopeTodosRead, rt.GetCommentRevisions)).Methods("GET")
 
 }
 
@@ -94,45 +294,112 @@ func (rt *Router) JWTMiddleware(next http.Handler) http.Handler {
 		}
 
 		clearString := strings.ReplaceAll(tokenString, "Bearer ", "")
-		userID, err := rt.jwtService.ValidateToken(clearString)
+		claims, err := rt.jwtService.ParseToken(clearString)
 		if err != nil {
 			http.Error(w, "Invalid token", http.StatusUnauthorized)
 			return
 		}
+		r = r.WithContext(rt.readYourWrites(r, claims.UserID))
 
-		// Store user ID in context for later use
-		ctx := context.WithValue(r.Context(), "userID", userID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+		// Tokens of disabled accounts or issued before a password reset are no longer honoured
+		user, err := rt.userService.ValidateSession(r.Context(), claims.UserID, claims.IssuedAt)
+		if err != nil {
+			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			return
+		}
+
+		// Tokens issued before scopes existed carry every scope of the role
+		scopes := claims.Scopes
+		if scopes == nil {
+			scopes = entity.DefaultScopes(user.Role)
+		}
+
+		// Store user ID, role and scopes in context for later use
+		ctx := context.WithValue(r.Context(), "userID", claims.UserID)
+		ctx = context.WithValue(ctx, "userRole", user.Role)
+		ctx = context.WithValue(ctx, "scopes", scopes)
+		rt.serveAuthenticated(w, r.WithContext(ctx), claims.UserID, next)
 	})
 }
 
-func (rt *Router) JRateLimiterMiddleware(next http.Handler) http.Handler {
+// AuthMiddleware accepts an API key in place of a JWT and falls back to JWTMiddleware otherwise.
+func (rt *Router) AuthMiddleware(next http.Handler) http.Handler {
+	jwtHandler := rt.JWTMiddleware(next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Retrieve user ID from context, set by JWTMiddleware
-		userID, ok := r.Context().Value("userID").(int)
-		if !ok {
-			http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		credential := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if rt.apiKeySvc == nil || !service.IsAPIKey(credential) {
+			jwtHandler.ServeHTTP(w, r)
 			return
 		}
 
-		// Check if the request is allowed by the rate limiter
-		allowed, err := rt.rateLimiter.AllowRequest(strconv.Itoa(userID))
+		key, err := rt.apiKeySvc.Authenticate(r.Context(), credential)
 		if err != nil {
-			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
 			return
 		}
+		r = r.WithContext(rt.readYourWrites(r, key.UserID))
 
-		if !allowed {
-			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+		// Keys of disabled accounts stop working, as do keys created before a password reset
+		user, err := rt.userService.ValidateSession(r.Context(), key.UserID, key.CreatedAt)
+		if err != nil {
+			http.Error(w, "Invalid API key", http.StatusUnauthorized)
 			return
 		}
 
-		// Proceed to the next handler
-		next.ServeHTTP(w, r)
+		ctx := context.WithValue(r.Context(), "userID", key.UserID)
+		ctx = context.WithValue(ctx, "userRole", user.Role)
+		ctx = context.WithValue(ctx, "scopes", key.Scopes)
+		ctx = context.WithValue(ctx, "apiKeyID", key.KeyID)
+		rt.serveAuthenticated(w, r.WithContext(ctx), key.UserID, next)
 	})
 }
+
+// RequireScope only lets requests through when the token or API key carries the scope.
+// It must run after JWTMiddleware or AuthMiddleware, which store the scopes in the request context.
+func (rt *Router) RequireScope(scope string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scopes, _ := r.Context().Value("scopes").([]string)
+			if !entity.HasScope(scopes, scope) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{
+					"error":          "insufficient_scope",
+					"message":        "the credential does not grant " + scope,
+					"required_scope": scope,
+				})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// scoped wraps a handler so it requires the given scope
+func (rt *Router) scoped(scope string, handler http.HandlerFunc) http.Handler {
+	return rt.RequireScope(scope)(handler)
+}
+
+// RequirePermission only lets requests through when the caller's role grants the permission.
+// It must run after JWTMiddleware, which stores the role in the request context.
+func (rt *Router) RequirePermission(permission entity.Permission) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value("userRole").(string)
+			if !entity.HasPermission(role, permission) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				json.NewEncoder(w).Encode(map[string]string{"error": "forbidden", "message": "missing permission " + string(permission)})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func (rt *Router) ServeHTML(w http.ResponseWriter, r *http.Request) {
-	htmlFile := filepath.Join(rt.Config.HtmlAssetsPath, "index.html")
+	htmlFile := filepath.Join(rt.currentConfig().HtmlAssetsPath, "index.html")
 	file, err := os.ReadFile(htmlFile)
 	if err != nil {
 		http.Error(w, "File not found", http.StatusNotFound)
@@ -163,15 +430,47 @@ func (rt *Router) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
 	webSocket := &entity.WebSocketConnection{Conn: conn}
 	// Store the WebSocket connection for further use (e.g., in WorkerPool jobs)
 	rt.WorkerPool.WebSocket = webSocket
+
+	// Browsers cannot set headers on WebSocket requests, so signed-in clients pass their token
+	// as a query parameter to receive notifications addressed to them
+	userID, ok := rt.webSocketUser(r)
+	if !ok {
+		return
+	}
+	rt.WorkerPool.Connections.Register(userID, webSocket)
+	go func() {
+		defer rt.WorkerPool.Connections.Unregister(userID, webSocket)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// webSocketUser resolves the user behind the token query parameter of a WebSocket request
+func (rt *Router) webSocketUser(r *http.Request) (int, bool) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		return 0, false
+	}
+	claims, err := rt.jwtService.ParseToken(token)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := rt.userService.ValidateSession(r.Context(), claims.UserID, claims.IssuedAt); err != nil {
+		return 0, false
+	}
+	return claims.UserID, true
 }
 
 func (rt *Router) DownloadFileHandler(w http.ResponseWriter, r *http.Request) {
-	log.Println("Downloading")
+	slog.Debug("Downloading")
 	vars := mux.Vars(r)
 	filename := vars["filename"]
 
 	// Set the path to the directory where your PDF files are stored
-	filepath := filepath.Join(rt.Config.PDFOutputPath, filename)
+	filepath := filepath.Join(rt.currentConfig().PDFOutputPath, filename)
 
 	http.ServeFile(w, r, filepath)
 }
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScopeEnforcement(t *testing.T) {
+	mockToDoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+	mockToDoSvc.On("GetAllTodos", mock.Anything, 1).Return([]entity.ToDo{}, nil)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	// A dashboard token that may only read
+	jwtSvc := &mocks.MockJWTValidator{Scopes: []string{entity.ScopeTodosRead}}
+	r := NewRouter(mockToDoSvc, mockUserSvc, jwtSvc, ratelimiter, pool, &mocks.MockEmailSender{})
+	r.InitRoutes()
+
+	t.Run("TestReadOnlyToken_GetAllowed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/todos", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+
+	t.Run("TestReadOnlyToken_DeleteAllForbidden", func(t *testing.T) {
+		req := httptest.NewRequest("DELETE", "/todos", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+		assert.Equal(t, "insufficient_scope", body["error"])
+		assert.Equal(t, entity.ScopeTodosDeleteAll, body["required_scope"])
+		mockToDoSvc.AssertNotCalled(t, "DeleteAllTodos", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestReadOnlyToken_CannotManageAPIKeys", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api-keys", nil)
+		req.Header.Set("Authorization", "Bearer dummytoken")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
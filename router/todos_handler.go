@@ -2,11 +2,15 @@ package router
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/service"
 	"github.com/srikanthbhandary/todo-server/utility"
 	"github.com/srikanthbhandary/todo-server/worker"
 )
@@ -27,26 +31,47 @@ func (rt *Router) CreateToDo(w http.ResponseWriter, r *http.Request) {
 	todo.UserID = userID // Associate the todo with the logged-in user
 
 	err = rt.todoService.AddToDo(r.Context(), &todo)
+	if errors.Is(err, repository.ErrListNotWritable) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to create todo", "message": err.Error()})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "failed to create todo", "message": err.Error()})
 		return
 	}
 
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d", todo.ToDoID))
+	w.Header().Set("ETag", todoETag(todo))
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{"message": "ToDo created successfully"})
+	json.NewEncoder(w).Encode(todo)
 }
 
 func (rt *Router) GetAllToDos(w http.ResponseWriter, r *http.Request) {
 	// Extract user ID from the context
 	userID := r.Context().Value("userID").(int)
 
-	todos, err := rt.todoService.GetAllTodos(r.Context(), userID)
+	var todos []entity.ToDo
+	var err error
+	switch assignedTo := r.URL.Query().Get("assigned_to"); assignedTo {
+	case "":
+		todos, err = rt.todoService.GetAllTodos(r.Context(), userID)
+	case "me":
+		todos, err = rt.todoService.GetAssignedTodos(r.Context(), userID)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "assigned_to only supports \"me\""})
+		return
+	}
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "failed to retrieve todos", "message": err.Error()})
 		return
 	}
+	if notModified(w, r, listETag(todos)) {
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(todos)
@@ -70,8 +95,43 @@ func (rt *Router) GetTodo(w http.ResponseWriter, r *http.Request) {
 		json.NewEncoder(w).Encode(map[string]string{"error": "todo not found"})
 		return
 	}
+	if notModified(w, r, todoETag(todo)) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(todo)
+}
 
+// UpdateToDo changes the fields present in the body; each change becomes a new revision
+func (rt *Router) UpdateToDo(w http.ResponseWriter, r *http.Request) {
+	var update entity.TodoUpdate
 	w.Header().Set("Content-Type", "application/json")
+
+	todoID, err := strconv.Atoi(mux.Vars(r)["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid JSON body", "message": err.Error()})
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(w, r, todoID)
+	if !ok {
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	todo, err := rt.todoService.UpdateToDo(r.Context(), userID, todoID, update, expectedVersion)
+	if err != nil {
+		writeTodoError(w, "failed to update todo", err)
+		return
+	}
+	w.Header().Set("ETag", todoETag(todo))
 	json.NewEncoder(w).Encode(todo)
 }
 
@@ -84,11 +144,21 @@ func (rt *Router) DeleteToDo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, ok := ifMatchVersion(w, r, todoID)
+	if !ok {
+		return
+	}
+
 	// Extract user ID from the context
 	userID := r.Context().Value("userID").(int)
 
-	err = rt.todoService.DeleteToDo(r.Context(), userID, todoID)
+	err = rt.todoService.DeleteToDo(r.Context(), userID, todoID, expectedVersion)
 	if err != nil {
+		var conflict *service.ConflictError
+		if errors.As(err, &conflict) {
+			writeTodoError(w, "failed to delete todo", err)
+			return
+		}
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "failed to delete todo", "message": err.Error()})
 		return
@@ -111,6 +181,84 @@ func (rt *Router) DeleteAllTodos(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// GetTrash lists the deleted todos that can still be restored
+func (rt *Router) GetTrash(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(int)
+	w.Header().Set("Content-Type", "application/json")
+
+	trash, err := rt.todoService.GetTrash(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to retrieve trash", "message": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(trash)
+}
+
+// SearchTodos runs a full-text search over the caller's todos, most relevant first
+func (rt *Router) SearchTodos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	limit, offset, err := pagination(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid pagination", "message": err.Error()})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	results, err := rt.todoService.SearchTodos(r.Context(), userID, r.URL.Query().Get("q"), limit, offset)
+	if errors.Is(err, service.ErrInvalidSearch) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid search query", "message": err.Error()})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to search todos", "message": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(results)
+}
+
+// RestoreToDo takes a todo out of the trash
+func (rt *Router) RestoreToDo(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	todoID, err := strconv.Atoi(mux.Vars(r)["todoID"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid todo ID"})
+		return
+	}
+
+	userID := r.Context().Value("userID").(int)
+	todo, err := rt.todoService.RestoreToDo(r.Context(), userID, todoID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to restore todo", "message": err.Error()})
+		return
+	}
+	w.Header().Set("ETag", todoETag(todo))
+	json.NewEncoder(w).Encode(todo)
+}
+
+// UndoDeleteAll brings back the todos removed by the last "delete all", within a short window
+func (rt *Router) UndoDeleteAll(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(int)
+	w.Header().Set("Content-Type", "application/json")
+
+	restored, err := rt.todoService.UndoDeleteAll(r.Context(), userID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, service.ErrNothingToUndo) {
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"error": "failed to undo delete", "message": err.Error()})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int{"restored": restored})
+}
+
 func (rt *Router) DownloadToDos(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("userID").(int)
 	user, err := rt.userService.GetUserByID(r.Context(), userID)
@@ -131,7 +279,7 @@ func (rt *Router) DownloadToDos(w http.ResponseWriter, r *http.Request) {
 		UserName:  user.UserName,
 		Email:     user.Email,
 		Todos:     todos,
-		Generator: utility.NewPDFGenerator(rt.Config.PDFOutputPath),
+		Generator: utility.NewPDFGenerator(rt.currentConfig().PDFOutputPath),
 		WebSocket: rt.WorkerPool.WebSocket, // Use the active WebSocket connection
 	}
 	rt.WorkerPool.EnqueueJob(pdfJob)
@@ -139,3 +287,32 @@ func (rt *Router) DownloadToDos(w http.ResponseWriter, r *http.Request) {
 	// Inform the client the job is queued
 	w.Write([]byte("PDF generation started. You'll be notified when it's ready for download."))
 }
+
+// writeTodoError maps todo service errors to HTTP statuses.
+// A version conflict is a failed precondition and carries the current ETag when the todo is still readable.
+func writeTodoError(w http.ResponseWriter, message string, err error) {
+	var conflict *service.ConflictError
+	if errors.As(err, &conflict) && conflict.Current != nil {
+		w.Header().Set("ETag", todoETag(*conflict.Current))
+	}
+	w.WriteHeader(todoErrorStatus(err))
+	json.NewEncoder(w).Encode(map[string]string{"error": message, "message": err.Error()})
+}
+
+// todoErrorStatus returns the HTTP status for an error from the todo service
+func todoErrorStatus(err error) int {
+	var conflict *service.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, service.ErrInvalidTodo), errors.Is(err, service.ErrInvalidBatchOp):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrBatchAborted):
+		return http.StatusFailedDependency
+	case errors.Is(err, repository.ErrListNotWritable):
+		return http.StatusForbidden
+	case errors.Is(err, repository.ErrNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -4,17 +4,19 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-redis/redis"
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/srikanthbhandary/todo-server/mocks"
-
+	"github.com/srikanthbhandary/todo-server/service"
 	"github.com/srikanthbhandary/todo-server/worker"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -34,9 +36,13 @@ func TestCreateToDo(t *testing.T) {
 
 	// Case 1: Test normal behavior
 	mockRedis.On("Incr", "rate_limit:1").Return(intCmd)
+	mockRedis.On("PTTL", "rate_limit:1").Return(redis.NewDurationResult(10*time.Second, nil))
 	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(boolCmd)
 	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 2, 1*time.Second)
 
+	// Every token presented by these tests belongs to a live session
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
 	t.Run("TestCreateToDO_SUCCESS", func(t *testing.T) {
 		jobChannel := make(chan worker.Job, 10)
 		pool := worker.NewWorkerPool(3, jobChannel)
@@ -107,3 +113,205 @@ func TestCreateToDo(t *testing.T) {
 
 	})
 }
+
+func TestTrashHandlers(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(todoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{})
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestGetTrash", func(t *testing.T) {
+		deletedAt := time.Now()
+		todoSvc.On("GetTrash", mock.Anything, 1).Return([]entity.ToDo{{ToDoID: 4, DeletedAt: &deletedAt}}, nil).Once()
+
+		rr := serve("GET", "/todos/trash")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"deleted_at"`)
+	})
+
+	t.Run("TestRestoreToDo_NotInTrash", func(t *testing.T) {
+		todoSvc.On("RestoreToDo", mock.Anything, 1, 4).Return(entity.ToDo{}, errors.New("todo not found")).Once()
+
+		rr := serve("POST", "/todos/4/restore")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Run("TestUndoDeleteAll", func(t *testing.T) {
+		todoSvc.On("UndoDeleteAll", mock.Anything, 1).Return(2, nil).Once()
+
+		rr := serve("POST", "/todos/undo-delete-all")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.JSONEq(t, `{"restored": 2}`, rr.Body.String())
+	})
+
+	t.Run("TestUndoDeleteAll_WindowExpired", func(t *testing.T) {
+		todoSvc.On("UndoDeleteAll", mock.Anything, 1).Return(0, service.ErrNothingToUndo).Once()
+
+		rr := serve("POST", "/todos/undo-delete-all")
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+}
+
+func TestConditionalRequests(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(todoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{})
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(method, path, body string, header map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer token")
+		for name, value := range header {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	todo := entity.ToDo{ToDoID: 3, Title: "Milk", Version: 2}
+	todoSvc.On("GetTodo", mock.Anything, 1, 3).Return(todo, nil)
+
+	t.Run("TestGetTodo_ETag", func(t *testing.T) {
+		rr := serve("GET", "/todos/3", "", nil)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3-2"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("TestGetTodo_NotModified", func(t *testing.T) {
+		rr := serve("GET", "/todos/3", "", map[string]string{"If-None-Match": `"3-1", "3-2"`})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+		assert.Empty(t, rr.Body.String())
+	})
+
+	t.Run("TestGetAllToDos_NotModified", func(t *testing.T) {
+		todoSvc.On("GetAllTodos", mock.Anything, 1).Return([]entity.ToDo{todo}, nil)
+
+		first := serve("GET", "/todos", "", nil)
+		assert.Equal(t, http.StatusOK, first.Code)
+		etag := first.Header().Get("ETag")
+		assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+		rr := serve("GET", "/todos", "", map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, rr.Code)
+	})
+
+	t.Run("TestUpdateToDo_IfMatch", func(t *testing.T) {
+		updated := entity.ToDo{ToDoID: 3, Title: "Oat milk", Version: 3}
+		todoSvc.On("UpdateToDo", mock.Anything, 1, 3, mock.Anything, 2).Return(updated, nil).Once()
+
+		rr := serve("PATCH", "/todos/3", `{"title": "Oat milk"}`, map[string]string{"If-Match": `"3-2"`})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, `"3-3"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("TestUpdateToDo_IfMatchOtherTodo", func(t *testing.T) {
+		rr := serve("PATCH", "/todos/3", `{"title": "Oat milk"}`, map[string]string{"If-Match": `"4-2"`})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	})
+
+	t.Run("TestUpdateToDo_Conflict", func(t *testing.T) {
+		current := entity.ToDo{ToDoID: 3, Version: 5}
+		todoSvc.On("UpdateToDo", mock.Anything, 1, 3, mock.Anything, 1).
+			Return(entity.ToDo{}, &service.ConflictError{TodoID: 3, Current: &current}).Once()
+
+		rr := serve("PATCH", "/todos/3", `{"title": "Stale"}`, map[string]string{"If-Match": `"3-1"`})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.Equal(t, `"3-5"`, rr.Header().Get("ETag"))
+	})
+
+	t.Run("TestDeleteToDo_Conflict", func(t *testing.T) {
+		todoSvc.On("DeleteToDo", mock.Anything, 1, 3, 1).Return(&service.ConflictError{TodoID: 3}).Once()
+
+		rr := serve("DELETE", "/todos/3", "", map[string]string{"If-Match": `"3-1"`})
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	})
+}
+
+func TestSearchTodos(t *testing.T) {
+	todoSvc := new(mocks.MockToDoService)
+	mockUserSvc := new(mocks.MockUserService)
+	mockRedis := &mocks.MockRedisClient{}
+
+	mockRedis.On("Incr", "rate_limit:1").Return(redis.NewIntResult(1, nil))
+	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(redis.NewBoolResult(true, nil))
+	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 100, 10*time.Second)
+
+	jobChannel := make(chan worker.Job, 10)
+	pool := worker.NewWorkerPool(1, jobChannel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool.Init(ctx)
+
+	r := NewRouter(todoSvc, mockUserSvc, new(mocks.MockJWTValidator), ratelimiter, pool, &mocks.MockEmailSender{})
+	r.InitRoutes()
+
+	mockUserSvc.On("ValidateSession", mock.Anything, 1, mock.Anything).Return(&entity.User{UserID: 1, Role: entity.RoleUser}, nil)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("TestSearchTodos_Ranked", func(t *testing.T) {
+		todoSvc.On("SearchTodos", mock.Anything, 1, `"march invoice" pay*`, 10, 20).Return([]entity.TodoSearchResult{
+			{Todo: entity.ToDo{ToDoID: 3, Title: "Pay March invoice"}, Rank: 0.6, Title: "<mark>Pay</mark> <mark>March</mark> <mark>invoice</mark>"},
+		}, nil).Once()
+
+		rr := serve(`/todos/search?q=%22march+invoice%22+pay*&limit=10&offset=20`)
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"title_highlight"`)
+		assert.Contains(t, rr.Body.String(), `"rank":0.6`)
+	})
+
+	t.Run("TestSearchTodos_EmptyQuery", func(t *testing.T) {
+		todoSvc.On("SearchTodos", mock.Anything, 1, "", 50, 0).Return(nil, service.ErrInvalidSearch).Once()
+
+		rr := serve("/todos/search")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+
+	t.Run("TestSearchTodos_InvalidPagination", func(t *testing.T) {
+		rr := serve("/todos/search?q=invoice&limit=-1")
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
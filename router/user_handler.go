@@ -2,12 +2,18 @@ package router
 
 import (
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
 	"strconv"
 
 	"github.com/gorilla/mux"
 	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/worker"
 )
 
 func (rt *Router) CreateUser(w http.ResponseWriter, r *http.Request) {
@@ -23,11 +29,17 @@ func (rt *Router) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	err = rt.userService.CreateUser(r.Context(), &user)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		status := http.StatusInternalServerError
+		if errors.Is(err, repository.ErrConflict) {
+			status = http.StatusConflict // the username or email is taken
+		}
+		w.WriteHeader(status)
 		json.NewEncoder(w).Encode(map[string]string{"error": "failed to create user", "message": err.Error()})
 		return
 	}
 
+	rt.sendVerificationEmail(r, user.Email)
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]string{"message": "User created successfully"})
 }
@@ -35,13 +47,22 @@ func (rt *Router) CreateUser(w http.ResponseWriter, r *http.Request) {
 func (rt *Router) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	userID, err := strconv.Atoi(vars["id"])
-	log.Println(userID, err)
+	w.Header().Set("Content-Type", "application/json")
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "invalid user ID"})
 		return
 	}
 
+	// Users may only look themselves up unless their role allows viewing others
+	callerID := r.Context().Value("userID").(int)
+	role, _ := r.Context().Value("userRole").(string)
+	if userID != callerID && !entity.HasPermission(role, entity.PermissionViewUsers) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "forbidden"})
+		return
+	}
+
 	user, err := rt.userService.GetUserByID(r.Context(), userID)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -49,14 +70,28 @@ func (rt *Router) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	json.NewEncoder(w).Encode(user.Profile())
+}
+
+func (rt *Router) GetMe(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value("userID").(int)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+
+	user, err := rt.userService.GetUserByID(r.Context(), userID)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "user not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(user.Profile())
 }
 
 func (rt *Router) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var loginRequest struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		Scopes   []string `json:"scopes"` // optional, narrows the token, e.g. to todos:read for dashboards
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&loginRequest)
@@ -66,24 +101,127 @@ func (rt *Router) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	clientIP := clientIP(r)
+	if rt.loginThrottler != nil {
+		wait, err := rt.loginThrottler.Blocked(loginRequest.Username, clientIP)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Internal Server Error"})
+			return
+		}
+		if wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(map[string]string{"error": "too many failed login attempts, try again later"})
+			return
+		}
+	}
+
 	// Get user from the database (this is a simplified example)
 	user, err := rt.userService.GetUserByUserName(r.Context(), loginRequest.Username)
-	if err != nil || !rt.userService.CheckPasswordHash(loginRequest.Password, user.Password) {
+	if err != nil {
+		// Spend the same time on unknown usernames so they cannot be told apart
+		rt.userService.CheckPasswordHash(loginRequest.Password, dummyPasswordHash)
+		user = nil
+	}
+	if user == nil || !rt.userService.CheckPasswordHash(loginRequest.Password, user.Password) {
+		rt.recordLoginFailure(loginRequest.Username, clientIP, user)
+		failure := &entity.AuditEvent{Action: "user.login_failed", TargetType: "user", TargetID: loginRequest.Username}
+		if user != nil {
+			failure.SubjectID = &user.UserID
+		}
+		rt.recordAudit(r, failure)
 
 		w.WriteHeader(http.StatusUnauthorized)
 		json.NewEncoder(w).Encode(map[string]string{"error": "invalid credentials"})
 		return
 	}
 
+	if rt.loginThrottler != nil {
+		if err := rt.loginThrottler.RecordSuccess(loginRequest.Username); err != nil {
+			slog.Error("failed to reset login failures", "error", err)
+		}
+	}
+
+	if user.Disabled {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "account is disabled"})
+		return
+	}
+
+	if cfg := rt.currentConfig(); cfg != nil && cfg.RequireEmailVerification && !user.EmailVerified {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "email address not verified"})
+		return
+	}
+
+	allowedScopes := entity.DefaultScopes(user.Role)
+	scopes := allowedScopes
+	if len(loginRequest.Scopes) > 0 {
+		for _, scope := range loginRequest.Scopes {
+			if !entity.HasScope(allowedScopes, scope) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid scope", "message": scope})
+				return
+			}
+		}
+		scopes = loginRequest.Scopes
+	}
+
 	// Generate JWT token
-	token, err := rt.jwtService.GenerateToken(user.UserID)
+	token, err := rt.jwtService.GenerateToken(user.UserID, scopes)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "could not generate token"})
 		return
 	}
 
+	rt.recordAudit(r, &entity.AuditEvent{ActorID: &user.UserID, SubjectID: &user.UserID, Action: "user.login",
+		TargetType: "user", TargetID: strconv.Itoa(user.UserID)})
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{"token": token})
 }
+
+// recordAudit appends an event for something the router does itself, such as logins
+func (rt *Router) recordAudit(r *http.Request, event *entity.AuditEvent) {
+	if rt.auditSvc == nil {
+		return
+	}
+	if err := rt.auditSvc.Record(r.Context(), event); err != nil {
+		slog.Error("failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+// dummyPasswordHash is compared against when the username does not exist
+const dummyPasswordHash = "$2a$10$kd4s1ToXfxe0JqnN7qGi8e2zSQudRxs6.9CzgcFO2YEzho20FdPEO"
+
+// recordLoginFailure counts a failed login and tells the owner when their account gets locked
+func (rt *Router) recordLoginFailure(username, ip string, user *entity.User) {
+	if rt.loginThrottler == nil {
+		return
+	}
+
+	lockedOut, err := rt.loginThrottler.RecordFailure(username, ip)
+	if err != nil {
+		slog.Error("failed to record login failure", "error", err)
+		return
+	}
+	if !lockedOut || user == nil || user.Email == "" {
+		return
+	}
+
+	body := fmt.Sprintf("Hi %s,\n\nYour account was temporarily locked after repeated failed login attempts. "+
+		"You can try again later.\nIf this was not you, consider resetting your password.", user.UserName)
+	rt.WorkerPool.EnqueueJob(worker.NewEmailJob(rt.EmailSender, []string{user.Email}, "Your account has been temporarily locked", body))
+}
+
+// clientIP returns the address of the peer that sent the request
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +14,7 @@ import (
 	"github.com/go-redis/redis"
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
 
 	"github.com/srikanthbhandary/todo-server/worker"
 	"github.com/stretchr/testify/assert"
@@ -33,6 +36,7 @@ func TestCreateUser_Success(t *testing.T) {
 
 	// Case 1: Test normal behavior
 	mockRedis.On("Incr", "rate_limit:1").Return(intCmd)
+	mockRedis.On("PTTL", "rate_limit:1").Return(redis.NewDurationResult(10*time.Second, nil))
 	mockRedis.On("Expire", "rate_limit:1", 10*time.Second).Return(boolCmd)
 	ratelimiter := NewRedisRateLimiter(context.TODO(), mockRedis, 2, 1*time.Second)
 
@@ -95,6 +99,28 @@ func TestCreateUser_Success(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rr.Code)
 	})
 
+	t.Run("TestCreateUser_Taken", func(t *testing.T) {
+		jobChannel := make(chan worker.Job, 10)
+		pool := worker.NewWorkerPool(3, jobChannel)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		defer cancel()
+		pool.Init(ctx)
+
+		r := NewRouter(mockToDoSvc, mockUserSvc, jwtSvc, ratelimiter, pool, emailSender)
+		r.InitRoutes()
+
+		user := &entity.User{UserName: "taken", Password: "password"}
+		mockUserSvc.On("CreateUser", mock.Anything, user).Return(fmt.Errorf("%w: username is taken", repository.ErrConflict))
+
+		body, _ := json.Marshal(user)
+		req := httptest.NewRequest("POST", "/users", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusConflict, rr.Code)
+	})
+
 	t.Run("TestLoginUser_Success", func(t *testing.T) {
 		jobChannel := make(chan worker.Job, 10)
 		pool := worker.NewWorkerPool(3, jobChannel)
@@ -122,7 +148,7 @@ func TestCreateUser_Success(t *testing.T) {
 
 		mockUserSvc.On("GetUserByUserName", mock.Anything, loginRequest.Username).Return(user, nil)
 		mockUserSvc.On("CheckPasswordHash", loginRequest.Password, user.Password).Return(true)
-		jwtSvc.On("GenerateToken", user.UserID).Return("dummytoken", nil)
+		jwtSvc.On("GenerateToken", user.UserID, entity.DefaultScopes(user.Role)).Return("dummytoken", nil)
 
 		body, _ := json.Marshal(loginRequest)
 		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(body))
@@ -140,4 +166,44 @@ func TestCreateUser_Success(t *testing.T) {
 		mockUserSvc.AssertExpectations(t)
 		jwtSvc.AssertExpectations(t)
 	})
+
+	t.Run("TestLoginUser_UnknownUserLockout", func(t *testing.T) {
+		jobChannel := make(chan worker.Job, 10)
+		pool := worker.NewWorkerPool(3, jobChannel)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		defer cancel()
+		pool.Init(ctx)
+
+		userSvc := new(mocks.MockUserService)
+		throttler := new(mocks.MockLoginThrottler)
+		r := NewRouter(mockToDoSvc, userSvc, jwtSvc, ratelimiter, pool, emailSender, WithLoginThrottler(throttler))
+		r.InitRoutes()
+
+		throttler.On("Blocked", "ghost", "192.0.2.1").Return(time.Duration(0), nil).Once()
+		userSvc.On("GetUserByUserName", mock.Anything, "ghost").Return(&entity.User{}, errors.New("user not found"))
+		// The dummy hash keeps unknown usernames as slow as wrong passwords
+		userSvc.On("CheckPasswordHash", "guess", dummyPasswordHash).Return(false)
+		throttler.On("RecordFailure", "ghost", "192.0.2.1").Return(true, nil)
+
+		body := []byte(`{"username": "ghost", "password": "guess"}`)
+		req := httptest.NewRequest("POST", "/login", bytes.NewBuffer(body))
+		rr := httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rr.Code)
+		userSvc.AssertExpectations(t)
+		throttler.AssertExpectations(t)
+
+		// Once locked out, further attempts are refused before any credential check
+		throttler.On("Blocked", "ghost", "192.0.2.1").Return(90*time.Second, nil)
+
+		req = httptest.NewRequest("POST", "/login", bytes.NewBuffer(body))
+		rr = httptest.NewRecorder()
+		r.Router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "90", rr.Header().Get("Retry-After"))
+		userSvc.AssertNumberOfCalls(t, "GetUserByUserName", 1)
+	})
 }
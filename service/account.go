@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+var (
+	// ErrInvalidToken is returned when a verification or reset token cannot be redeemed.
+	ErrInvalidToken = errors.New("token is invalid, expired or already used")
+
+	// ErrAlreadyVerified is returned when verification is requested for a verified address.
+	ErrAlreadyVerified = errors.New("email address is already verified")
+
+	// ErrEmptyPassword is returned when a password reset does not carry a new password.
+	ErrEmptyPassword = errors.New("password must not be empty")
+)
+
+// AccountService covers the email verification and password reset flows.
+// Issue methods return the user and the token so the caller can deliver it;
+// the methods redeeming a token return the ID of the user it belonged to.
+type AccountService interface {
+	IssueEmailVerification(ctx context.Context, email string) (*entity.User, string, error)
+	VerifyEmail(ctx context.Context, token string) (int, error)
+	IssuePasswordReset(ctx context.Context, email string) (*entity.User, string, error)
+	ResetPassword(ctx context.Context, token, newPassword string) (int, error)
+}
+
+// AccountServiceImpl is the implementation of AccountService interface
+type AccountServiceImpl struct {
+	users     repository.UserRepository
+	tokens    repository.UserTokenRepository
+	secretKey string
+}
+
+// NewAccountService creates a new instance of AccountServiceImpl
+func NewAccountService(users repository.UserRepository, tokens repository.UserTokenRepository, secret string) *AccountServiceImpl {
+	return &AccountServiceImpl{users: users, tokens: tokens, secretKey: secret}
+}
+
+// IssueEmailVerification creates a verification token for the account owning email
+func (s *AccountServiceImpl) IssueEmailVerification(ctx context.Context, email string) (*entity.User, string, error) {
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.EmailVerified {
+		return user, "", ErrAlreadyVerified
+	}
+
+	token, err := s.issue(ctx, user.UserID, entity.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// VerifyEmail redeems a verification token and marks the address as verified
+func (s *AccountServiceImpl) VerifyEmail(ctx context.Context, token string) (int, error) {
+	userID, err := s.redeem(ctx, token, entity.TokenPurposeEmailVerification)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.users.MarkEmailVerified(ctx, userID); err != nil {
+		return 0, err
+	}
+	return userID, s.tokens.InvalidateTokens(ctx, userID, entity.TokenPurposeEmailVerification)
+}
+
+// IssuePasswordReset creates a password reset token for the account owning email
+func (s *AccountServiceImpl) IssuePasswordReset(ctx context.Context, email string) (*entity.User, string, error) {
+	user, err := s.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.issue(ctx, user.UserID, entity.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// ResetPassword redeems a reset token, stores the new password and revokes existing sessions
+func (s *AccountServiceImpl) ResetPassword(ctx context.Context, token, newPassword string) (int, error) {
+	if newPassword == "" {
+		return 0, ErrEmptyPassword
+	}
+
+	userID, err := s.redeem(ctx, token, entity.TokenPurposePasswordReset)
+	if err != nil {
+		return 0, err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.users.UpdatePassword(ctx, userID, string(hashedPassword)); err != nil {
+		return 0, err
+	}
+	return userID, s.tokens.InvalidateTokens(ctx, userID, entity.TokenPurposePasswordReset)
+}
+
+// issue stores a new token record and returns its signed form
+func (s *AccountServiceImpl) issue(ctx context.Context, userID int, purpose entity.TokenPurpose, ttl time.Duration) (string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+
+	record := &entity.UserToken{
+		TokenID:   id,
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := s.tokens.CreateToken(ctx, record); err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{
+		"sub":     userID,
+		"jti":     record.TokenID,
+		"purpose": string(purpose),
+		"exp":     record.ExpiresAt.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.signingKey(purpose))
+}
+
+// redeem checks the token signature and consumes its server-side record
+func (s *AccountServiceImpl) redeem(ctx context.Context, tokenString string, purpose entity.TokenPurpose) (int, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+		return s.signingKey(purpose), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != string(purpose) {
+		return 0, ErrInvalidToken
+	}
+	tokenID, ok := claims["jti"].(string)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+
+	record, err := s.tokens.ConsumeToken(ctx, tokenID, purpose)
+	if errors.Is(err, repository.ErrNotFound) {
+		return 0, ErrInvalidToken
+	}
+	if err != nil {
+		return 0, err
+	}
+	return record.UserID, nil
+}
+
+// signingKey derives a per-purpose key so these tokens can never pass as access tokens
+func (s *AccountServiceImpl) signingKey(purpose entity.TokenPurpose) []byte {
+	return []byte(s.secretKey + ":" + string(purpose))
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAccountService(t *testing.T) {
+	t.Run("TestVerifyEmail_SUCCESS", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		tokenRepo := new(mocks.MockUserTokenRepository)
+		service := NewAccountService(userRepo, tokenRepo, "test")
+
+		user := &entity.User{UserID: 7, UserName: "testuser", Email: "test@example.com"}
+		var issued *entity.UserToken
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("CreateToken", mock.Anything, mock.AnythingOfType("*entity.UserToken")).
+			Run(func(args mock.Arguments) { issued = args.Get(1).(*entity.UserToken) }).
+			Return(nil)
+
+		_, token, err := service.IssueEmailVerification(context.Background(), user.Email)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.TokenPurposeEmailVerification, issued.Purpose)
+
+		tokenRepo.On("ConsumeToken", mock.Anything, issued.TokenID, entity.TokenPurposeEmailVerification).
+			Return(&entity.UserToken{TokenID: issued.TokenID, UserID: user.UserID}, nil)
+		tokenRepo.On("InvalidateTokens", mock.Anything, user.UserID, entity.TokenPurposeEmailVerification).Return(nil)
+		userRepo.On("MarkEmailVerified", mock.Anything, user.UserID).Return(nil)
+
+		userID, err := service.VerifyEmail(context.Background(), token)
+		assert.NoError(t, err)
+		assert.Equal(t, user.UserID, userID)
+		userRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("TestIssueEmailVerification_AlreadyVerified", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		tokenRepo := new(mocks.MockUserTokenRepository)
+		service := NewAccountService(userRepo, tokenRepo, "test")
+
+		user := &entity.User{UserID: 7, Email: "test@example.com", EmailVerified: true}
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+
+		_, _, err := service.IssueEmailVerification(context.Background(), user.Email)
+		assert.ErrorIs(t, err, ErrAlreadyVerified)
+		tokenRepo.AssertNotCalled(t, "CreateToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestResetPassword_SUCCESS", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		tokenRepo := new(mocks.MockUserTokenRepository)
+		service := NewAccountService(userRepo, tokenRepo, "test")
+
+		user := &entity.User{UserID: 7, Email: "test@example.com"}
+		var issued *entity.UserToken
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("CreateToken", mock.Anything, mock.AnythingOfType("*entity.UserToken")).
+			Run(func(args mock.Arguments) { issued = args.Get(1).(*entity.UserToken) }).
+			Return(nil)
+
+		_, token, err := service.IssuePasswordReset(context.Background(), user.Email)
+		assert.NoError(t, err)
+
+		tokenRepo.On("ConsumeToken", mock.Anything, issued.TokenID, entity.TokenPurposePasswordReset).
+			Return(&entity.UserToken{TokenID: issued.TokenID, UserID: user.UserID}, nil)
+		tokenRepo.On("InvalidateTokens", mock.Anything, user.UserID, entity.TokenPurposePasswordReset).Return(nil)
+		userRepo.On("UpdatePassword", mock.Anything, user.UserID, mock.MatchedBy(func(hash string) bool {
+			return hash != "" && hash != "newpassword"
+		})).Return(nil)
+
+		userID, err := service.ResetPassword(context.Background(), token, "newpassword")
+		assert.NoError(t, err)
+		assert.Equal(t, user.UserID, userID)
+		userRepo.AssertExpectations(t)
+		tokenRepo.AssertExpectations(t)
+	})
+
+	t.Run("TestResetPassword_WrongPurpose", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		tokenRepo := new(mocks.MockUserTokenRepository)
+		service := NewAccountService(userRepo, tokenRepo, "test")
+
+		user := &entity.User{UserID: 7, Email: "test@example.com"}
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("CreateToken", mock.Anything, mock.Anything).Return(nil)
+
+		// A verification token must not be accepted as a reset token
+		_, token, err := service.IssueEmailVerification(context.Background(), user.Email)
+		assert.NoError(t, err)
+
+		_, err = service.ResetPassword(context.Background(), token, "newpassword")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		tokenRepo.AssertNotCalled(t, "ConsumeToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestResetPassword_AccessTokenRejected", func(t *testing.T) {
+		service := NewAccountService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), "test")
+
+		accessToken, err := NewJWTService("test").GenerateToken(7, nil)
+		assert.NoError(t, err)
+
+		_, err = service.ResetPassword(context.Background(), accessToken, "newpassword")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+
+	t.Run("TestVerifyEmail_StorageFailure", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		tokenRepo := new(mocks.MockUserTokenRepository)
+		service := NewAccountService(userRepo, tokenRepo, "test")
+
+		user := &entity.User{UserID: 7, Email: "test@example.com"}
+		userRepo.On("GetUserByEmail", mock.Anything, user.Email).Return(user, nil)
+		tokenRepo.On("CreateToken", mock.Anything, mock.Anything).Return(nil)
+		_, token, err := service.IssueEmailVerification(context.Background(), user.Email)
+		assert.NoError(t, err)
+
+		// A database outage must not be reported as a bad token
+		tokenRepo.On("ConsumeToken", mock.Anything, mock.Anything, entity.TokenPurposeEmailVerification).Return(nil, assert.AnError)
+
+		_, err = service.VerifyEmail(context.Background(), token)
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+// APIKeyPrefix starts every API key so they are easy to tell apart from JWTs and to spot in leaks.
+const APIKeyPrefix = "tds_"
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, malformed, revoked or expired keys.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrInvalidScope is returned when creating a key with a scope that does not exist.
+	ErrInvalidScope = errors.New("invalid scope")
+)
+
+// apiKeyScopes lists the scopes a key can be created with
+var apiKeyScopes = []string{entity.ScopeTodosRead, entity.ScopeTodosWrite, entity.ScopeTodosDeleteAll}
+
+// defaultAPIKeyScopes are granted when a key is created without scopes.
+// Deleting every todo is destructive enough that it has to be asked for explicitly.
+var defaultAPIKeyScopes = []string{entity.ScopeTodosRead, entity.ScopeTodosWrite}
+
+// APIKeyService manages personal API keys and authenticates requests made with them.
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error)
+	ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID int) error
+	Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error)
+}
+
+// APIKeyServiceImpl is the implementation of APIKeyService interface
+type APIKeyServiceImpl struct {
+	repo repository.APIKeyRepository
+}
+
+// NewAPIKeyService creates a new instance of APIKeyServiceImpl
+func NewAPIKeyService(repo repository.APIKeyRepository) *APIKeyServiceImpl {
+	return &APIKeyServiceImpl{repo: repo}
+}
+
+// CreateAPIKey creates a key and returns it along with its secret, which is never shown again.
+// A key created without scopes can read and write todos.
+func (s *APIKeyServiceImpl) CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	if len(scopes) == 0 {
+		scopes = defaultAPIKeyScopes
+	}
+	for _, scope := range scopes {
+		if !entity.HasScope(apiKeyScopes, scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	prefix, err := randomHex(4)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &entity.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hashSecret(secret),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", err
+	}
+	return key, APIKeyPrefix + prefix + "_" + secret, nil
+}
+
+// ListAPIKeys returns the user's keys without their secrets
+func (s *APIKeyServiceImpl) ListAPIKeys(ctx context.Context, userID int) ([]entity.APIKey, error) {
+	return s.repo.ListAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey revokes one of the user's keys
+func (s *APIKeyServiceImpl) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	return s.repo.RevokeAPIKey(ctx, userID, keyID)
+}
+
+// Authenticate resolves a raw key to its record and records the use
+func (s *APIKeyServiceImpl) Authenticate(ctx context.Context, rawKey string) (*entity.APIKey, error) {
+	parts := strings.SplitN(strings.TrimPrefix(rawKey, APIKeyPrefix), "_", 2)
+	if !strings.HasPrefix(rawKey, APIKeyPrefix) || len(parts) != 2 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.GetAPIKeyByPrefix(ctx, parts[0])
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hashSecret(parts[1]))) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.KeyID); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// IsAPIKey reports whether a bearer credential looks like an API key rather than a JWT
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// hashSecret hashes a key secret for storage; secrets are random so no salt or stretching is needed
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
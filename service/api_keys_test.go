@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyService(t *testing.T) {
+	t.Run("TestCreateAndAuthenticate_SUCCESS", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo)
+
+		var stored *entity.APIKey
+		mockRepo.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*entity.APIKey")).
+			Run(func(args mock.Arguments) {
+				stored = args.Get(1).(*entity.APIKey)
+				stored.KeyID = 5
+			}).
+			Return(nil)
+
+		key, secret, err := service.CreateAPIKey(context.Background(), 1, "ci", []string{entity.ScopeTodosRead}, nil)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(secret, APIKeyPrefix+key.Prefix+"_"))
+		assert.NotContains(t, stored.Hash, strings.Split(secret, "_")[2], "the secret must not be stored in clear")
+
+		mockRepo.On("GetAPIKeyByPrefix", mock.Anything, key.Prefix).Return(stored, nil)
+		mockRepo.On("TouchAPIKey", mock.Anything, 5).Return(nil)
+
+		result, err := service.Authenticate(context.Background(), secret)
+		assert.NoError(t, err)
+		assert.Equal(t, 5, result.KeyID)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("TestCreateAPIKey_DefaultScopes", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo)
+		mockRepo.On("CreateAPIKey", mock.Anything, mock.Anything).Return(nil)
+
+		key, _, err := service.CreateAPIKey(context.Background(), 1, "ci", nil, nil)
+		assert.NoError(t, err)
+		assert.True(t, key.HasScope(entity.ScopeTodosRead))
+		assert.True(t, key.HasScope(entity.ScopeTodosWrite))
+		assert.False(t, key.HasScope(entity.ScopeTodosDeleteAll))
+	})
+
+	t.Run("TestCreateAPIKey_InvalidScope", func(t *testing.T) {
+		mockRepo := new(mocks.MockAPIKeyRepository)
+		service := NewAPIKeyService(mockRepo)
+
+		_, _, err := service.CreateAPIKey(context.Background(), 1, "ci", []string{"admin"}, nil)
+		assert.ErrorIs(t, err, ErrInvalidScope)
+		mockRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestAuthenticate_Rejected", func(t *testing.T) {
+		past := time.Now().Add(-time.Hour)
+		hash := hashSecret("secret")
+
+		tests := []struct {
+			name   string
+			rawKey string
+			stored *entity.APIKey
+		}{
+			{"wrong secret", "tds_abcd1234_other", &entity.APIKey{KeyID: 1, Hash: hash}},
+			{"revoked", "tds_abcd1234_secret", &entity.APIKey{KeyID: 1, Hash: hash, RevokedAt: &past}},
+			{"expired", "tds_abcd1234_secret", &entity.APIKey{KeyID: 1, Hash: hash, ExpiresAt: &past}},
+			{"unknown prefix", "tds_abcd1234_secret", nil},
+			{"malformed", "tds_nosecret", nil},
+		}
+
+		for _, tt := range tests {
+			mockRepo := new(mocks.MockAPIKeyRepository)
+			service := NewAPIKeyService(mockRepo)
+			if tt.stored != nil {
+				mockRepo.On("GetAPIKeyByPrefix", mock.Anything, "abcd1234").Return(tt.stored, nil)
+			} else {
+				mockRepo.On("GetAPIKeyByPrefix", mock.Anything, "abcd1234").Return(nil, errors.New("api key not found"))
+			}
+
+			_, err := service.Authenticate(context.Background(), tt.rawKey)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey, tt.name)
+			mockRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything)
+		}
+	})
+}
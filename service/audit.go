@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+// AuditService records and queries the audit log
+type AuditService interface {
+	Record(ctx context.Context, event *entity.AuditEvent) error
+	ListEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error)
+	PurgeExpired(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// AuditServiceImpl is the implementation of AuditService interface
+type AuditServiceImpl struct {
+	repo repository.AuditRepository
+}
+
+// NewAuditService creates a new instance of AuditServiceImpl
+func NewAuditService(repo repository.AuditRepository) *AuditServiceImpl {
+	return &AuditServiceImpl{repo: repo}
+}
+
+// Record appends an event. The actor, client IP and request ID are taken from the request
+// context, as stored by the router middleware, unless the event already carries them.
+func (s *AuditServiceImpl) Record(ctx context.Context, event *entity.AuditEvent) error {
+	if event.ActorID == nil {
+		if userID, ok := ctx.Value("userID").(int); ok {
+			event.ActorID = &userID
+		}
+	}
+	if event.IP == "" {
+		event.IP, _ = ctx.Value("clientIP").(string)
+	}
+	if event.RequestID == "" {
+		event.RequestID, _ = ctx.Value("requestID").(string)
+	}
+	return s.repo.CreateAuditEvent(ctx, event)
+}
+
+// ListEvents returns the events matching the filter, newest first
+func (s *AuditServiceImpl) ListEvents(ctx context.Context, filter entity.AuditFilter) ([]entity.AuditEvent, error) {
+	return s.repo.ListAuditEvents(ctx, filter)
+}
+
+// PurgeExpired deletes events older than the retention period
+func (s *AuditServiceImpl) PurgeExpired(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.DeleteAuditEventsBefore(ctx, time.Now().Add(-retention))
+}
+
+// recordAudit appends an event for a mutation that already succeeded.
+// Failing to audit is logged rather than returned, since the change cannot be undone at this point.
+func recordAudit(ctx context.Context, audit AuditService, action, targetType string, targetID interface{}, subjectID *int, before, after interface{}) {
+	event := &entity.AuditEvent{
+		SubjectID:  subjectID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   fmt.Sprint(targetID),
+		Before:     snapshot(before),
+		After:      snapshot(after),
+	}
+	if err := audit.Record(ctx, event); err != nil {
+		slog.Error("failed to record audit event", "action", action, "target_type", targetType, "target_id", targetID, "error", err)
+	}
+}
+
+// snapshot serializes a before or after state; nil stays empty
+func snapshot(state interface{}) json.RawMessage {
+	if state == nil {
+		return nil
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditService(t *testing.T) {
+	t.Run("TestRecord_FillsRequestContext", func(t *testing.T) {
+		repo := new(mocks.MockAuditRepository)
+		audit := NewAuditService(repo)
+		repo.On("CreateAuditEvent", mock.Anything, mock.AnythingOfType("*entity.AuditEvent")).Return(nil).Once()
+
+		ctx := context.WithValue(context.Background(), "userID", 7)
+		ctx = context.WithValue(ctx, "clientIP", "10.0.0.1")
+		ctx = context.WithValue(ctx, "requestID", "req-1")
+		event := &entity.AuditEvent{Action: "todo.create", TargetType: "todo", TargetID: "3"}
+		assert.NoError(t, audit.Record(ctx, event))
+
+		assert.Equal(t, 7, *event.ActorID)
+		assert.Equal(t, "10.0.0.1", event.IP)
+		assert.Equal(t, "req-1", event.RequestID)
+	})
+
+	t.Run("TestDeleteAllTodos_RecordsDeletedTodos", func(t *testing.T) {
+		inner := new(mocks.MockToDoService)
+		audit := new(mocks.MockAuditService)
+		todos := NewAuditedToDoService(inner, audit)
+
+		shared := entity.ToDo{ToDoID: 2, ListID: 9, Title: "shared"}
+		inner.On("GetAllTodos", mock.Anything, 1).Return([]entity.ToDo{{ToDoID: 1, Title: "mine"}, shared}, nil).Once()
+		inner.On("DeleteAllTodos", mock.Anything, 1).Return(nil)
+		inner.On("GetAllTodos", mock.Anything, 1).Return([]entity.ToDo{shared}, nil).Once()
+
+		var recorded *entity.AuditEvent
+		audit.On("Record", mock.Anything, mock.AnythingOfType("*entity.AuditEvent")).
+			Run(func(args mock.Arguments) { recorded = args.Get(1).(*entity.AuditEvent) }).Return(nil)
+
+		assert.NoError(t, todos.DeleteAllTodos(context.Background(), 1))
+		assert.Equal(t, "todo.delete_all", recorded.Action)
+
+		var deleted []entity.ToDo
+		assert.NoError(t, json.Unmarshal(recorded.Before, &deleted))
+		assert.Len(t, deleted, 1)
+		assert.Equal(t, 1, deleted[0].ToDoID)
+	})
+
+	t.Run("TestDeleteToDo_FailureNotRecorded", func(t *testing.T) {
+		inner := new(mocks.MockToDoService)
+		audit := new(mocks.MockAuditService)
+		todos := NewAuditedToDoService(inner, audit)
+
+		inner.On("GetTodo", mock.Anything, 1, 5).Return(entity.ToDo{ToDoID: 5}, nil)
+		inner.On("DeleteToDo", mock.Anything, 1, 5, 0).Return(assert.AnError)
+
+		assert.Error(t, todos.DeleteToDo(context.Background(), 1, 5, 0))
+		audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestUpdateUser_SnapshotsWithoutPassword", func(t *testing.T) {
+		inner := new(mocks.MockUserService)
+		audit := new(mocks.MockAuditService)
+		users := NewAuditedUserService(inner, audit)
+
+		user := &entity.User{UserID: 4, UserName: "bob", Email: "bob@example.com", Password: "$2a$10$secret"}
+		inner.On("GetUserByID", mock.Anything, 4).Return(user, nil)
+		inner.On("UpdateUser", mock.Anything, user).Return(nil)
+
+		var recorded *entity.AuditEvent
+		audit.On("Record", mock.Anything, mock.AnythingOfType("*entity.AuditEvent")).
+			Run(func(args mock.Arguments) { recorded = args.Get(1).(*entity.AuditEvent) }).Return(nil)
+
+		assert.NoError(t, users.UpdateUser(context.Background(), user))
+		assert.Equal(t, "user.update", recorded.Action)
+		assert.Equal(t, 4, *recorded.SubjectID)
+		assert.Contains(t, string(recorded.After), "bob@example.com")
+		assert.NotContains(t, string(recorded.Before), "secret")
+		assert.NotContains(t, string(recorded.After), "secret")
+	})
+
+	t.Run("TestResetPassword_RecordsTokenOwner", func(t *testing.T) {
+		userRepo := new(mocks.MockUserRepository)
+		tokenRepo := new(mocks.MockUserTokenRepository)
+		audit := new(mocks.MockAuditService)
+		accounts := NewAuditedAccountService(NewAccountService(userRepo, tokenRepo, "test"), audit)
+
+		var issued *entity.UserToken
+		userRepo.On("GetUserByEmail", mock.Anything, "test@example.com").Return(&entity.User{UserID: 7, Email: "test@example.com"}, nil)
+		tokenRepo.On("CreateToken", mock.Anything, mock.AnythingOfType("*entity.UserToken")).
+			Run(func(args mock.Arguments) { issued = args.Get(1).(*entity.UserToken) }).Return(nil)
+		_, token, err := accounts.IssuePasswordReset(context.Background(), "test@example.com")
+		assert.NoError(t, err)
+
+		tokenRepo.On("ConsumeToken", mock.Anything, issued.TokenID, entity.TokenPurposePasswordReset).
+			Return(&entity.UserToken{TokenID: issued.TokenID, UserID: 7}, nil)
+		tokenRepo.On("InvalidateTokens", mock.Anything, 7, entity.TokenPurposePasswordReset).Return(nil)
+		userRepo.On("UpdatePassword", mock.Anything, 7, mock.Anything).Return(nil)
+
+		var recorded *entity.AuditEvent
+		audit.On("Record", mock.Anything, mock.AnythingOfType("*entity.AuditEvent")).
+			Run(func(args mock.Arguments) { recorded = args.Get(1).(*entity.AuditEvent) }).Return(nil)
+
+		_, err = accounts.ResetPassword(context.Background(), token, "newpassword")
+		assert.NoError(t, err)
+		assert.Equal(t, "user.password_reset", recorded.Action)
+		assert.Equal(t, 7, *recorded.ActorID)
+		assert.Equal(t, 7, *recorded.SubjectID)
+	})
+
+	t.Run("TestVerifyEmail_FailureNotRecorded", func(t *testing.T) {
+		audit := new(mocks.MockAuditService)
+		accounts := NewAuditedAccountService(NewAccountService(new(mocks.MockUserRepository), new(mocks.MockUserTokenRepository), "test"), audit)
+
+		_, err := accounts.VerifyEmail(context.Background(), "not-a-token")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+		audit.AssertNotCalled(t, "Record", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestRunBatch_DeleteRecordsTodoAndOwner", func(t *testing.T) {
+		inner := new(mocks.MockToDoService)
+		audit := new(mocks.MockAuditService)
+		todos := NewAuditedToDoService(inner, audit)
+
+		ops := []entity.BatchOperation{{Op: entity.BatchDelete, ID: 5}}
+		deleted := entity.ToDo{ToDoID: 5, UserID: 2, Title: "Eggs"}
+		inner.On("RunBatch", mock.Anything, 1, ops, false).Return([]entity.BatchOutcome{{Before: &deleted}}, nil)
+
+		var recorded *entity.AuditEvent
+		audit.On("Record", mock.Anything, mock.AnythingOfType("*entity.AuditEvent")).
+			Run(func(args mock.Arguments) { recorded = args.Get(1).(*entity.AuditEvent) }).Return(nil)
+
+		_, err := todos.RunBatch(context.Background(), 1, ops, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "todo.delete", recorded.Action)
+		assert.Equal(t, 2, *recorded.SubjectID)
+		assert.Contains(t, string(recorded.Before), "Eggs")
+	})
+}
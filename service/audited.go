@@ -0,0 +1,409 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// The Audited* types decorate a service so that every successful mutation is appended to the audit log.
+// Read methods pass straight through to the embedded service.
+
+// AuditedToDoService records todo mutations
+type AuditedToDoService struct {
+	ToDoService
+	audit AuditService
+}
+
+// NewAuditedToDoService wraps a ToDoService with auditing
+func NewAuditedToDoService(inner ToDoService, audit AuditService) *AuditedToDoService {
+	return &AuditedToDoService{ToDoService: inner, audit: audit}
+}
+
+func (s *AuditedToDoService) AddToDo(ctx context.Context, todo *entity.ToDo) error {
+	if err := s.ToDoService.AddToDo(ctx, todo); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "todo.create", "todo", todo.ToDoID, &todo.UserID, nil, todo)
+	return nil
+}
+
+func (s *AuditedToDoService) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	return s.change(ctx, "todo.update", userID, todoID, func() (entity.ToDo, error) {
+		return s.ToDoService.UpdateToDo(ctx, userID, todoID, update, expectedVersion)
+	})
+}
+
+func (s *AuditedToDoService) RevertToDo(ctx context.Context, userID, todoID, revision, expectedVersion int) (entity.ToDo, error) {
+	return s.change(ctx, "todo.revert", userID, todoID, func() (entity.ToDo, error) {
+		return s.ToDoService.RevertToDo(ctx, userID, todoID, revision, expectedVersion)
+	})
+}
+
+func (s *AuditedToDoService) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	before, err := s.ToDoService.GetTodo(ctx, userID, todoID)
+	if err != nil {
+		return err
+	}
+	if err := s.ToDoService.DeleteToDo(ctx, userID, todoID, expectedVersion); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "todo.delete", "todo", todoID, &before.UserID, before, nil)
+	return nil
+}
+
+func (s *AuditedToDoService) DeleteAllTodos(ctx context.Context, userID int) error {
+	before, err := s.ToDoService.GetAllTodos(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.ToDoService.DeleteAllTodos(ctx, userID); err != nil {
+		return err
+	}
+	after, err := s.ToDoService.GetAllTodos(ctx, userID)
+	if err != nil {
+		after = nil
+	}
+
+	// Only some of the visible todos are deleted, so keep the ones that disappeared
+	remaining := make(map[int]bool, len(after))
+	for _, todo := range after {
+		remaining[todo.ToDoID] = true
+	}
+	deleted := []entity.ToDo{}
+	for _, todo := range before {
+		if !remaining[todo.ToDoID] {
+			deleted = append(deleted, todo)
+		}
+	}
+	recordAudit(ctx, s.audit, "todo.delete_all", "user", userID, &userID, deleted, nil)
+	return nil
+}
+
+func (s *AuditedToDoService) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	assignment, err := s.ToDoService.AssignTodo(ctx, userID, todoID, assigneeID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.AssignmentID != 0 {
+		recordAudit(ctx, s.audit, "todo.assign", "todo", todoID, assigneeID,
+			map[string]*int{"assignee_id": assignment.PreviousAssigneeID}, map[string]*int{"assignee_id": assigneeID})
+	}
+	return assignment, nil
+}
+
+func (s *AuditedToDoService) RestoreToDo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	todo, err := s.ToDoService.RestoreToDo(ctx, userID, todoID)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	recordAudit(ctx, s.audit, "todo.restore", "todo", todoID, &todo.UserID, nil, todo)
+	return todo, nil
+}
+
+func (s *AuditedToDoService) UndoDeleteAll(ctx context.Context, userID int) (int, error) {
+	restored, err := s.ToDoService.UndoDeleteAll(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	recordAudit(ctx, s.audit, "todo.undo_delete_all", "user", userID, &userID, nil, map[string]int{"restored": restored})
+	return restored, nil
+}
+
+// RunBatch records one event per applied operation, as the single-todo methods would
+func (s *AuditedToDoService) RunBatch(ctx context.Context, userID int, ops []entity.BatchOperation, atomic bool) ([]entity.BatchOutcome, error) {
+	outcomes, err := s.ToDoService.RunBatch(ctx, userID, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for i, outcome := range outcomes {
+		if outcome.Err != nil {
+			continue
+		}
+		switch ops[i].Op {
+		case entity.BatchCreate:
+			recordAudit(ctx, s.audit, "todo.create", "todo", outcome.Todo.ToDoID, &outcome.Todo.UserID, nil, outcome.Todo)
+		case entity.BatchUpdate:
+			recordAudit(ctx, s.audit, "todo.update", "todo", ops[i].ID, &outcome.Todo.UserID, batchBefore(outcome), outcome.Todo)
+		case entity.BatchDelete:
+			var subjectID *int
+			if outcome.Before != nil {
+				subjectID = &outcome.Before.UserID
+			}
+			recordAudit(ctx, s.audit, "todo.delete", "todo", ops[i].ID, subjectID, batchBefore(outcome), nil)
+		}
+	}
+	return outcomes, nil
+}
+
+// batchBefore returns the todo an outcome changed as it was, or nil when it is not known
+func batchBefore(outcome entity.BatchOutcome) interface{} {
+	if outcome.Before == nil {
+		return nil
+	}
+	return outcome.Before
+}
+
+// change runs an edit of a todo and records the todo before and after it
+func (s *AuditedToDoService) change(ctx context.Context, action string, userID, todoID int, edit func() (entity.ToDo, error)) (entity.ToDo, error) {
+	before, err := s.ToDoService.GetTodo(ctx, userID, todoID)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	after, err := edit()
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	recordAudit(ctx, s.audit, action, "todo", todoID, &after.UserID, before, after)
+	return after, nil
+}
+
+// AuditedUserService records account mutations; snapshots never include password hashes
+type AuditedUserService struct {
+	UserService
+	audit AuditService
+}
+
+// NewAuditedUserService wraps a UserService with auditing
+func NewAuditedUserService(inner UserService, audit AuditService) *AuditedUserService {
+	return &AuditedUserService{UserService: inner, audit: audit}
+}
+
+func (s *AuditedUserService) CreateUser(ctx context.Context, user *entity.User) error {
+	if err := s.UserService.CreateUser(ctx, user); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "user.create", "user", user.UserID, &user.UserID, nil, user.Profile())
+	return nil
+}
+
+func (s *AuditedUserService) UpdateUser(ctx context.Context, user *entity.User) error {
+	return s.mutate(ctx, "user.update", user.UserID, func() error { return s.UserService.UpdateUser(ctx, user) })
+}
+
+func (s *AuditedUserService) DeleteUser(ctx context.Context, userID int) error {
+	before := s.profile(ctx, userID)
+	if err := s.UserService.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "user.delete", "user", userID, &userID, before, nil)
+	return nil
+}
+
+func (s *AuditedUserService) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	action := "user.enable"
+	if disabled {
+		action = "user.disable"
+	}
+	return s.mutate(ctx, action, userID, func() error { return s.UserService.SetUserDisabled(ctx, userID, disabled) })
+}
+
+func (s *AuditedUserService) SetUserRole(ctx context.Context, userID int, role string) error {
+	return s.mutate(ctx, "user.role_change", userID, func() error { return s.UserService.SetUserRole(ctx, userID, role) })
+}
+
+func (s *AuditedUserService) SetPassword(ctx context.Context, userID int, password string) error {
+	if err := s.UserService.SetPassword(ctx, userID, password); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "user.password_set", "user", userID, &userID, nil, nil)
+	return nil
+}
+
+// mutate runs a change to a user and records their profile before and after it
+func (s *AuditedUserService) mutate(ctx context.Context, action string, userID int, change func() error) error {
+	before := s.profile(ctx, userID)
+	if err := change(); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, action, "user", userID, &userID, before, s.profile(ctx, userID))
+	return nil
+}
+
+// profile snapshots a user without credentials, or returns nil when the user cannot be loaded
+func (s *AuditedUserService) profile(ctx context.Context, userID int) interface{} {
+	user, err := s.UserService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil
+	}
+	return user.Profile()
+}
+
+// AuditedListService records list, membership and invitation changes
+type AuditedListService struct {
+	ListService
+	audit AuditService
+}
+
+// NewAuditedListService wraps a ListService with auditing
+func NewAuditedListService(inner ListService, audit AuditService) *AuditedListService {
+	return &AuditedListService{ListService: inner, audit: audit}
+}
+
+func (s *AuditedListService) CreateList(ctx context.Context, userID int, name string) (*entity.TodoList, error) {
+	list, err := s.ListService.CreateList(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.audit, "list.create", "list", list.ListID, nil, nil, list)
+	return list, nil
+}
+
+func (s *AuditedListService) DeleteList(ctx context.Context, userID, listID int) error {
+	var before interface{}
+	if list, members, err := s.ListService.GetList(ctx, userID, listID); err == nil {
+		before = map[string]interface{}{"list": list, "members": members}
+	}
+	if err := s.ListService.DeleteList(ctx, userID, listID); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "list.delete", "list", listID, nil, before, nil)
+	return nil
+}
+
+func (s *AuditedListService) InviteMember(ctx context.Context, userID, listID int, email, role string) (*entity.ListInvitation, error) {
+	invitation, err := s.ListService.InviteMember(ctx, userID, listID, email, role)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.audit, "list.invite", "list", listID, nil, nil, invitation)
+	return invitation, nil
+}
+
+func (s *AuditedListService) RespondToInvitation(ctx context.Context, userID, invitationID int, accept bool) (*entity.ListInvitation, error) {
+	invitation, err := s.ListService.RespondToInvitation(ctx, userID, invitationID, accept)
+	if err != nil {
+		return nil, err
+	}
+	recordAudit(ctx, s.audit, "list.invitation_"+invitation.Status, "list", invitation.ListID, &userID, nil, invitation)
+	return invitation, nil
+}
+
+func (s *AuditedListService) SetMemberRole(ctx context.Context, userID, listID, memberID int, role string) error {
+	if err := s.ListService.SetMemberRole(ctx, userID, listID, memberID, role); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "list.member_role", "list", listID, &memberID, nil, map[string]interface{}{"user_id": memberID, "role": role})
+	return nil
+}
+
+func (s *AuditedListService) RemoveMember(ctx context.Context, userID, listID, memberID int) error {
+	if err := s.ListService.RemoveMember(ctx, userID, listID, memberID); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "list.member_remove", "list", listID, &memberID, map[string]int{"user_id": memberID}, nil)
+	return nil
+}
+
+// AuditedCommentService records comment changes
+type AuditedCommentService struct {
+	CommentService
+	audit AuditService
+}
+
+// NewAuditedCommentService wraps a CommentService with auditing
+func NewAuditedCommentService(inner CommentService, audit AuditService) *AuditedCommentService {
+	return &AuditedCommentService{CommentService: inner, audit: audit}
+}
+
+func (s *AuditedCommentService) CreateComment(ctx context.Context, userID, todoID int, body string) (*entity.Comment, []entity.User, error) {
+	comment, mentioned, err := s.CommentService.CreateComment(ctx, userID, todoID, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordAudit(ctx, s.audit, "comment.create", "comment", comment.CommentID, nil, nil, comment)
+	return comment, mentioned, nil
+}
+
+func (s *AuditedCommentService) UpdateComment(ctx context.Context, userID, todoID, commentID int, body string) (*entity.Comment, []entity.User, error) {
+	comment, mentioned, err := s.CommentService.UpdateComment(ctx, userID, todoID, commentID, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordAudit(ctx, s.audit, "comment.update", "comment", commentID, nil, nil, comment)
+	return comment, mentioned, nil
+}
+
+func (s *AuditedCommentService) DeleteComment(ctx context.Context, userID, todoID, commentID int) error {
+	if err := s.CommentService.DeleteComment(ctx, userID, todoID, commentID); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "comment.delete", "comment", commentID, nil, map[string]int{"todo_id": todoID}, nil)
+	return nil
+}
+
+// AuditedAPIKeyService records API key creation and revocation
+type AuditedAPIKeyService struct {
+	APIKeyService
+	audit AuditService
+}
+
+// NewAuditedAPIKeyService wraps an APIKeyService with auditing
+func NewAuditedAPIKeyService(inner APIKeyService, audit AuditService) *AuditedAPIKeyService {
+	return &AuditedAPIKeyService{APIKeyService: inner, audit: audit}
+}
+
+func (s *AuditedAPIKeyService) CreateAPIKey(ctx context.Context, userID int, name string, scopes []string, expiresAt *time.Time) (*entity.APIKey, string, error) {
+	key, secret, err := s.APIKeyService.CreateAPIKey(ctx, userID, name, scopes, expiresAt)
+	if err != nil {
+		return nil, "", err
+	}
+	recordAudit(ctx, s.audit, "api_key.create", "api_key", key.KeyID, &userID, nil, key)
+	return key, secret, nil
+}
+
+func (s *AuditedAPIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID int) error {
+	if err := s.APIKeyService.RevokeAPIKey(ctx, userID, keyID); err != nil {
+		return err
+	}
+	recordAudit(ctx, s.audit, "api_key.revoke", "api_key", keyID, &userID, nil, nil)
+	return nil
+}
+
+// AuditedAccountService records the email verifications and password resets done with a token.
+// Those requests are not signed in, so the owner of the token is recorded as the actor.
+type AuditedAccountService struct {
+	AccountService
+	audit AuditService
+}
+
+// NewAuditedAccountService wraps an AccountService with auditing
+func NewAuditedAccountService(inner AccountService, audit AuditService) *AuditedAccountService {
+	return &AuditedAccountService{AccountService: inner, audit: audit}
+}
+
+func (s *AuditedAccountService) VerifyEmail(ctx context.Context, token string) (int, error) {
+	userID, err := s.AccountService.VerifyEmail(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, "user.email_verified", userID)
+	return userID, nil
+}
+
+func (s *AuditedAccountService) ResetPassword(ctx context.Context, token, newPassword string) (int, error) {
+	userID, err := s.AccountService.ResetPassword(ctx, token, newPassword)
+	if err != nil {
+		return 0, err
+	}
+	s.record(ctx, "user.password_reset", userID)
+	return userID, nil
+}
+
+// record appends an event acted by the user whose token was redeemed; the client IP and request ID
+// still come from the request context
+func (s *AuditedAccountService) record(ctx context.Context, action string, userID int) {
+	event := &entity.AuditEvent{
+		ActorID:    &userID,
+		SubjectID:  &userID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   strconv.Itoa(userID),
+	}
+	if err := s.audit.Record(ctx, event); err != nil {
+		slog.Error("failed to record audit event", "action", action, "user_id", userID, "error", err)
+	}
+}
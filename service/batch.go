@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+// MaxBatchSize bounds the number of operations in one batch
+const MaxBatchSize = 100
+
+var (
+	// ErrInvalidBatch is returned for an empty batch or one larger than MaxBatchSize
+	ErrInvalidBatch = fmt.Errorf("a batch must contain between 1 and %d operations", MaxBatchSize)
+
+	// ErrInvalidBatchOp is returned for an operation that is not create, update or delete
+	ErrInvalidBatchOp = errors.New("operation must be create, update or delete")
+
+	// ErrBatchAborted is reported for the operations of an all-or-nothing batch that were not applied
+	// because another operation failed
+	ErrBatchAborted = errors.New("not applied because another operation in the batch failed")
+)
+
+// RunBatch applies the operations in order. In atomic mode they share one transaction and the first
+// failure rolls all of them back, leaving ErrBatchAborted on every other operation.
+// Otherwise each operation is applied on its own and failures do not affect the others.
+func (s *TodoServiceImpl) RunBatch(ctx context.Context, userID int, ops []entity.BatchOperation, atomic bool) ([]entity.BatchOutcome, error) {
+	if len(ops) == 0 || len(ops) > MaxBatchSize {
+		return nil, ErrInvalidBatch
+	}
+
+	outcomes := make([]entity.BatchOutcome, len(ops))
+	if !atomic {
+		for i, op := range ops {
+			outcomes[i] = s.apply(ctx, userID, op)
+		}
+		return outcomes, nil
+	}
+
+	failed := -1
+	err := s.repo.WithTx(ctx, func(tx repository.ToDoRepository) error {
+		txService := &TodoServiceImpl{repo: tx}
+		for i, op := range ops {
+			outcomes[i] = txService.apply(ctx, userID, op)
+			if outcomes[i].Err != nil {
+				failed = i
+				return outcomes[i].Err
+			}
+		}
+		return nil
+	})
+	if failed < 0 && err != nil {
+		return nil, err // the transaction itself failed
+	}
+	if failed >= 0 {
+		for i := range outcomes {
+			if i != failed {
+				outcomes[i] = entity.BatchOutcome{Err: ErrBatchAborted}
+			}
+		}
+	}
+	return outcomes, nil
+}
+
+// before reads a todo as it is ahead of a change, for the audit log. Inside a transaction the read
+// belongs to it; otherwise it goes to the primary, as a replica may not have the latest version yet.
+func (s *TodoServiceImpl) before(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	return s.GetTodo(repository.WithPrimary(ctx), userID, todoID)
+}
+
+// apply runs a single batch operation through the regular service methods
+func (s *TodoServiceImpl) apply(ctx context.Context, userID int, op entity.BatchOperation) entity.BatchOutcome {
+	switch op.Op {
+	case entity.BatchCreate:
+		todo := &entity.ToDo{UserID: userID, ListID: op.ListID}
+		if op.Title == nil || strings.TrimSpace(*op.Title) == "" {
+			return entity.BatchOutcome{Err: ErrInvalidTodo}
+		}
+		todo.Title = *op.Title
+		if op.Description != nil {
+			todo.Description = *op.Description
+		}
+		if op.DateTime != nil {
+			todo.DateTime = *op.DateTime
+		}
+		if err := s.AddToDo(ctx, todo); err != nil {
+			return entity.BatchOutcome{Err: err}
+		}
+		return entity.BatchOutcome{Todo: todo}
+	case entity.BatchUpdate:
+		before, err := s.before(ctx, userID, op.ID)
+		if err != nil {
+			return entity.BatchOutcome{Err: err}
+		}
+		todo, err := s.UpdateToDo(ctx, userID, op.ID, op.TodoUpdate, op.Version)
+		if err != nil {
+			return entity.BatchOutcome{Err: err}
+		}
+		return entity.BatchOutcome{Todo: &todo, Before: &before}
+	case entity.BatchDelete:
+		before, err := s.before(ctx, userID, op.ID)
+		if err != nil {
+			return entity.BatchOutcome{Err: err}
+		}
+		if err := s.DeleteToDo(ctx, userID, op.ID, op.Version); err != nil {
+			return entity.BatchOutcome{Err: err}
+		}
+		return entity.BatchOutcome{Before: &before}
+	default:
+		return entity.BatchOutcome{Err: ErrInvalidBatchOp}
+	}
+}
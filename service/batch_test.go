@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRunBatch(t *testing.T) {
+	title := "Milk"
+	ops := []entity.BatchOperation{
+		{Op: entity.BatchCreate, TodoUpdate: entity.TodoUpdate{Title: &title}},
+		{Op: entity.BatchDelete, ID: 4},
+		{Op: entity.BatchDelete, ID: 5},
+	}
+
+	t.Run("TestRunBatch_TransactionalRollsBack", func(t *testing.T) {
+		repo := new(mocks.MockToDoRepository)
+		todos := NewTodoService(repo)
+		repo.On("WithTx", mock.Anything).Return(nil)
+		repo.On("AddToDo", mock.Anything, mock.AnythingOfType("*entity.ToDo")).Return(nil)
+		repo.On("GetTodo", mock.Anything, 1, 4).Return(entity.ToDo{ToDoID: 4, UserID: 2}, nil)
+		repo.On("DeleteToDo", mock.Anything, 1, 4, 0).Return(fmt.Errorf("todo not found"))
+
+		outcomes, err := todos.RunBatch(context.Background(), 1, ops, true)
+
+		assert.NoError(t, err)
+		assert.ErrorIs(t, outcomes[0].Err, ErrBatchAborted)
+		assert.EqualError(t, outcomes[1].Err, "todo not found")
+		assert.ErrorIs(t, outcomes[2].Err, ErrBatchAborted)
+		repo.AssertNotCalled(t, "DeleteToDo", mock.Anything, 1, 5, 0)
+	})
+
+	t.Run("TestRunBatch_BestEffortContinues", func(t *testing.T) {
+		repo := new(mocks.MockToDoRepository)
+		todos := NewTodoService(repo)
+		repo.On("AddToDo", mock.Anything, mock.AnythingOfType("*entity.ToDo")).Return(nil)
+		repo.On("GetTodo", mock.Anything, 1, 4).Return(entity.ToDo{}, fmt.Errorf("todo not found"))
+		repo.On("GetTodo", mock.Anything, 1, 5).Return(entity.ToDo{ToDoID: 5, UserID: 2, Title: "Eggs"}, nil)
+		repo.On("DeleteToDo", mock.Anything, 1, 5, 0).Return(nil)
+
+		outcomes, err := todos.RunBatch(context.Background(), 1, ops, false)
+
+		assert.NoError(t, err)
+		assert.NoError(t, outcomes[0].Err)
+		assert.Equal(t, "Milk", outcomes[0].Todo.Title)
+		assert.Error(t, outcomes[1].Err)
+		assert.NoError(t, outcomes[2].Err)
+		assert.Equal(t, "Eggs", outcomes[2].Before.Title)
+		repo.AssertNotCalled(t, "WithTx", mock.Anything)
+	})
+
+	t.Run("TestRunBatch_InvalidOperation", func(t *testing.T) {
+		todos := NewTodoService(new(mocks.MockToDoRepository))
+
+		outcomes, err := todos.RunBatch(context.Background(), 1, []entity.BatchOperation{{Op: "archive", ID: 1}}, false)
+
+		assert.NoError(t, err)
+		assert.ErrorIs(t, outcomes[0].Err, ErrInvalidBatchOp)
+	})
+
+	t.Run("TestRunBatch_TooLarge", func(t *testing.T) {
+		todos := NewTodoService(new(mocks.MockToDoRepository))
+
+		_, err := todos.RunBatch(context.Background(), 1, make([]entity.BatchOperation, MaxBatchSize+1), false)
+
+		assert.ErrorIs(t, err, ErrInvalidBatch)
+	})
+}
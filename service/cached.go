@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+// The Cached* types serve GetAllTodos and GetTodo from a TodoCache and drop the cached reads of
+// everyone a mutation is visible to: the members of every list it touched.
+// Other methods pass straight through to the embedded service.
+
+// cacheInvalidator finds who can see a list and drops their cached reads. Without a list
+// repository every todo is only visible to its owner.
+type cacheInvalidator struct {
+	cache *TodoCache
+	lists repository.ListRepository
+}
+
+// members returns the users who belong to any of the lists
+func (i cacheInvalidator) members(ctx context.Context, listIDs ...int) []int {
+	if i.lists == nil {
+		return nil
+	}
+	var userIDs []int
+	for _, listID := range listIDs {
+		members, err := i.lists.GetMembers(ctx, listID)
+		if err != nil {
+			// The other members see the change once their entries expire
+			i.cache.fail(err, "find the members of list %d", listID)
+			continue
+		}
+		for _, member := range members {
+			userIDs = append(userIDs, member.UserID)
+		}
+	}
+	return userIDs
+}
+
+// invalidateLists drops the cached reads of the user and of every member of the lists
+func (i cacheInvalidator) invalidateLists(ctx context.Context, userID int, listIDs ...int) {
+	i.cache.Invalidate(append(i.members(ctx, listIDs...), userID)...)
+}
+
+// CachedToDoService caches todo reads
+type CachedToDoService struct {
+	ToDoService
+	cacheInvalidator
+}
+
+// NewCachedToDoService wraps a ToDoService with caching. lists may be nil when the backend has no shared lists.
+func NewCachedToDoService(inner ToDoService, cache *TodoCache, lists repository.ListRepository) *CachedToDoService {
+	return &CachedToDoService{ToDoService: inner, cacheInvalidator: cacheInvalidator{cache: cache, lists: lists}}
+}
+
+func (s *CachedToDoService) GetAllTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return cachedRead(ctx, s.cache, userID, "todos", func(ctx context.Context) ([]entity.ToDo, error) {
+		return s.ToDoService.GetAllTodos(ctx, userID)
+	})
+}
+
+func (s *CachedToDoService) GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	return cachedRead(ctx, s.cache, userID, "todo:"+strconv.Itoa(todoID), func(ctx context.Context) (entity.ToDo, error) {
+		return s.ToDoService.GetTodo(ctx, userID, todoID)
+	})
+}
+
+func (s *CachedToDoService) AddToDo(ctx context.Context, todo *entity.ToDo) error {
+	if err := s.ToDoService.AddToDo(ctx, todo); err != nil {
+		return err
+	}
+	s.invalidateLists(ctx, todo.UserID, todo.ListID)
+	return nil
+}
+
+func (s *CachedToDoService) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	return s.change(ctx, userID, func() (entity.ToDo, error) {
+		return s.ToDoService.UpdateToDo(ctx, userID, todoID, update, expectedVersion)
+	})
+}
+
+func (s *CachedToDoService) RevertToDo(ctx context.Context, userID, todoID, revision, expectedVersion int) (entity.ToDo, error) {
+	return s.change(ctx, userID, func() (entity.ToDo, error) {
+		return s.ToDoService.RevertToDo(ctx, userID, todoID, revision, expectedVersion)
+	})
+}
+
+func (s *CachedToDoService) RestoreToDo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	return s.change(ctx, userID, func() (entity.ToDo, error) {
+		return s.ToDoService.RestoreToDo(ctx, userID, todoID)
+	})
+}
+
+func (s *CachedToDoService) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	listIDs := s.listsOf(ctx, userID, todoID)
+	if err := s.ToDoService.DeleteToDo(ctx, userID, todoID, expectedVersion); err != nil {
+		return err
+	}
+	s.invalidateLists(ctx, userID, listIDs...)
+	return nil
+}
+
+// DeleteAllTodos and UndoDeleteAll only touch the user's personal list
+func (s *CachedToDoService) DeleteAllTodos(ctx context.Context, userID int) error {
+	if err := s.ToDoService.DeleteAllTodos(ctx, userID); err != nil {
+		return err
+	}
+	s.cache.Invalidate(userID)
+	return nil
+}
+
+func (s *CachedToDoService) UndoDeleteAll(ctx context.Context, userID int) (int, error) {
+	restored, err := s.ToDoService.UndoDeleteAll(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	s.cache.Invalidate(userID)
+	return restored, nil
+}
+
+func (s *CachedToDoService) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	assignment, err := s.ToDoService.AssignTodo(ctx, userID, todoID, assigneeID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateLists(ctx, userID, s.listsOf(ctx, userID, todoID)...)
+	return assignment, nil
+}
+
+// RunBatch drops the cached reads for every list an applied operation touched. Deleted todos can no
+// longer be looked up afterwards, so their lists are found before the batch runs.
+func (s *CachedToDoService) RunBatch(ctx context.Context, userID int, ops []entity.BatchOperation, atomic bool) ([]entity.BatchOutcome, error) {
+	var listIDs []int
+	for _, op := range ops {
+		if op.Op == entity.BatchDelete {
+			listIDs = append(listIDs, s.listsOf(ctx, userID, op.ID)...)
+		}
+	}
+	outcomes, err := s.ToDoService.RunBatch(ctx, userID, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for _, outcome := range outcomes {
+		if outcome.Err == nil && outcome.Todo != nil {
+			listIDs = append(listIDs, outcome.Todo.ListID)
+		}
+	}
+	s.invalidateLists(ctx, userID, uniqueInts(listIDs)...)
+	return outcomes, nil
+}
+
+// change runs an edit of a todo and drops the cached reads of its list when it succeeds
+func (s *CachedToDoService) change(ctx context.Context, userID int, edit func() (entity.ToDo, error)) (entity.ToDo, error) {
+	todo, err := edit()
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	s.invalidateLists(ctx, userID, todo.ListID)
+	return todo, nil
+}
+
+// listsOf returns the list the todo belongs to, or none when the user cannot see it
+func (s *CachedToDoService) listsOf(ctx context.Context, userID, todoID int) []int {
+	todo, err := s.ToDoService.GetTodo(repository.WithPrimary(ctx), userID, todoID)
+	if err != nil {
+		return nil
+	}
+	return []int{todo.ListID}
+}
+
+// CachedListService drops cached todo reads when list membership changes what a user can see
+type CachedListService struct {
+	ListService
+	cacheInvalidator
+}
+
+// NewCachedListService wraps a ListService so that membership changes reach the todo cache
+func NewCachedListService(inner ListService, cache *TodoCache, lists repository.ListRepository) *CachedListService {
+	return &CachedListService{ListService: inner, cacheInvalidator: cacheInvalidator{cache: cache, lists: lists}}
+}
+
+func (s *CachedListService) DeleteList(ctx context.Context, userID, listID int) error {
+	members := s.members(ctx, listID)
+	if err := s.ListService.DeleteList(ctx, userID, listID); err != nil {
+		return err
+	}
+	s.cache.Invalidate(append(members, userID)...)
+	return nil
+}
+
+func (s *CachedListService) RespondToInvitation(ctx context.Context, userID, invitationID int, accept bool) (*entity.ListInvitation, error) {
+	invitation, err := s.ListService.RespondToInvitation(ctx, userID, invitationID, accept)
+	if err != nil {
+		return nil, err
+	}
+	if accept {
+		s.cache.Invalidate(userID)
+	}
+	return invitation, nil
+}
+
+func (s *CachedListService) RemoveMember(ctx context.Context, userID, listID, memberID int) error {
+	if err := s.ListService.RemoveMember(ctx, userID, listID, memberID); err != nil {
+		return err
+	}
+	s.cache.Invalidate(memberID)
+	return nil
+}
+
+// CachedUserService drops cached todo reads when a deleted user's lists and todos go with them
+type CachedUserService struct {
+	UserService
+	cacheInvalidator
+}
+
+// NewCachedUserService wraps a UserService so that account deletion reaches the todo cache
+func NewCachedUserService(inner UserService, cache *TodoCache, lists repository.ListRepository) *CachedUserService {
+	return &CachedUserService{UserService: inner, cacheInvalidator: cacheInvalidator{cache: cache, lists: lists}}
+}
+
+func (s *CachedUserService) DeleteUser(ctx context.Context, userID int) error {
+	var members []int
+	if s.lists != nil {
+		if lists, err := s.lists.GetLists(ctx, userID); err == nil {
+			for _, list := range lists {
+				members = append(members, s.members(ctx, list.ListID)...)
+			}
+		}
+	}
+	if err := s.UserService.DeleteUser(ctx, userID); err != nil {
+		return err
+	}
+	s.cache.Invalidate(append(members, userID)...)
+	return nil
+}
+
+func uniqueInts(values []int) []int {
+	seen := make(map[int]bool, len(values))
+	unique := values[:0]
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeRedis keeps values in a map and ignores expiry; err makes every command fail
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+	err    error
+}
+
+func (f *fakeRedis) Get(key string) *redis.StringCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return redis.NewStringResult("", f.err)
+	}
+	value, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return redis.NewStatusResult("", f.err)
+	}
+	f.values[key] = fakeString(value)
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) SetNX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return redis.NewBoolResult(false, f.err)
+	}
+	if _, ok := f.values[key]; ok {
+		return redis.NewBoolResult(false, nil)
+	}
+	f.values[key] = fakeString(value)
+	return redis.NewBoolResult(true, nil)
+}
+
+func fakeString(value interface{}) string {
+	if data, ok := value.([]byte); ok {
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+func TestCachedToDoService(t *testing.T) {
+	newService := func() (*CachedToDoService, *TodoCache, *fakeRedis, *mocks.MockToDoService, *mocks.MockListRepository) {
+		client := &fakeRedis{values: map[string]string{}}
+		cache := NewTodoCache(client, time.Minute)
+		inner := new(mocks.MockToDoService)
+		lists := new(mocks.MockListRepository)
+		return NewCachedToDoService(inner, cache, lists), cache, client, inner, lists
+	}
+	todos := []entity.ToDo{{ToDoID: 1, Title: "Buy milk", UserID: 7, ListID: 5, Version: 1}}
+
+	t.Run("TestGetAllTodos_Hit", func(t *testing.T) {
+		service, cache, _, inner, _ := newService()
+		inner.On("GetAllTodos", mock.Anything, 7).Return(todos, nil).Once()
+
+		for i := 0; i < 3; i++ {
+			result, err := service.GetAllTodos(context.Background(), 7)
+			assert.NoError(t, err)
+			assert.Equal(t, todos, result)
+		}
+		inner.AssertNumberOfCalls(t, "GetAllTodos", 1)
+
+		metrics := cache.Metrics()
+		assert.Equal(t, 2.0, metrics["hits"])
+		assert.Equal(t, 1.0, metrics["misses"])
+		assert.InDelta(t, 2.0/3, metrics["hit_ratio"], 0.001)
+	})
+
+	t.Run("TestGetTodo_ErrorsAreNotCached", func(t *testing.T) {
+		service, _, _, inner, _ := newService()
+		inner.On("GetTodo", mock.Anything, 7, 1).Return(entity.ToDo{}, repository.ErrNotFound).Once()
+		inner.On("GetTodo", mock.Anything, 7, 1).Return(todos[0], nil).Once()
+
+		_, err := service.GetTodo(context.Background(), 7, 1)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+		todo, err := service.GetTodo(context.Background(), 7, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, todos[0], todo)
+	})
+
+	t.Run("TestUpdateToDo_InvalidatesListMembers", func(t *testing.T) {
+		service, _, _, inner, lists := newService()
+		inner.On("GetAllTodos", mock.Anything, mock.Anything).Return(todos, nil)
+		inner.On("UpdateToDo", mock.Anything, 7, 1, mock.Anything, 1).Return(entity.ToDo{ToDoID: 1, ListID: 5, Version: 2}, nil)
+		lists.On("GetMembers", mock.Anything, 5).Return([]entity.ListMember{{ListID: 5, UserID: 7}, {ListID: 5, UserID: 8}}, nil)
+
+		for _, userID := range []int{7, 8, 9} {
+			service.GetAllTodos(context.Background(), userID)
+		}
+		_, err := service.UpdateToDo(context.Background(), 7, 1, entity.TodoUpdate{}, 1)
+		assert.NoError(t, err)
+		for _, userID := range []int{7, 8, 9} {
+			service.GetAllTodos(context.Background(), userID)
+		}
+
+		// The members read again; user 9 is not in the list and keeps the cached read
+		inner.AssertNumberOfCalls(t, "GetAllTodos", 5)
+	})
+
+	t.Run("TestDeleteToDo_FailureKeepsCache", func(t *testing.T) {
+		service, _, _, inner, _ := newService()
+		inner.On("GetAllTodos", mock.Anything, 7).Return(todos, nil)
+		inner.On("GetTodo", mock.Anything, 7, 1).Return(todos[0], nil)
+		inner.On("DeleteToDo", mock.Anything, 7, 1, 3).Return(repository.ErrVersionConflict)
+
+		service.GetAllTodos(context.Background(), 7)
+		err := service.DeleteToDo(context.Background(), 7, 1, 3)
+		assert.ErrorIs(t, err, repository.ErrVersionConflict)
+		service.GetAllTodos(context.Background(), 7)
+
+		inner.AssertNumberOfCalls(t, "GetAllTodos", 1)
+	})
+
+	t.Run("TestGetAllTodos_CoalescesMisses", func(t *testing.T) {
+		service, _, _, inner, _ := newService()
+		started, release := make(chan struct{}), make(chan struct{})
+		inner.On("GetAllTodos", mock.Anything, 7).Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).Return(todos, nil).Once()
+
+		var wg sync.WaitGroup
+		read := func() {
+			defer wg.Done()
+			result, err := service.GetAllTodos(context.Background(), 7)
+			assert.NoError(t, err)
+			assert.Equal(t, todos, result)
+		}
+		wg.Add(1)
+		go read()
+		<-started
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go read()
+		}
+		close(release)
+		wg.Wait()
+
+		// Readers either joined the running fetch or found its result cached
+		inner.AssertNumberOfCalls(t, "GetAllTodos", 1)
+	})
+
+	t.Run("TestGetAllTodos_WaiterGivesUp", func(t *testing.T) {
+		service, _, _, inner, _ := newService()
+		started, release := make(chan struct{}), make(chan struct{})
+		inner.On("GetAllTodos", mock.Anything, 7).Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).Return(todos, nil).Once()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			result, err := service.GetAllTodos(context.Background(), 7)
+			assert.NoError(t, err)
+			assert.Equal(t, todos, result, "the fetch carries on for the readers still waiting")
+		}()
+		<-started
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := service.GetAllTodos(ctx, 7)
+		assert.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		<-done
+	})
+
+	t.Run("TestRedisDown_ReadsFromService", func(t *testing.T) {
+		service, cache, client, inner, _ := newService()
+		client.err = errors.New("connection refused")
+		inner.On("GetAllTodos", mock.Anything, 7).Return(todos, nil)
+
+		for i := 0; i < 2; i++ {
+			result, err := service.GetAllTodos(context.Background(), 7)
+			assert.NoError(t, err)
+			assert.Equal(t, todos, result)
+		}
+		inner.AssertNumberOfCalls(t, "GetAllTodos", 2)
+		assert.Equal(t, 2.0, cache.Metrics()["errors"])
+	})
+
+	t.Run("TestMiss_ReadsFromPrimary", func(t *testing.T) {
+		service, _, _, inner, lists := newService()
+		stale := []entity.ToDo{{ToDoID: 1, Title: "Buy milk", UserID: 7, ListID: 5, Version: 1}}
+		fresh := []entity.ToDo{{ToDoID: 1, Title: "Buy oat milk", UserID: 7, ListID: 5, Version: 2}}
+		// A replica lagging behind the primary still serves the todo as it was before the update
+		inner.On("GetAllTodos", mock.MatchedBy(repository.UsesPrimary), 8).Return(fresh, nil)
+		inner.On("GetAllTodos", mock.Anything, 8).Return(stale, nil)
+		inner.On("UpdateToDo", mock.Anything, 7, 1, mock.Anything, 1).Return(fresh[0], nil)
+		lists.On("GetMembers", mock.Anything, 5).Return([]entity.ListMember{{ListID: 5, UserID: 7}, {ListID: 5, UserID: 8}}, nil)
+
+		_, err := service.UpdateToDo(context.Background(), 7, 1, entity.TodoUpdate{}, 1)
+		assert.NoError(t, err)
+		for i := 0; i < 2; i++ {
+			result, err := service.GetAllTodos(context.Background(), 8)
+			assert.NoError(t, err)
+			assert.Equal(t, fresh, result, "the other list member never gets the replica's stale rows")
+		}
+	})
+
+	t.Run("TestWithPrimary_SkipsCache", func(t *testing.T) {
+		service, _, _, inner, _ := newService()
+		inner.On("GetAllTodos", mock.Anything, 7).Return(todos, nil)
+
+		service.GetAllTodos(context.Background(), 7)
+		service.GetAllTodos(repository.WithPrimary(context.Background()), 7)
+
+		inner.AssertNumberOfCalls(t, "GetAllTodos", 2)
+	})
+}
+
+func TestCachedListService(t *testing.T) {
+	t.Run("TestRemoveMember_InvalidatesMember", func(t *testing.T) {
+		client := &fakeRedis{values: map[string]string{}}
+		cache := NewTodoCache(client, time.Minute)
+		todos := new(mocks.MockToDoService)
+		inner := new(mocks.MockListService)
+		lists := new(mocks.MockListRepository)
+		todoService := NewCachedToDoService(todos, cache, lists)
+		listService := NewCachedListService(inner, cache, lists)
+
+		todos.On("GetAllTodos", mock.Anything, 8).Return([]entity.ToDo{{ToDoID: 1, ListID: 5}}, nil)
+		inner.On("RemoveMember", mock.Anything, 7, 5, 8).Return(nil)
+
+		todoService.GetAllTodos(context.Background(), 8)
+		assert.NoError(t, listService.RemoveMember(context.Background(), 7, 5, 8))
+		todoService.GetAllTodos(context.Background(), 8)
+
+		todos.AssertNumberOfCalls(t, "GetAllTodos", 2)
+	})
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/srikanthbhandary/todo-server/utility"
+)
+
+// maxCommentLength bounds comment bodies, in bytes
+const maxCommentLength = 10000
+
+var (
+	// ErrTodoNotFound is returned for todos that do not exist or are in a list the user does not belong to.
+	ErrTodoNotFound = errors.New("todo not found")
+
+	// ErrCommentNotFound is returned for comments that do not exist on the todo.
+	ErrCommentNotFound = errors.New("comment not found")
+
+	// ErrCommentPermission is returned when editing someone else's comment, or deleting it without owning the list.
+	ErrCommentPermission = errors.New("not allowed to change this comment")
+
+	// ErrInvalidComment is returned for empty or oversized comment bodies.
+	ErrInvalidComment = errors.New("comment must be between 1 and 10000 characters")
+)
+
+// CommentService manages the discussion thread of todos.
+// Create and update return the users newly @mentioned so the caller can notify them.
+type CommentService interface {
+	CreateComment(ctx context.Context, userID, todoID int, body string) (*entity.Comment, []entity.User, error)
+	ListComments(ctx context.Context, userID, todoID, limit, offset int) ([]entity.Comment, error)
+	UpdateComment(ctx context.Context, userID, todoID, commentID int, body string) (*entity.Comment, []entity.User, error)
+	DeleteComment(ctx context.Context, userID, todoID, commentID int) error
+	GetCommentRevisions(ctx context.Context, userID, todoID, commentID int) ([]entity.CommentRevision, error)
+}
+
+// CommentServiceImpl is the implementation of CommentService interface
+type CommentServiceImpl struct {
+	comments repository.CommentRepository
+	todos    repository.ToDoRepository
+	lists    repository.ListRepository
+	users    repository.UserRepository
+}
+
+// NewCommentService creates a new instance of CommentServiceImpl
+func NewCommentService(comments repository.CommentRepository, todos repository.ToDoRepository,
+	lists repository.ListRepository, users repository.UserRepository) *CommentServiceImpl {
+	return &CommentServiceImpl{comments: comments, todos: todos, lists: lists, users: users}
+}
+
+// CreateComment adds a comment to a todo the user can see
+func (s *CommentServiceImpl) CreateComment(ctx context.Context, userID, todoID int, body string) (*entity.Comment, []entity.User, error) {
+	body, err := validateComment(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	todo, err := s.todo(ctx, userID, todoID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	comment := &entity.Comment{TodoID: todoID, UserID: userID, Body: body}
+	if err := s.comments.CreateComment(ctx, comment); err != nil {
+		return nil, nil, err
+	}
+	if author, err := s.users.GetUserByID(ctx, userID); err == nil {
+		comment.UserName = author.UserName
+	}
+	renderComment(comment)
+
+	return comment, s.mentionedMembers(ctx, todo, userID, body, ""), nil
+}
+
+// ListComments returns a page of a todo's comments, oldest first
+func (s *CommentServiceImpl) ListComments(ctx context.Context, userID, todoID, limit, offset int) ([]entity.Comment, error) {
+	if _, err := s.todo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+
+	comments, err := s.comments.ListComments(ctx, todoID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		renderComment(&comments[i])
+	}
+	return comments, nil
+}
+
+// UpdateComment replaces the body of the user's own comment; the previous body is kept as a revision
+func (s *CommentServiceImpl) UpdateComment(ctx context.Context, userID, todoID, commentID int, body string) (*entity.Comment, []entity.User, error) {
+	body, err := validateComment(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	todo, err := s.todo(ctx, userID, todoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	comment, err := s.comment(ctx, todoID, commentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if comment.UserID != userID {
+		return nil, nil, ErrCommentPermission
+	}
+
+	previous := comment.Body
+	if err := s.comments.UpdateComment(ctx, comment, body); err != nil {
+		return nil, nil, err
+	}
+	renderComment(comment)
+
+	// Only people mentioned for the first time hear about the edit
+	return comment, s.mentionedMembers(ctx, todo, userID, body, previous), nil
+}
+
+// DeleteComment deletes a comment; authors can delete their own and list owners can delete any
+func (s *CommentServiceImpl) DeleteComment(ctx context.Context, userID, todoID, commentID int) error {
+	todo, err := s.todo(ctx, userID, todoID)
+	if err != nil {
+		return err
+	}
+	comment, err := s.comment(ctx, todoID, commentID)
+	if err != nil {
+		return err
+	}
+
+	if comment.UserID != userID {
+		role, err := s.lists.GetMemberRole(ctx, todo.ListID, userID)
+		if err != nil {
+			return err
+		}
+		if role != entity.ListRoleOwner {
+			return ErrCommentPermission
+		}
+	}
+	return s.comments.DeleteComment(ctx, todoID, commentID)
+}
+
+// GetCommentRevisions returns the earlier bodies of a comment
+func (s *CommentServiceImpl) GetCommentRevisions(ctx context.Context, userID, todoID, commentID int) ([]entity.CommentRevision, error) {
+	if _, err := s.todo(ctx, userID, todoID); err != nil {
+		return nil, err
+	}
+	if _, err := s.comment(ctx, todoID, commentID); err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.comments.GetCommentRevisions(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range revisions {
+		revisions[i].BodyHTML = utility.RenderMarkdown(revisions[i].Body)
+	}
+	return revisions, nil
+}
+
+// todo loads a todo through the user's list memberships
+func (s *CommentServiceImpl) todo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	todo, err := s.todos.GetTodo(ctx, userID, todoID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return entity.ToDo{}, ErrTodoNotFound
+		}
+		return entity.ToDo{}, err
+	}
+	return todo, nil
+}
+
+// comment loads a comment of the todo
+func (s *CommentServiceImpl) comment(ctx context.Context, todoID, commentID int) (*entity.Comment, error) {
+	comment, err := s.comments.GetComment(ctx, todoID, commentID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, ErrCommentNotFound
+		}
+		return nil, err
+	}
+	return comment, nil
+}
+
+// mentionedMembers resolves the @mentions in body that are not already in previous.
+// Unknown names, the author and users without access to the todo's list are skipped.
+func (s *CommentServiceImpl) mentionedMembers(ctx context.Context, todo entity.ToDo, authorID int, body, previous string) []entity.User {
+	known := make(map[string]bool)
+	for _, name := range utility.ParseMentions(previous) {
+		known[name] = true
+	}
+
+	var mentioned []entity.User
+	for _, name := range utility.ParseMentions(body) {
+		if known[name] {
+			continue
+		}
+		user, err := s.users.GetUserByUserName(ctx, name)
+		if err != nil || user.UserID == authorID {
+			continue
+		}
+		if role, err := s.lists.GetMemberRole(ctx, todo.ListID, user.UserID); err != nil || role == "" {
+			continue
+		}
+		mentioned = append(mentioned, *user)
+	}
+	return mentioned
+}
+
+// validateComment trims a comment body and checks its length
+func validateComment(body string) (string, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || len(body) > maxCommentLength {
+		return "", ErrInvalidComment
+	}
+	return body, nil
+}
+
+// renderComment fills in the sanitized HTML of a comment
+func renderComment(comment *entity.Comment) {
+	comment.BodyHTML = utility.RenderMarkdown(comment.Body)
+}
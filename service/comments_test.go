@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCommentService(t *testing.T) {
+	type deps struct {
+		comments *mocks.MockCommentRepository
+		todos    *mocks.MockToDoRepository
+		lists    *mocks.MockListRepository
+		users    *mocks.MockUserRepository
+	}
+	newService := func() (*CommentServiceImpl, deps) {
+		d := deps{new(mocks.MockCommentRepository), new(mocks.MockToDoRepository), new(mocks.MockListRepository), new(mocks.MockUserRepository)}
+		return NewCommentService(d.comments, d.todos, d.lists, d.users), d
+	}
+	todo := entity.ToDo{ToDoID: 10, ListID: 5}
+
+	t.Run("TestCreateComment_MentionsMembersOnly", func(t *testing.T) {
+		service, d := newService()
+		d.todos.On("GetTodo", mock.Anything, 7, 10).Return(todo, nil)
+		d.comments.On("CreateComment", mock.Anything, mock.AnythingOfType("*entity.Comment")).Return(nil)
+		d.users.On("GetUserByID", mock.Anything, 7).Return(&entity.User{UserID: 7, UserName: "author"}, nil)
+		d.users.On("GetUserByUserName", mock.Anything, "alice").Return(&entity.User{UserID: 8, UserName: "alice"}, nil)
+		d.users.On("GetUserByUserName", mock.Anything, "mallory").Return(&entity.User{UserID: 9, UserName: "mallory"}, nil)
+		d.users.On("GetUserByUserName", mock.Anything, "ghost").Return(&entity.User{}, fmt.Errorf("user not found"))
+		d.lists.On("GetMemberRole", mock.Anything, 5, 8).Return(entity.ListRoleViewer, nil)
+		d.lists.On("GetMemberRole", mock.Anything, 5, 9).Return("", nil)
+
+		comment, mentioned, err := service.CreateComment(context.Background(), 7, 10, "Thoughts @alice @mallory @ghost? <script>x</script>")
+		assert.NoError(t, err)
+		assert.Equal(t, "author", comment.UserName)
+		assert.NotContains(t, comment.BodyHTML, "<script")
+		assert.Len(t, mentioned, 1)
+		assert.Equal(t, 8, mentioned[0].UserID)
+	})
+
+	t.Run("TestCreateComment_Empty", func(t *testing.T) {
+		service, d := newService()
+
+		_, _, err := service.CreateComment(context.Background(), 7, 10, "   ")
+		assert.ErrorIs(t, err, ErrInvalidComment)
+		d.comments.AssertNotCalled(t, "CreateComment", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestCreateComment_NoAccess", func(t *testing.T) {
+		service, d := newService()
+		d.todos.On("GetTodo", mock.Anything, 7, 10).Return(entity.ToDo{}, fmt.Errorf("todo %w", repository.ErrNotFound))
+
+		_, _, err := service.CreateComment(context.Background(), 7, 10, "hello")
+		assert.ErrorIs(t, err, ErrTodoNotFound)
+	})
+
+	t.Run("TestUpdateComment_OnlyNewMentions", func(t *testing.T) {
+		service, d := newService()
+		existing := &entity.Comment{CommentID: 3, TodoID: 10, UserID: 7, Body: "ping @alice"}
+		d.todos.On("GetTodo", mock.Anything, 7, 10).Return(todo, nil)
+		d.comments.On("GetComment", mock.Anything, 10, 3).Return(existing, nil)
+		d.comments.On("UpdateComment", mock.Anything, existing, "ping @alice and @bob").Return(nil)
+		d.users.On("GetUserByUserName", mock.Anything, "bob").Return(&entity.User{UserID: 11, UserName: "bob"}, nil)
+		d.lists.On("GetMemberRole", mock.Anything, 5, 11).Return(entity.ListRoleEditor, nil)
+
+		_, mentioned, err := service.UpdateComment(context.Background(), 7, 10, 3, "ping @alice and @bob")
+		assert.NoError(t, err)
+		assert.Len(t, mentioned, 1)
+		assert.Equal(t, "bob", mentioned[0].UserName)
+	})
+
+	t.Run("TestUpdateComment_NotAuthor", func(t *testing.T) {
+		service, d := newService()
+		d.todos.On("GetTodo", mock.Anything, 8, 10).Return(todo, nil)
+		d.comments.On("GetComment", mock.Anything, 10, 3).Return(&entity.Comment{CommentID: 3, UserID: 7}, nil)
+
+		_, _, err := service.UpdateComment(context.Background(), 8, 10, 3, "rewritten")
+		assert.ErrorIs(t, err, ErrCommentPermission)
+	})
+
+	t.Run("TestDeleteComment_ListOwner", func(t *testing.T) {
+		service, d := newService()
+		d.todos.On("GetTodo", mock.Anything, 8, 10).Return(todo, nil)
+		d.comments.On("GetComment", mock.Anything, 10, 3).Return(&entity.Comment{CommentID: 3, UserID: 7}, nil)
+		d.lists.On("GetMemberRole", mock.Anything, 5, 8).Return(entity.ListRoleOwner, nil)
+		d.comments.On("DeleteComment", mock.Anything, 10, 3).Return(nil)
+
+		assert.NoError(t, service.DeleteComment(context.Background(), 8, 10, 3))
+		d.comments.AssertExpectations(t)
+	})
+
+	t.Run("TestDeleteComment_EditorForbidden", func(t *testing.T) {
+		service, d := newService()
+		d.todos.On("GetTodo", mock.Anything, 8, 10).Return(todo, nil)
+		d.comments.On("GetComment", mock.Anything, 10, 3).Return(&entity.Comment{CommentID: 3, UserID: 7}, nil)
+		d.lists.On("GetMemberRole", mock.Anything, 5, 8).Return(entity.ListRoleEditor, nil)
+
+		assert.ErrorIs(t, service.DeleteComment(context.Background(), 8, 10, 3), ErrCommentPermission)
+		d.comments.AssertNotCalled(t, "DeleteComment", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
@@ -5,11 +5,13 @@ import (
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
+	"github.com/srikanthbhandary/todo-server/entity"
 )
 
 type JWTValidator interface {
 	ValidateToken(tokenString string) (int, error)
-	GenerateToken(userID int) (string, error)
+	ParseToken(tokenString string) (*entity.TokenClaims, error)
+	GenerateToken(userID int, scopes []string) (string, error)
 }
 type JWTService struct {
 	secretKey string
@@ -19,11 +21,14 @@ func NewJWTService(secret string) JWTValidator {
 	return &JWTService{secretKey: secret}
 }
 
-// GenerateToken generates a new JWT token for a user
-func (j *JWTService) GenerateToken(userID int) (string, error) {
+// GenerateToken generates a new JWT token for a user, limited to the given scopes
+func (j *JWTService) GenerateToken(userID int, scopes []string) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
-		"sub": userID,
-		"exp": time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+		"sub":    userID,
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+		"scopes": scopes,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -32,6 +37,15 @@ func (j *JWTService) GenerateToken(userID int) (string, error) {
 
 // ValidateToken validates the JWT token and returns the user ID if valid
 func (j *JWTService) ValidateToken(tokenString string) (int, error) {
+	claims, err := j.ParseToken(tokenString)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
+
+// ParseToken validates the JWT token and returns the claims it carries
+func (j *JWTService) ParseToken(tokenString string) (*entity.TokenClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		// Ensure that the token's signing method is what you expect
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -39,9 +53,30 @@ func (j *JWTService) ValidateToken(tokenString string) (int, error) {
 		}
 		return []byte(j.secretKey), nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return int(claims["sub"].(float64)), nil
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok {
+		return nil, errors.New("invalid token subject")
+	}
+
+	result := &entity.TokenClaims{UserID: int(sub)}
+	if iat, ok := claims["iat"].(float64); ok {
+		result.IssuedAt = time.Unix(int64(iat), 0)
+	}
+	if scopes, ok := claims["scopes"].([]interface{}); ok {
+		result.Scopes = make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if s, ok := scope.(string); ok {
+				result.Scopes = append(result.Scopes, s)
+			}
+		}
 	}
-	return 0, err
+	return result, nil
 }
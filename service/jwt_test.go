@@ -11,7 +11,7 @@ func TestJWTService(t *testing.T) {
 
 	// Test generating a token
 	userID := 1
-	token, err := jwtService.GenerateToken(userID)
+	token, err := jwtService.GenerateToken(userID, []string{"todos:read"})
 	if err != nil {
 		t.Errorf("expected no error, got %v", err)
 		return // Exit early to prevent using an invalid token
@@ -28,3 +28,20 @@ func TestJWTService(t *testing.T) {
 		t.Errorf("expected userID %d, got %d", userID, validatedUserID)
 	}
 }
+
+func TestJWTServiceScopes(t *testing.T) {
+	jwtService := NewJWTService("test")
+
+	token, err := jwtService.GenerateToken(1, []string{"todos:read"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	claims, err := jwtService.ParseToken(token)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(claims.Scopes) != 1 || claims.Scopes[0] != "todos:read" {
+		t.Errorf("expected scopes [todos:read], got %v", claims.Scopes)
+	}
+}
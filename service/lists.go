@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/repository"
+)
+
+var (
+	// ErrListNotFound is returned for lists that do not exist or the user is not a member of.
+	ErrListNotFound = errors.New("list not found")
+
+	// ErrListPermission is returned when the user's role in a list does not allow the action.
+	ErrListPermission = errors.New("insufficient list role")
+
+	// ErrInvalidListRole is returned when granting a list role that does not exist.
+	ErrInvalidListRole = errors.New("invalid list role")
+
+	// ErrLastOwner is returned when a change would leave a list without an owner.
+	ErrLastOwner = errors.New("a list must keep at least one owner")
+
+	// ErrPersonalList is returned when sharing or deleting a personal list.
+	ErrPersonalList = errors.New("personal lists cannot be shared or deleted")
+
+	// ErrInvitationNotFound is returned for invitations that are not pending for the user.
+	ErrInvitationNotFound = errors.New("invitation not found")
+
+	// ErrInvalidListName is returned when a list is created without a name.
+	ErrInvalidListName = errors.New("list name is required")
+)
+
+// ListService manages shared todo lists, their members and invitations
+type ListService interface {
+	CreateList(ctx context.Context, userID int, name string) (*entity.TodoList, error)
+	GetLists(ctx context.Context, userID int) ([]entity.TodoList, error)
+	GetList(ctx context.Context, userID, listID int) (*entity.TodoList, []entity.ListMember, error)
+	GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error)
+	DeleteList(ctx context.Context, userID, listID int) error
+	InviteMember(ctx context.Context, userID, listID int, email, role string) (*entity.ListInvitation, error)
+	GetInvitations(ctx context.Context, userID int) ([]entity.ListInvitation, error)
+	RespondToInvitation(ctx context.Context, userID, invitationID int, accept bool) (*entity.ListInvitation, error)
+	SetMemberRole(ctx context.Context, userID, listID, memberID int, role string) error
+	RemoveMember(ctx context.Context, userID, listID, memberID int) error
+}
+
+// ListServiceImpl is the implementation of ListService interface
+type ListServiceImpl struct {
+	lists repository.ListRepository
+	todos repository.ToDoRepository
+	users repository.UserRepository
+}
+
+// NewListService creates a new instance of ListServiceImpl
+func NewListService(lists repository.ListRepository, todos repository.ToDoRepository, users repository.UserRepository) *ListServiceImpl {
+	return &ListServiceImpl{lists: lists, todos: todos, users: users}
+}
+
+// CreateList creates a shared list owned by the user
+func (s *ListServiceImpl) CreateList(ctx context.Context, userID int, name string) (*entity.TodoList, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrInvalidListName
+	}
+
+	list := &entity.TodoList{Name: name, OwnerID: userID}
+	if err := s.lists.CreateList(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetLists returns every list the user belongs to, making sure the personal list exists
+func (s *ListServiceImpl) GetLists(ctx context.Context, userID int) ([]entity.TodoList, error) {
+	if _, err := s.lists.EnsurePersonalList(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.lists.GetLists(ctx, userID)
+}
+
+// GetList returns a list and its members
+func (s *ListServiceImpl) GetList(ctx context.Context, userID, listID int) (*entity.TodoList, []entity.ListMember, error) {
+	if _, err := s.requireRole(ctx, userID, listID, entity.ListRoleViewer); err != nil {
+		return nil, nil, err
+	}
+
+	list, err := s.lists.GetList(ctx, userID, listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	members, err := s.lists.GetMembers(ctx, listID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return list, members, nil
+}
+
+// GetListTodos returns the todos of a list the user can view
+func (s *ListServiceImpl) GetListTodos(ctx context.Context, userID, listID int) ([]entity.ToDo, error) {
+	if _, err := s.requireRole(ctx, userID, listID, entity.ListRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.todos.GetListTodos(ctx, userID, listID)
+}
+
+// DeleteList deletes a shared list and its todos; only owners may do so
+func (s *ListServiceImpl) DeleteList(ctx context.Context, userID, listID int) error {
+	list, err := s.ownedSharedList(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+	return s.lists.DeleteList(ctx, list.ListID)
+}
+
+// InviteMember invites whoever owns email to join the list with the given role; only owners may invite
+func (s *ListServiceImpl) InviteMember(ctx context.Context, userID, listID int, email, role string) (*entity.ListInvitation, error) {
+	if !entity.ValidListRole(role) {
+		return nil, ErrInvalidListRole
+	}
+	list, err := s.ownedSharedList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &entity.ListInvitation{
+		ListID:    list.ListID,
+		ListName:  list.Name,
+		Email:     strings.TrimSpace(email),
+		Role:      role,
+		InvitedBy: userID,
+	}
+	if err := s.lists.CreateInvitation(ctx, invitation); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+// GetInvitations returns the pending invitations addressed to the user's email
+func (s *ListServiceImpl) GetInvitations(ctx context.Context, userID int) ([]entity.ListInvitation, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.lists.GetPendingInvitations(ctx, user.Email)
+}
+
+// RespondToInvitation accepts or declines an invitation addressed to the user's email
+func (s *ListServiceImpl) RespondToInvitation(ctx context.Context, userID, invitationID int, accept bool) (*entity.ListInvitation, error) {
+	user, err := s.users.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation, err := s.lists.RespondToInvitation(ctx, invitationID, userID, user.Email, accept)
+	if err != nil {
+		return nil, ErrInvitationNotFound
+	}
+	return invitation, nil
+}
+
+// SetMemberRole changes a member's role; only owners may do so and the last owner cannot step down
+func (s *ListServiceImpl) SetMemberRole(ctx context.Context, userID, listID, memberID int, role string) error {
+	if !entity.ValidListRole(role) {
+		return ErrInvalidListRole
+	}
+	if _, err := s.requireRole(ctx, userID, listID, entity.ListRoleOwner); err != nil {
+		return err
+	}
+	if role != entity.ListRoleOwner {
+		if err := s.keepOwner(ctx, listID, memberID); err != nil {
+			return err
+		}
+	}
+	return s.lists.SetMemberRole(ctx, listID, memberID, role)
+}
+
+// RemoveMember removes a member from a list.
+// Owners can remove anyone and every member can leave, but the last owner has to delete the list instead.
+func (s *ListServiceImpl) RemoveMember(ctx context.Context, userID, listID, memberID int) error {
+	required := entity.ListRoleOwner
+	if userID == memberID {
+		required = entity.ListRoleViewer
+	}
+	if _, err := s.requireRole(ctx, userID, listID, required); err != nil {
+		return err
+	}
+	if err := s.keepOwner(ctx, listID, memberID); err != nil {
+		return err
+	}
+	return s.lists.RemoveMember(ctx, listID, memberID)
+}
+
+// requireRole returns the user's role in the list if it grants at least minimum.
+// Non-members get ErrListNotFound so lists of other users stay invisible.
+func (s *ListServiceImpl) requireRole(ctx context.Context, userID, listID int, minimum string) (string, error) {
+	role, err := s.lists.GetMemberRole(ctx, listID, userID)
+	if err != nil {
+		return "", err
+	}
+	if role == "" {
+		return "", ErrListNotFound
+	}
+	if !entity.ListRoleAtLeast(role, minimum) {
+		return role, ErrListPermission
+	}
+	return role, nil
+}
+
+// ownedSharedList loads a list the user owns, refusing personal lists
+func (s *ListServiceImpl) ownedSharedList(ctx context.Context, userID, listID int) (*entity.TodoList, error) {
+	if _, err := s.requireRole(ctx, userID, listID, entity.ListRoleOwner); err != nil {
+		return nil, err
+	}
+	list, err := s.lists.GetList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.Personal {
+		return nil, ErrPersonalList
+	}
+	return list, nil
+}
+
+// keepOwner fails when memberID is the only owner of the list
+func (s *ListServiceImpl) keepOwner(ctx context.Context, listID, memberID int) error {
+	members, err := s.lists.GetMembers(ctx, listID)
+	if err != nil {
+		return err
+	}
+
+	owners, isOwner := 0, false
+	for _, member := range members {
+		if member.Role == entity.ListRoleOwner {
+			owners++
+			isOwner = isOwner || member.UserID == memberID
+		}
+	}
+	if isOwner && owners == 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListService(t *testing.T) {
+	newService := func() (*ListServiceImpl, *mocks.MockListRepository, *mocks.MockToDoRepository, *mocks.MockUserRepository) {
+		lists := new(mocks.MockListRepository)
+		todos := new(mocks.MockToDoRepository)
+		users := new(mocks.MockUserRepository)
+		return NewListService(lists, todos, users), lists, todos, users
+	}
+
+	t.Run("TestGetListTodos_Viewer", func(t *testing.T) {
+		service, lists, todos, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 7).Return(entity.ListRoleViewer, nil)
+		todos.On("GetListTodos", mock.Anything, 7, 5).Return([]entity.ToDo{{ToDoID: 1, ListID: 5}}, nil)
+
+		result, err := service.GetListTodos(context.Background(), 7, 5)
+		assert.NoError(t, err)
+		assert.Len(t, result, 1)
+	})
+
+	t.Run("TestGetListTodos_NotMember", func(t *testing.T) {
+		service, lists, todos, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 7).Return("", nil)
+
+		_, err := service.GetListTodos(context.Background(), 7, 5)
+		assert.ErrorIs(t, err, ErrListNotFound)
+		todos.AssertNotCalled(t, "GetListTodos", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestInviteMember_EditorForbidden", func(t *testing.T) {
+		service, lists, _, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 7).Return(entity.ListRoleEditor, nil)
+
+		_, err := service.InviteMember(context.Background(), 7, 5, "friend@example.com", entity.ListRoleViewer)
+		assert.ErrorIs(t, err, ErrListPermission)
+		lists.AssertNotCalled(t, "CreateInvitation", mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestInviteMember_PersonalList", func(t *testing.T) {
+		service, lists, _, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 7).Return(entity.ListRoleOwner, nil)
+		lists.On("GetList", mock.Anything, 7, 5).Return(&entity.TodoList{ListID: 5, Personal: true}, nil)
+
+		_, err := service.InviteMember(context.Background(), 7, 5, "friend@example.com", entity.ListRoleViewer)
+		assert.ErrorIs(t, err, ErrPersonalList)
+	})
+
+	t.Run("TestInviteMember_SUCCESS", func(t *testing.T) {
+		service, lists, _, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 7).Return(entity.ListRoleOwner, nil)
+		lists.On("GetList", mock.Anything, 7, 5).Return(&entity.TodoList{ListID: 5, Name: "Release"}, nil)
+		lists.On("CreateInvitation", mock.Anything, mock.MatchedBy(func(i *entity.ListInvitation) bool {
+			return i.ListID == 5 && i.Email == "friend@example.com" && i.Role == entity.ListRoleEditor && i.InvitedBy == 7
+		})).Return(nil)
+
+		invitation, err := service.InviteMember(context.Background(), 7, 5, " friend@example.com ", entity.ListRoleEditor)
+		assert.NoError(t, err)
+		assert.Equal(t, "Release", invitation.ListName)
+	})
+
+	t.Run("TestRemoveMember_LastOwnerCannotLeave", func(t *testing.T) {
+		service, lists, _, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 7).Return(entity.ListRoleOwner, nil)
+		lists.On("GetMembers", mock.Anything, 5).Return([]entity.ListMember{
+			{UserID: 7, Role: entity.ListRoleOwner},
+			{UserID: 8, Role: entity.ListRoleEditor},
+		}, nil)
+
+		err := service.RemoveMember(context.Background(), 7, 5, 7)
+		assert.ErrorIs(t, err, ErrLastOwner)
+		lists.AssertNotCalled(t, "RemoveMember", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestRemoveMember_ViewerLeaves", func(t *testing.T) {
+		service, lists, _, _ := newService()
+		lists.On("GetMemberRole", mock.Anything, 5, 8).Return(entity.ListRoleViewer, nil)
+		lists.On("GetMembers", mock.Anything, 5).Return([]entity.ListMember{
+			{UserID: 7, Role: entity.ListRoleOwner},
+			{UserID: 8, Role: entity.ListRoleViewer},
+		}, nil)
+		lists.On("RemoveMember", mock.Anything, 5, 8).Return(nil)
+
+		err := service.RemoveMember(context.Background(), 8, 5, 8)
+		assert.NoError(t, err)
+		lists.AssertExpectations(t)
+	})
+
+	t.Run("TestRespondToInvitation_MatchesEmail", func(t *testing.T) {
+		service, lists, _, users := newService()
+		users.On("GetUserByID", mock.Anything, 8).Return(&entity.User{UserID: 8, Email: "friend@example.com"}, nil)
+		lists.On("RespondToInvitation", mock.Anything, 3, 8, "friend@example.com", true).
+			Return(&entity.ListInvitation{InvitationID: 3, Status: entity.InvitationAccepted}, nil)
+
+		invitation, err := service.RespondToInvitation(context.Background(), 8, 3, true)
+		assert.NoError(t, err)
+		assert.Equal(t, entity.InvitationAccepted, invitation.Status)
+	})
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/srikanthbhandary/todo-server/repository"
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheClient is the part of the Redis client the todo cache uses
+type CacheClient interface {
+	Get(key string) *redis.StringCmd
+	Set(key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	SetNX(key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+}
+
+// TodoCache keeps each user's todo reads in Redis.
+//
+// Every user has a generation stored next to their entries, and entries are keyed by it, so dropping
+// everything cached for a user is a single write: entries of an older generation are never read again
+// and expire with their TTL. Generations are random rather than counters, so one lost to eviction or
+// expiry is never reused while entries made under it are still around.
+//
+// Redis errors are logged and counted, and the read falls through to the database, so a Redis outage
+// slows reads down rather than failing them.
+type TodoCache struct {
+	client  CacheClient
+	ttl     time.Duration
+	flights singleflight.Group
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	coalesced atomic.Int64
+	errors    atomic.Int64
+}
+
+// NewTodoCache creates a TodoCache whose entries live for at most ttl
+func NewTodoCache(client CacheClient, ttl time.Duration) *TodoCache {
+	return &TodoCache{client: client, ttl: ttl}
+}
+
+// Invalidate drops everything cached for the given users
+func (c *TodoCache) Invalidate(userIDs ...int) {
+	seen := make(map[int]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if err := c.client.Set(generationKey(userID), newGeneration(), c.ttl).Err(); err != nil {
+			c.fail(err, "invalidate user %d", userID)
+		}
+	}
+}
+
+// Metrics reports the cache counters and the share of reads answered from Redis
+func (c *TodoCache) Metrics() map[string]float64 {
+	hits, misses := float64(c.hits.Load()), float64(c.misses.Load())
+	ratio := 0.0
+	if hits+misses > 0 {
+		ratio = hits / (hits + misses)
+	}
+	return map[string]float64{
+		"hits":      hits,
+		"misses":    misses,
+		"coalesced": float64(c.coalesced.Load()),
+		"errors":    float64(c.errors.Load()),
+		"hit_ratio": ratio,
+	}
+}
+
+// generation returns the user's current generation, starting one if they have none
+func (c *TodoCache) generation(userID int) (string, error) {
+	key := generationKey(userID)
+	gen, err := c.client.Get(key).Result()
+	if err != redis.Nil {
+		return gen, err
+	}
+
+	gen = newGeneration()
+	started, err := c.client.SetNX(key, gen, c.ttl).Result()
+	if err != nil || started {
+		return gen, err
+	}
+	// Another reader started one first
+	return c.client.Get(key).Result()
+}
+
+// fail counts and logs a Redis error; action describes what failed, formatted with args
+func (c *TodoCache) fail(err error, action string, args ...interface{}) {
+	c.errors.Add(1)
+	slog.Warn("todo cache: failed to "+fmt.Sprintf(action, args...), "error", err)
+}
+
+// cachedRead answers a read of the user's todos from the cache, or runs fetch and caches its result.
+// Concurrent misses on the same entry share one fetch. Misses are fetched from the primary database:
+// a replica that has not caught up with a write would otherwise have its stale rows cached under the
+// generation the write started, for a whole TTL. Reads that must see the primary skip the cache.
+func cachedRead[T any](ctx context.Context, c *TodoCache, userID int, entry string, fetch func(context.Context) (T, error)) (T, error) {
+	if repository.UsesPrimary(ctx) {
+		return fetch(ctx)
+	}
+	gen, err := c.generation(userID)
+	if err != nil {
+		c.fail(err, "read the generation of user %d", userID)
+		return fetch(ctx)
+	}
+
+	key := fmt.Sprintf("todo_cache:%d:%s:%s", userID, gen, entry)
+	data, err := c.client.Get(key).Bytes()
+	if err == nil {
+		var value T
+		if err := json.Unmarshal(data, &value); err == nil {
+			c.hits.Add(1)
+			return value, nil
+		}
+	} else if err != redis.Nil {
+		c.fail(err, "read %s", key)
+	}
+	c.misses.Add(1)
+
+	// The fetch is shared, so one caller going away must not fail it for the others
+	flight := c.flights.DoChan(key, func() (interface{}, error) {
+		value, err := fetch(repository.WithPrimary(context.WithoutCancel(ctx)))
+		if err != nil {
+			return value, err
+		}
+		if data, err := json.Marshal(value); err == nil {
+			if err := c.client.Set(key, data, c.ttl).Err(); err != nil {
+				c.fail(err, "write %s", key)
+			}
+		}
+		return value, nil
+	})
+	select {
+	case result := <-flight:
+		if result.Shared {
+			c.coalesced.Add(1)
+		}
+		value, _ := result.Val.(T)
+		return value, result.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+func generationKey(userID int) string {
+	return fmt.Sprintf("todo_cache:%d:gen", userID)
+}
+
+func newGeneration() string {
+	return strconv.FormatUint(rand.Uint64(), 36)
+}
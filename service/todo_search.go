@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+)
+
+// MaxSearchQueryLength caps the length of a search query in bytes
+const MaxSearchQueryLength = 256
+
+// ErrInvalidSearch is returned when a search query has no words to look for or is too long
+var ErrInvalidSearch = errors.New("search query must contain at least one word and be at most 256 characters")
+
+// SearchTodos returns a page of the user's todos matching every term of query, most relevant first
+func (s *TodoServiceImpl) SearchTodos(ctx context.Context, userID int, query string, limit, offset int) ([]entity.TodoSearchResult, error) {
+	if len(query) > MaxSearchQueryLength {
+		return nil, ErrInvalidSearch
+	}
+	terms := ParseSearchQuery(query)
+	if len(terms) == 0 {
+		return nil, ErrInvalidSearch
+	}
+	return s.repo.SearchTodos(ctx, userID, terms, limit, offset)
+}
+
+// ParseSearchQuery splits a query into terms. Text in double quotes is a phrase, a word ending
+// in '*' matches as a prefix, and everything else is a plain word. Words are lower-cased and
+// reduced to letters and digits; a word joined by punctuation, like "e-mail", becomes a phrase.
+func ParseSearchQuery(query string) []entity.SearchTerm {
+	var terms []entity.SearchTerm
+	for i, part := range strings.Split(query, `"`) {
+		// Odd parts sit between a pair of quotes
+		if i%2 == 1 {
+			if words := searchWords(part); len(words) > 0 {
+				terms = append(terms, entity.SearchTerm{Words: words})
+			}
+			continue
+		}
+		for _, field := range strings.Fields(part) {
+			words := searchWords(field)
+			if len(words) == 0 {
+				continue
+			}
+			terms = append(terms, entity.SearchTerm{Words: words, Prefix: strings.HasSuffix(field, "*")})
+		}
+	}
+	return terms
+}
+
+// searchWords lower-cases text and splits it into runs of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
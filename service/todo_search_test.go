@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/srikanthbhandary/todo-server/entity"
+	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		terms []entity.SearchTerm
+	}{
+		{"Invoice", []entity.SearchTerm{{Words: []string{"invoice"}}}},
+		{`"March invoice" pay*`, []entity.SearchTerm{
+			{Words: []string{"march", "invoice"}},
+			{Words: []string{"pay"}, Prefix: true},
+		}},
+		{"e-mail", []entity.SearchTerm{{Words: []string{"e", "mail"}}}},
+		{`'); DROP TABLE todos; -- "unclosed`, []entity.SearchTerm{
+			{Words: []string{"drop"}},
+			{Words: []string{"table"}},
+			{Words: []string{"todos"}},
+			{Words: []string{"unclosed"}},
+		}},
+		{` * "" !`, nil},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.terms, ParseSearchQuery(test.query), test.query)
+	}
+}
+
+func TestSearchTodos(t *testing.T) {
+	t.Run("TestSearchTodos_PassesTerms", func(t *testing.T) {
+		repo := new(mocks.MockToDoRepository)
+		todos := NewTodoService(repo)
+		terms := []entity.SearchTerm{{Words: []string{"invoice"}}}
+		repo.On("SearchTodos", mock.Anything, 1, terms, 50, 0).Return([]entity.TodoSearchResult{{Rank: 0.1}}, nil)
+
+		results, err := todos.SearchTodos(context.Background(), 1, "invoice", 50, 0)
+
+		assert.NoError(t, err)
+		assert.Len(t, results, 1)
+	})
+
+	t.Run("TestSearchTodos_Invalid", func(t *testing.T) {
+		todos := NewTodoService(new(mocks.MockToDoRepository))
+
+		_, err := todos.SearchTodos(context.Background(), 1, `""`, 50, 0)
+		assert.ErrorIs(t, err, ErrInvalidSearch)
+
+		_, err = todos.SearchTodos(context.Background(), 1, strings.Repeat("a", MaxSearchQueryLength+1), 50, 0)
+		assert.ErrorIs(t, err, ErrInvalidSearch)
+	})
+}
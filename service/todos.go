@@ -2,19 +2,66 @@ package service
 
 import (
 	"context"
-
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/srikanthbhandary/todo-server/repository"
 )
 
+// DeleteAllUndoWindow is how long after deleting all todos the deletion can be undone
+const DeleteAllUndoWindow = 15 * time.Minute
+
+var (
+	// ErrNothingToUndo is returned when there is no recent "delete all" left to undo
+	ErrNothingToUndo = errors.New("no recent deletion to undo")
+
+	// ErrInvalidTodo is returned when an update would leave a todo without a title
+	ErrInvalidTodo = errors.New("todo title cannot be empty")
+)
+
+// ConflictError is returned when a write was conditioned on a version of a todo that is no longer current.
+// Current holds the todo as it is now, when it can still be read.
+type ConflictError struct {
+	TodoID  int
+	Current *entity.ToDo
+}
+
+func (e *ConflictError) Error() string {
+	if e.Current == nil {
+		return fmt.Sprintf("todo %d has been changed by someone else", e.TodoID)
+	}
+	return fmt.Sprintf("todo %d has been changed by someone else and is now at version %d", e.TodoID, e.Current.Version)
+}
+
+// Unwrap lets callers match the repository's ErrVersionConflict
+func (e *ConflictError) Unwrap() error {
+	return repository.ErrVersionConflict
+}
+
+// ToDoService manages todos. Methods taking an expected version only apply the change to that
+// version of the todo and return a *ConflictError otherwise; zero skips the check.
 type ToDoService interface {
 	AddToDo(ctx context.Context, todo *entity.ToDo) error
 	GetAllTodos(ctx context.Context, userID int) ([]entity.ToDo, error)
 	GetTodo(ctx context.Context, userID, todoID int) (entity.ToDo, error)
-	DeleteToDo(ctx context.Context, userID, todoID int) error
+	UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error)
+	DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error
 	DeleteAllTodos(ctx context.Context, userID int) error
+	GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error)
+	AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error)
+	GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error)
+	GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error)
+	RestoreToDo(ctx context.Context, userID, todoID int) (entity.ToDo, error)
+	UndoDeleteAll(ctx context.Context, userID int) (int, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (int64, error)
+	GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error)
+	DiffRevisions(ctx context.Context, userID, todoID, from, to int) ([]entity.FieldChange, error)
+	RevertToDo(ctx context.Context, userID, todoID, revision, expectedVersion int) (entity.ToDo, error)
+	RunBatch(ctx context.Context, userID int, ops []entity.BatchOperation, atomic bool) ([]entity.BatchOutcome, error)
+	SearchTodos(ctx context.Context, userID int, query string, limit, offset int) ([]entity.TodoSearchResult, error)
 }
 
 // TodoServiceImpl is the implementation of ToDoService interface
@@ -46,12 +93,113 @@ func (s *TodoServiceImpl) GetTodo(ctx context.Context, userID, todoID int) (enti
 	return s.repo.GetTodo(ctx, userID, todoID) // Call the repository to get the specific todo
 }
 
+// UpdateToDo changes the given fields of a todo, recording the result as a new revision
+func (s *TodoServiceImpl) UpdateToDo(ctx context.Context, userID, todoID int, update entity.TodoUpdate, expectedVersion int) (entity.ToDo, error) {
+	if update.Title != nil && strings.TrimSpace(*update.Title) == "" {
+		return entity.ToDo{}, ErrInvalidTodo
+	}
+	todo, err := s.repo.UpdateToDo(ctx, userID, todoID, update, expectedVersion)
+	return todo, s.conflict(ctx, userID, todoID, err)
+}
+
 // DeleteToDo deletes a specific todo for a user
-func (s *TodoServiceImpl) DeleteToDo(ctx context.Context, userID, todoID int) error {
-	return s.repo.DeleteToDo(ctx, userID, todoID) // Call the repository to delete the todo
+func (s *TodoServiceImpl) DeleteToDo(ctx context.Context, userID, todoID, expectedVersion int) error {
+	err := s.repo.DeleteToDo(ctx, userID, todoID, expectedVersion) // Call the repository to delete the todo
+	return s.conflict(ctx, userID, todoID, err)
 }
 
 // DeleteAllTodos deletes all todos for a specific user.
 func (s *TodoServiceImpl) DeleteAllTodos(ctx context.Context, userID int) error {
 	return s.repo.DeleteAllTodos(ctx, userID) // Call the repository to delete all todos for the user
 }
+
+// GetAssignedTodos retrieves the todos assigned to a user
+func (s *TodoServiceImpl) GetAssignedTodos(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return s.repo.GetAssignedTodos(ctx, userID)
+}
+
+// AssignTodo assigns a todo to a member of its list, or unassigns it when assigneeID is nil
+func (s *TodoServiceImpl) AssignTodo(ctx context.Context, userID, todoID int, assigneeID *int) (*entity.TodoAssignment, error) {
+	return s.repo.AssignTodo(ctx, userID, todoID, assigneeID)
+}
+
+// GetAssignmentHistory retrieves the assignee changes of a todo
+func (s *TodoServiceImpl) GetAssignmentHistory(ctx context.Context, userID, todoID int) ([]entity.TodoAssignment, error) {
+	return s.repo.GetAssignmentHistory(ctx, userID, todoID)
+}
+
+// GetTrash retrieves the deleted todos the user can still see
+func (s *TodoServiceImpl) GetTrash(ctx context.Context, userID int) ([]entity.ToDo, error) {
+	return s.repo.GetTrash(ctx, userID)
+}
+
+// RestoreToDo takes a todo out of the trash and returns it
+func (s *TodoServiceImpl) RestoreToDo(ctx context.Context, userID, todoID int) (entity.ToDo, error) {
+	if err := s.repo.RestoreToDo(ctx, userID, todoID); err != nil {
+		return entity.ToDo{}, err
+	}
+	// A replica may not have seen the restore yet
+	return s.repo.GetTodo(repository.WithPrimary(ctx), userID, todoID)
+}
+
+// UndoDeleteAll restores the todos removed by the user's last "delete all", if it was within DeleteAllUndoWindow
+func (s *TodoServiceImpl) UndoDeleteAll(ctx context.Context, userID int) (int, error) {
+	restored, err := s.repo.UndoDeleteAll(ctx, userID, time.Now().Add(-DeleteAllUndoWindow))
+	if err != nil {
+		return 0, err
+	}
+	if restored == 0 {
+		return 0, ErrNothingToUndo
+	}
+	return restored, nil
+}
+
+// PurgeTrash permanently deletes todos that have been in the trash longer than the retention period
+func (s *TodoServiceImpl) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.PurgeDeletedTodos(ctx, time.Now().Add(-retention))
+}
+
+// GetRevisions retrieves every version of a todo, oldest first
+func (s *TodoServiceImpl) GetRevisions(ctx context.Context, userID, todoID int) ([]entity.TodoRevision, error) {
+	return s.repo.GetRevisions(ctx, userID, todoID)
+}
+
+// DiffRevisions lists the fields that changed between two revisions of a todo
+func (s *TodoServiceImpl) DiffRevisions(ctx context.Context, userID, todoID, from, to int) ([]entity.FieldChange, error) {
+	older, err := s.repo.GetRevision(ctx, userID, todoID, from)
+	if err != nil {
+		return nil, err
+	}
+	newer, err := s.repo.GetRevision(ctx, userID, todoID, to)
+	if err != nil {
+		return nil, err
+	}
+	return older.Diff(newer), nil
+}
+
+// RevertToDo restores a todo to an earlier revision. The revert is itself recorded as a new revision.
+func (s *TodoServiceImpl) RevertToDo(ctx context.Context, userID, todoID, revision, expectedVersion int) (entity.ToDo, error) {
+	target, err := s.repo.GetRevision(ctx, userID, todoID, revision)
+	if err != nil {
+		return entity.ToDo{}, err
+	}
+	todo, err := s.repo.UpdateToDo(ctx, userID, todoID, entity.TodoUpdate{
+		Title:       &target.Title,
+		Description: &target.Description,
+		DateTime:    &target.DateTime,
+	}, expectedVersion)
+	return todo, s.conflict(ctx, userID, todoID, err)
+}
+
+// conflict turns the repository's version conflict into a *ConflictError carrying the current todo.
+// Other errors, and nil, are returned unchanged.
+func (s *TodoServiceImpl) conflict(ctx context.Context, userID, todoID int, err error) error {
+	if !errors.Is(err, repository.ErrVersionConflict) {
+		return err
+	}
+	conflict := &ConflictError{TodoID: todoID}
+	if current, getErr := s.repo.GetTodo(repository.WithPrimary(ctx), userID, todoID); getErr == nil {
+		conflict.Current = &current
+	}
+	return conflict
+}
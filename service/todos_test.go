@@ -7,6 +7,7 @@ import (
 
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/srikanthbhandary/todo-server/mocks"
+	"github.com/srikanthbhandary/todo-server/repository"
 	"github.com/srikanthbhandary/todo-server/service"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -53,9 +54,9 @@ func TestToDo(t *testing.T) {
 	})
 
 	t.Run("TestDeleteToDo_SUCCESS", func(t *testing.T) {
-		mockRepo.On("DeleteToDo", mock.Anything, 1, 1).Return(nil)
+		mockRepo.On("DeleteToDo", mock.Anything, 1, 1, 0).Return(nil)
 
-		err := service.DeleteToDo(context.Background(), 1, 1)
+		err := service.DeleteToDo(context.Background(), 1, 1, 0)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -70,4 +71,94 @@ func TestToDo(t *testing.T) {
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("TestRestoreToDo_SUCCESS", func(t *testing.T) {
+		mockRepo.On("RestoreToDo", mock.Anything, 1, 2).Return(nil)
+		mockRepo.On("GetTodo", mock.Anything, 1, 2).Return(entity.ToDo{ToDoID: 2}, nil)
+
+		todo, err := service.RestoreToDo(context.Background(), 1, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, todo.ToDoID)
+	})
+
+}
+
+func TestUndoDeleteAll(t *testing.T) {
+	mockRepo := new(mocks.MockToDoRepository)
+	todos := service.NewTodoService(mockRepo)
+
+	t.Run("TestUndoDeleteAll_WithinWindow", func(t *testing.T) {
+		mockRepo.On("UndoDeleteAll", mock.Anything, 1, mock.MatchedBy(func(since time.Time) bool {
+			return time.Since(since) >= service.DeleteAllUndoWindow && time.Since(since) < service.DeleteAllUndoWindow+time.Minute
+		})).Return(3, nil).Once()
+
+		restored, err := todos.UndoDeleteAll(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, restored)
+	})
+
+	t.Run("TestUndoDeleteAll_NothingToUndo", func(t *testing.T) {
+		mockRepo.On("UndoDeleteAll", mock.Anything, 1, mock.Anything).Return(0, nil).Once()
+
+		_, err := todos.UndoDeleteAll(context.Background(), 1)
+
+		assert.ErrorIs(t, err, service.ErrNothingToUndo)
+	})
+}
+
+func TestTodoRevisions(t *testing.T) {
+	mockRepo := new(mocks.MockToDoRepository)
+	todos := service.NewTodoService(mockRepo)
+	due := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	first := entity.TodoRevision{Revision: 1, TodoID: 3, Title: "Draft", Description: "same", DateTime: due}
+	second := entity.TodoRevision{Revision: 2, TodoID: 3, Title: "Final", Description: "same", DateTime: due.Add(time.Hour)}
+
+	t.Run("TestUpdateToDo_EmptyTitle", func(t *testing.T) {
+		empty := " "
+		_, err := todos.UpdateToDo(context.Background(), 1, 3, entity.TodoUpdate{Title: &empty}, 0)
+
+		assert.ErrorIs(t, err, service.ErrInvalidTodo)
+		mockRepo.AssertNotCalled(t, "UpdateToDo", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TestDiffRevisions", func(t *testing.T) {
+		mockRepo.On("GetRevision", mock.Anything, 1, 3, 1).Return(first, nil)
+		mockRepo.On("GetRevision", mock.Anything, 1, 3, 2).Return(second, nil)
+
+		changes, err := todos.DiffRevisions(context.Background(), 1, 3, 1, 2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.FieldChange{
+			{Field: "title", From: "Draft", To: "Final"},
+			{Field: "datetime", From: due, To: due.Add(time.Hour)},
+		}, changes)
+	})
+
+	t.Run("TestRevertToDo_WritesRevisionFields", func(t *testing.T) {
+		mockRepo.On("UpdateToDo", mock.Anything, 1, 3, mock.MatchedBy(func(update entity.TodoUpdate) bool {
+			return *update.Title == "Draft" && *update.Description == "same" && update.DateTime.Equal(due)
+		}), 0).Return(entity.ToDo{ToDoID: 3, Title: "Draft"}, nil)
+
+		todo, err := todos.RevertToDo(context.Background(), 1, 3, 1, 0)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Draft", todo.Title)
+	})
+}
+
+func TestTodoVersionConflict(t *testing.T) {
+	mockRepo := new(mocks.MockToDoRepository)
+	todos := service.NewTodoService(mockRepo)
+	title := "Stale"
+
+	mockRepo.On("UpdateToDo", mock.Anything, 1, 3, mock.Anything, 1).Return(entity.ToDo{}, repository.ErrVersionConflict)
+	mockRepo.On("GetTodo", mock.Anything, 1, 3).Return(entity.ToDo{ToDoID: 3, Version: 4}, nil)
+
+	_, err := todos.UpdateToDo(context.Background(), 1, 3, entity.TodoUpdate{Title: &title}, 1)
+
+	var conflict *service.ConflictError
+	assert.ErrorAs(t, err, &conflict)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	assert.Equal(t, 4, conflict.Current.Version)
 }
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/srikanthbhandary/todo-server/repository"
@@ -16,8 +18,24 @@ type UserService interface {
 	UpdateUser(ctx context.Context, user *entity.User) error
 	DeleteUser(ctx context.Context, userID int) error
 	CheckPasswordHash(password, hash string) bool
+	ValidateSession(ctx context.Context, userID int, issuedAt time.Time) (*entity.User, error)
+	ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error)
+	SetUserDisabled(ctx context.Context, userID int, disabled bool) error
+	SetUserRole(ctx context.Context, userID int, role string) error
+	SetPassword(ctx context.Context, userID int, password string) error
 }
 
+var (
+	// ErrSessionRevoked is returned for tokens issued before the user's sessions were revoked.
+	ErrSessionRevoked = errors.New("session has been revoked")
+
+	// ErrAccountDisabled is returned for accounts an administrator has disabled.
+	ErrAccountDisabled = errors.New("account is disabled")
+
+	// ErrInvalidRole is returned when assigning a role that does not exist.
+	ErrInvalidRole = errors.New("invalid role")
+)
+
 // UserServiceImpl is the implementation of UserService interface
 type UserServiceImpl struct {
 	repo repository.UserRepository // Assuming you have a repository interface for users
@@ -38,6 +56,11 @@ func (s *UserServiceImpl) CreateUser(ctx context.Context, user *entity.User) err
 	}
 	user.Password = string(hashedPassword) // Store the hashed password
 	fmt.Println("Stored password", user.Password)
+
+	// Privileges are never taken from the signup request
+	user.Role = entity.RoleUser
+	user.Disabled = false
+	user.EmailVerified = false
 	return s.repo.CreateUser(ctx, user) // Call the repository to add the user
 }
 
@@ -75,3 +98,51 @@ func (s *UserServiceImpl) CheckPasswordHash(password, hash string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 	return err == nil
 }
+
+// ValidateSession checks that a token still represents a usable session and returns its user.
+// Tokens issued before the user's sessions were last revoked are rejected, as are disabled accounts.
+func (s *UserServiceImpl) ValidateSession(ctx context.Context, userID int, issuedAt time.Time) (*entity.User, error) {
+	// A lagging replica could still accept a revoked session or a disabled account.
+	user, err := s.repo.GetUserByID(repository.WithPrimary(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrAccountDisabled
+	}
+	// Token timestamps only carry whole seconds.
+	if !user.SessionsRevokedAt.IsZero() && issuedAt.Before(user.SessionsRevokedAt.Truncate(time.Second)) {
+		return nil, ErrSessionRevoked
+	}
+	return user, nil
+}
+
+// ListUsers returns a page of users matching search, or all users when search is empty
+func (s *UserServiceImpl) ListUsers(ctx context.Context, search string, limit, offset int) ([]entity.User, error) {
+	return s.repo.ListUsers(ctx, search, limit, offset)
+}
+
+// SetUserDisabled enables or disables an account; disabled accounts cannot log in or use existing tokens
+func (s *UserServiceImpl) SetUserDisabled(ctx context.Context, userID int, disabled bool) error {
+	return s.repo.SetDisabled(ctx, userID, disabled)
+}
+
+// SetUserRole assigns one of the known roles to a user
+func (s *UserServiceImpl) SetUserRole(ctx context.Context, userID int, role string) error {
+	if !entity.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	return s.repo.SetRole(ctx, userID, role)
+}
+
+// SetPassword replaces a user's password and revokes their existing sessions
+func (s *UserServiceImpl) SetPassword(ctx context.Context, userID int, password string) error {
+	if password == "" {
+		return ErrEmptyPassword
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdatePassword(ctx, userID, string(hashedPassword))
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 	"github.com/srikanthbhandary/todo-server/mocks"
@@ -70,4 +71,31 @@ func TestUserService(t *testing.T) {
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
+
+	t.Run("TestValidateSession_Revoked", func(t *testing.T) {
+		revokedAt := time.Now()
+		user := &entity.User{UserID: 2, SessionsRevokedAt: revokedAt}
+
+		mockRepo.On("GetUserByID", mock.Anything, 2).Return(user, nil)
+
+		_, err := service.ValidateSession(context.Background(), 2, revokedAt.Add(-time.Hour))
+		assert.ErrorIs(t, err, ErrSessionRevoked)
+
+		result, err := service.ValidateSession(context.Background(), 2, revokedAt.Add(time.Second))
+		assert.NoError(t, err)
+		assert.Equal(t, user, result)
+	})
+
+	t.Run("TestValidateSession_Disabled", func(t *testing.T) {
+		mockRepo.On("GetUserByID", mock.Anything, 3).Return(&entity.User{UserID: 3, Disabled: true}, nil)
+
+		_, err := service.ValidateSession(context.Background(), 3, time.Now())
+		assert.ErrorIs(t, err, ErrAccountDisabled)
+	})
+
+	t.Run("TestSetUserRole_Invalid", func(t *testing.T) {
+		err := service.SetUserRole(context.Background(), 1, "superuser")
+		assert.ErrorIs(t, err, ErrInvalidRole)
+		mockRepo.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything, mock.Anything)
+	})
 }
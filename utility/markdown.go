@@ -0,0 +1,46 @@
+package utility
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+var (
+	markdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+	// htmlPolicy allows the formatting user content needs and strips scripts, styles and event handlers
+	htmlPolicy = bluemonday.UGCPolicy()
+
+	// mentionPattern matches @username when it starts a word, so email addresses are not mentions
+	mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9_][A-Za-z0-9_.-]*)`)
+)
+
+// RenderMarkdown converts user-supplied Markdown into HTML that is safe to embed in a page.
+// Raw HTML in the source is sanitized rather than trusted.
+func RenderMarkdown(source string) string {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return htmlPolicy.Sanitize(source)
+	}
+	return htmlPolicy.Sanitize(buf.String())
+}
+
+// ParseMentions returns the distinct usernames mentioned as @username, in order of appearance
+func ParseMentions(text string) []string {
+	seen := make(map[string]bool)
+	var mentions []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		mentions = append(mentions, name)
+	}
+	return mentions
+}
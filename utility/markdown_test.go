@@ -0,0 +1,22 @@
+package utility
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	html := RenderMarkdown("**ship it** <script>alert(1)</script> [link](javascript:alert(1))")
+
+	assert.Contains(t, html, "<strong>ship it</strong>")
+	assert.NotContains(t, html, "<script")
+	assert.False(t, strings.Contains(html, "javascript:"))
+}
+
+func TestParseMentions(t *testing.T) {
+	mentions := ParseMentions("@alice can you check with @bob.smith? cc @alice, not me@example.com")
+
+	assert.Equal(t, []string{"alice", "bob.smith"}, mentions)
+}
@@ -1,8 +1,10 @@
 package worker
 
 import (
-	"log"
+	"log/slog"
 	"time"
+
+	"github.com/srikanthbhandary/todo-server/entity"
 )
 
 type EmailSender interface {
@@ -25,7 +27,7 @@ type Notification struct {
 // Process implements the Job interface for Notification.
 // It simulates sending a notification by logging the title and adding a delay to simulate work.
 func (n *Notification) Process() error {
-	log.Println("sending the notification ", n.title) // Log the notification being sent.
+	slog.Debug("sending the notification", "title", n.title) // Log the notification being sent.
 	// Simulate a delay in processing (e.g., sending the notification might take time).
 	time.Sleep(1 * time.Second)
 	return nil // Return nil to indicate the job was processed without error.
@@ -53,3 +55,17 @@ func (ej *EmailJob) Process() error {
 	// Use the injected emailSender to send the email.
 	return ej.emailSender.SendEmail(ej.toAddress, ej.subject, ej.body)
 }
+
+// UserNotificationJob pushes a message to every open WebSocket connection of a user.
+type UserNotificationJob struct {
+	Connections *entity.WebSocketRegistry
+	UserID      int
+	Message     []byte
+}
+
+// Process implements the Job interface for UserNotificationJob.
+// Users without an open connection simply miss the push; email covers them.
+func (nj *UserNotificationJob) Process() error {
+	nj.Connections.SendToUser(nj.UserID, nj.Message)
+	return nil
+}
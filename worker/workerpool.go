@@ -2,17 +2,22 @@ package worker
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"sync"
 
 	"github.com/srikanthbhandary/todo-server/entity"
 )
 
 type WorkerPool struct {
-	numOfWorkers int            // The number of workers (goroutines) that will be processing jobs.
-	wg           sync.WaitGroup // WaitGroup to keep track of active workers and ensure they complete their tasks before shutdown.
-	inputChannel chan Job       // The channel through which jobs are submitted for processing. Workers will consume jobs from this channel.
+	mu           sync.Mutex                // Guards numOfWorkers, ctx and workers while the pool is resized.
+	numOfWorkers int                       // The number of workers (goroutines) that will be processing jobs.
+	ctx          context.Context           // The context the pool was started with, nil until Init.
+	workers      []context.CancelCauseFunc // Stops each running worker, so that shrinking retires exactly those.
+	wg           sync.WaitGroup            // WaitGroup to keep track of active workers and ensure they complete their tasks before shutdown.
+	inputChannel chan Job                  // The channel through which jobs are submitted for processing. Workers will consume jobs from this channel.
 	WebSocket    *entity.WebSocketConnection
+	Connections  *entity.WebSocketRegistry // Per-user connections for targeted notifications
 }
 
 // NewWorkerPool creates a new WorkerPool
@@ -20,9 +25,13 @@ func NewWorkerPool(numOfWorkers int, inputChannel chan Job) *WorkerPool {
 	return &WorkerPool{
 		numOfWorkers: numOfWorkers,
 		inputChannel: inputChannel,
+		Connections:  entity.NewWebSocketRegistry(),
 	}
 }
 
+// errWorkerRetired cancels the context of a worker that is no longer needed
+var errWorkerRetired = errors.New("worker retired")
+
 // StartWorker processes jobs in the input channel
 func (wp *WorkerPool) StartWorker(ctx context.Context) {
 	defer wp.wg.Done()
@@ -30,16 +39,20 @@ func (wp *WorkerPool) StartWorker(ctx context.Context) {
 	for {
 		select {
 		case <-ctx.Done():
+			if context.Cause(ctx) == errWorkerRetired {
+				slog.Debug("worker retired as the pool shrank")
+				return
+			}
 			// Context cancelled, exit worker
-			log.Println("worker exiting due to cancellation")
+			slog.Info("worker exiting due to cancellation")
 			return
 		case job, ok := <-wp.inputChannel:
 			if !ok {
 				return
 			}
-			log.Println("JOB Received")
+			slog.Debug("job received")
 			if err := job.Process(); err != nil {
-				log.Printf("error processing job: %v", err)
+				slog.Error("error processing job", "error", err)
 			}
 
 		}
@@ -48,11 +61,50 @@ func (wp *WorkerPool) StartWorker(ctx context.Context) {
 
 // Init initializes and starts the workers
 func (wp *WorkerPool) Init(ctx context.Context) {
-	for i := 0; i < wp.numOfWorkers; i++ {
-		wp.wg.Add(1)
-		log.Println("starting worker", i+1)
-		go wp.StartWorker(ctx)
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.ctx = ctx
+	for len(wp.workers) < wp.numOfWorkers {
+		wp.startWorker()
+	}
+}
+
+// Resize changes the number of workers of the pool. Workers that are no longer needed exit once
+// they have finished their current job. Before Init it only sets how many workers Init starts.
+func (wp *WorkerPool) Resize(numOfWorkers int) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	wp.numOfWorkers = numOfWorkers
+	if wp.ctx == nil {
+		return
+	}
+	for len(wp.workers) < numOfWorkers {
+		wp.startWorker()
 	}
+	for len(wp.workers) > numOfWorkers {
+		// A busy worker sees the cancellation when it is done, so resizing does not wait for it
+		last := len(wp.workers) - 1
+		wp.workers[last](errWorkerRetired)
+		wp.workers = wp.workers[:last]
+	}
+}
+
+// startWorker starts a worker with its own context, so that it can be retired alone; wp.mu must be held
+func (wp *WorkerPool) startWorker() {
+	slog.Info("starting worker", "worker", len(wp.workers)+1)
+	ctx, cancel := context.WithCancelCause(wp.ctx)
+	wp.workers = append(wp.workers, cancel)
+	wp.wg.Add(1)
+	go wp.StartWorker(ctx)
+}
+
+// Size returns the number of workers the pool is meant to have
+func (wp *WorkerPool) Size() int {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+	return wp.numOfWorkers
 }
 
 // Stop signals the workers to stop gracefully
@@ -0,0 +1,101 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingJob holds a worker until release is closed
+type blockingJob struct {
+	started *sync.WaitGroup
+	release chan struct{}
+}
+
+func (j *blockingJob) Process() error {
+	j.started.Done()
+	<-j.release
+	return nil
+}
+
+// busyWorkers checks that the pool runs the expected number of jobs at once
+func busyWorkers(t *testing.T, pool *WorkerPool, expected int) {
+	t.Helper()
+	close(occupyWorkers(t, pool, expected))
+}
+
+// occupyWorkers waits for the pool to run the expected number of jobs at once and returns the
+// channel that ends them once closed
+func occupyWorkers(t *testing.T, pool *WorkerPool, expected int) chan struct{} {
+	t.Helper()
+	var started sync.WaitGroup
+	started.Add(expected)
+	release := make(chan struct{})
+	for i := 0; i < expected; i++ {
+		go pool.EnqueueJob(&blockingJob{started: &started, release: release})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		started.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected %d jobs to run at once", expected)
+	}
+	return release
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool := NewWorkerPool(1, make(chan Job))
+	pool.Init(ctx)
+
+	pool.Resize(3)
+	if pool.Size() != 3 {
+		t.Fatalf("expected 3 workers, got %d", pool.Size())
+	}
+	busyWorkers(t, pool, 3)
+
+	pool.Resize(1)
+	if pool.Size() != 1 {
+		t.Fatalf("expected 1 worker, got %d", pool.Size())
+	}
+
+	pool.Stop()
+	pool.Wait()
+}
+
+func TestWorkerPoolResize_GrowAfterShrink(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool := NewWorkerPool(3, make(chan Job))
+	pool.Init(ctx)
+
+	// The retired workers are still busy when the pool grows again
+	release := occupyWorkers(t, pool, 3)
+	pool.Resize(1)
+	pool.Resize(3)
+	close(release)
+
+	busyWorkers(t, pool, 3)
+	pool.Stop()
+	pool.Wait()
+}
+
+func TestWorkerPoolResize_BeforeInit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pool := NewWorkerPool(1, make(chan Job))
+
+	pool.Resize(2)
+	pool.Init(ctx)
+
+	busyWorkers(t, pool, 2)
+	pool.Stop()
+	pool.Wait()
+}